@@ -0,0 +1,254 @@
+// Command admin runs maintenance tasks against the application database.
+//
+// Usage:
+//
+//	admin products-import [-format csv|jsonl] [-batch-size n] FILE
+//	admin products-export [-format csv|jsonl] [-o FILE]
+//	admin inventory-reconcile [-repair ledger|column]
+//
+// FILE may be "-" for standard input/output. Logs go to standard error.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/example/clean-arch-template/config"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/database"
+	"github.com/example/clean-arch-template/internal/infrastructure/mail"
+	"github.com/example/clean-arch-template/internal/infrastructure/notify"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/logger"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, env *environment, args []string) error
+}
+
+// environment holds what commands share
+type environment struct {
+	db          *gorm.DB
+	stockAlerts *usecase.StockAlertUseCase
+}
+
+var commands = []command{
+	{"products-import", "Upsert products by SKU from a CSV or JSON Lines file", importProducts},
+	{"products-export", "Write the full catalog as CSV or JSON Lines", exportProducts},
+	{"inventory-reconcile", "Report (and repair) stock that differs from the inventory ledger", reconcileInventory},
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	// Standard output is reserved for command output such as exports
+	slog.SetDefault(logger.New(os.Stderr, cfg.Log.Level, cfg.Log.Format))
+
+	db, err := database.NewPostgresConnection(&cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	db.Logger = gormlogger.New(log.New(os.Stderr, "", log.LstdFlags), gormlogger.Config{
+		SlowThreshold: time.Second,
+		LogLevel:      gormlogger.Warn,
+	})
+
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize notifier:", err)
+	}
+	env := &environment{
+		db:          db,
+		stockAlerts: usecase.NewStockAlertUseCase(persistence.NewProductRepository(db), persistence.NewStockAlertRepository(db), notifier),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, env, os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", cmd.name, err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", filepath.Base(os.Args[0]))
+}
+
+// newNotifier builds the low-stock alert notifier configured for the API, so
+// stock changed by commands raises the same alerts
+func newNotifier(cfg *config.Config) (gateway.Notifier, error) {
+	switch cfg.Alerts.Notifier {
+	case "webhook":
+		return notify.NewWebhookNotifier(cfg.Alerts.WebhookURL, cfg.Alerts.WebhookTimeout), nil
+	case "email":
+		mailer, err := newMailer(cfg.Mail)
+		if err != nil {
+			return nil, err
+		}
+		return notify.NewEmailNotifier(mailer, cfg.Alerts.EmailTo), nil
+	default:
+		return notify.NewLogNotifier(slog.Default()), nil
+	}
+}
+
+func newMailer(cfg config.MailConfig) (gateway.Mailer, error) {
+	if cfg.Driver == "smtp" {
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword.Value(), cfg.From), nil
+	}
+	return mail.NewFileMailer(cfg.FileDir, cfg.From)
+}
+
+func importProducts(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("products-import", flag.ExitOnError)
+	format := fs.String("format", "", "file format: csv or jsonl (default: from the file extension)")
+	batchSize := fs.Int("batch-size", usecase.DefaultImportBatchSize, "rows per transaction")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one FILE argument")
+	}
+
+	path := fs.Arg(0)
+	catalogFormat, err := catalogFormat(*format, path)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	report, err := usecase.NewProductImportUseCase(env.db, env.stockAlerts).Import(ctx, in, catalogFormat, *batchSize)
+	if report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Total)
+	}
+	return nil
+}
+
+func exportProducts(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("products-export", flag.ExitOnError)
+	format := fs.String("format", "", "file format: csv or jsonl (default: from the output extension, else csv)")
+	output := fs.String("o", "-", "output file")
+	fs.Parse(args)
+
+	catalogFormat, err := catalogFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	out := io.WriteCloser(os.Stdout)
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+	}
+
+	if err := usecase.NewProductImportUseCase(env.db, env.stockAlerts).Export(ctx, out, catalogFormat); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func reconcileInventory(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("inventory-reconcile", flag.ExitOnError)
+	repair := fs.String("repair", "", "ledger: append adjustments matching the warehouse stock; column: overwrite the warehouse stock from the ledger")
+	fs.Parse(args)
+
+	mode := usecase.ReconcileRepair(*repair)
+	switch mode {
+	case usecase.ReconcileReportOnly, usecase.ReconcileRepairLedger, usecase.ReconcileRepairColumn:
+	default:
+		return fmt.Errorf("invalid -repair %q: must be ledger or column", *repair)
+	}
+
+	inventoryUseCase := usecase.NewInventoryUseCase(
+		persistence.NewProductRepository(env.db),
+		persistence.NewProductVariantRepository(env.db),
+		persistence.NewWarehouseRepository(env.db),
+		persistence.NewInventoryRepository(env.db),
+		env.stockAlerts,
+	)
+	drift, err := inventoryUseCase.Reconcile(ctx, mode)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(drift)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("inventory reconciled", "drifted", len(drift), "repair", *repair)
+	if len(drift) > 0 && mode == usecase.ReconcileReportOnly {
+		return fmt.Errorf("%d stock levels differ from the ledger", len(drift))
+	}
+	return nil
+}
+
+// catalogFormat returns the explicit format, or the one implied by the file
+// extension, defaulting to CSV
+func catalogFormat(explicit, path string) (usecase.CatalogFormat, error) {
+	if explicit != "" {
+		return usecase.ParseCatalogFormat(explicit)
+	}
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" || path == "-" {
+		return usecase.CatalogFormatCSV, nil
+	}
+	return usecase.ParseCatalogFormat(ext)
+}
+
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
@@ -1,23 +1,45 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/example/clean-arch-template/config"
 	"github.com/example/clean-arch-template/internal/delivery/http"
 	"github.com/example/clean-arch-template/internal/delivery/http/handler"
+	"github.com/example/clean-arch-template/internal/gateway"
 	"github.com/example/clean-arch-template/internal/infrastructure/database"
+	"github.com/example/clean-arch-template/internal/infrastructure/mail"
+	"github.com/example/clean-arch-template/internal/infrastructure/notify"
 	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/infrastructure/storage"
 	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/logger"
+	"github.com/example/clean-arch-template/pkg/ratelimit"
+	"github.com/example/clean-arch-template/pkg/scheduler"
+	"github.com/example/clean-arch-template/pkg/token"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
 	// Load environment variables from .env file (optional)
 	_ = godotenv.Load()
 
-	// Load configuration
-	cfg := config.LoadConfig()
+	// Load configuration (defaults, optional CONFIG_FILE, then env overrides)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	// Configure logging; the standard logger is routed through slog as well
+	slog.SetDefault(logger.New(os.Stdout, cfg.Log.Level, cfg.Log.Format))
+	slog.Debug("Configuration loaded", "config", cfg.String())
 
 	// Initialize database connection
 	db, err := database.NewPostgresConnection(&cfg.Database)
@@ -35,25 +57,256 @@ func main() {
 	// They will be re-created with transaction (tx) when needed in use cases
 	userRepo := persistence.NewUserRepository(db)
 	productRepo := persistence.NewProductRepository(db)
+	categoryRepo := persistence.NewCategoryRepository(db)
+	tagRepo := persistence.NewTagRepository(db)
+	variantRepo := persistence.NewProductVariantRepository(db)
+	imageRepo := persistence.NewProductImageRepository(db)
+	warehouseRepo := persistence.NewWarehouseRepository(db)
+	inventoryRepo := persistence.NewInventoryRepository(db)
+	stockAlertRepo := persistence.NewStockAlertRepository(db)
+	couponRepo := persistence.NewCouponRepository(db)
+	taxRateRepo := persistence.NewTaxRateRepository(db)
+	shippingRateRepo := persistence.NewShippingRateRepository(db)
+	loginAttemptRepo := persistence.NewLoginAttemptRepository(db)
+	userTokenRepo := persistence.NewUserTokenRepository(db)
+	auditRepo := persistence.NewAuditRepository(db)
+
+	// Initialize external services
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer:", err)
+	}
+	blobStorage, err := newBlobStorage(cfg.Media)
+	if err != nil {
+		log.Fatal("Failed to initialize media storage:", err)
+	}
+	invoiceStorage, err := newInvoiceStorage(cfg.Invoices, cfg.Media)
+	if err != nil {
+		log.Fatal("Failed to initialize invoice storage:", err)
+	}
+	notifier := newNotifier(cfg.Alerts, mailer)
 
 	// Initialize Use Cases
-	userUseCase := usecase.NewUserUseCase(userRepo)
-	productUseCase := usecase.NewProductUseCase(productRepo)
+	userUseCase := usecase.NewUserUseCase(db, userRepo, loginAttemptRepo, userTokenRepo, mailer, usecase.UserUseCaseConfig{
+		Lockout: usecase.LockoutPolicy{
+			MaxFailedAttempts: cfg.Auth.MaxFailedLogins,
+			FailureWindow:     cfg.Auth.LoginFailureWindow,
+			LockoutDuration:   cfg.Auth.LockoutDuration,
+			DelayBase:         cfg.Auth.LoginDelayBase,
+			DelayMax:          cfg.Auth.LoginDelayMax,
+		},
+		VerificationTokenTTL:  cfg.Auth.VerificationTokenTTL,
+		PasswordResetTokenTTL: cfg.Auth.PasswordResetTokenTTL,
+		LinkBaseURL:           cfg.Mail.LinkBaseURL,
+	})
+	stockAlertUseCase := usecase.NewStockAlertUseCase(productRepo, stockAlertRepo, notifier)
+	productUseCase := usecase.NewProductUseCase(db, productRepo, categoryRepo, tagRepo, variantRepo, inventoryRepo, stockAlertUseCase)
+	inventoryUseCase := usecase.NewInventoryUseCase(productRepo, variantRepo, warehouseRepo, inventoryRepo, stockAlertUseCase)
+	warehouseUseCase := usecase.NewWarehouseUseCase(warehouseRepo)
+	categoryUseCase := usecase.NewCategoryUseCase(categoryRepo)
+	couponUseCase := usecase.NewCouponUseCase(couponRepo, productRepo)
+	pricingUseCase := usecase.NewPricingUseCase(taxRateRepo, shippingRateRepo, categoryRepo)
+	auditUseCase := usecase.NewAuditUseCase(auditRepo)
+	mediaUseCase := usecase.NewProductMediaUseCase(productRepo, imageRepo, blobStorage, usecase.MediaPolicy{
+		MaxUploadSize: int64(cfg.Media.MaxUploadSize),
+		MaxPixels:     int64(cfg.Media.MaxPixels),
+		ThumbnailSize: cfg.Media.ThumbnailSize,
+	})
+
+	// OrderUseCase, AccountUseCase, ProductImportUseCase, CartUseCase, AddressUseCase, ShipmentUseCase,
+	// InvoiceUseCase, PaymentUseCase and the job queue receive the DB instance directly for
+	// transaction management
+	jobQueue := usecase.NewJobQueue(db, usecase.JobPolicy{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+		BackoffBase:  cfg.Jobs.BackoffBase,
+		BackoffMax:   cfg.Jobs.BackoffMax,
+		Timeout:      cfg.Jobs.Timeout,
+	})
+	jobUseCase := usecase.NewJobUseCase(db)
+	webhookUseCase := usecase.NewWebhookUseCase(db, notify.NewWebhookSender(cfg.Webhooks.Timeout), jobQueue)
+	// Events are sent to webhook subscribers as part of the change they
+	// describe, and logged once it is committed
+	events := notify.NewLogPublisher(slog.Default())
+	invoiceUseCase := usecase.NewInvoiceUseCase(db, invoiceStorage, usecase.InvoiceIssuer{
+		Name:     cfg.Invoices.SellerName,
+		Address:  cfg.Invoices.SellerAddress,
+		TaxID:    cfg.Invoices.SellerTaxID,
+		Currency: cfg.Invoices.Currency,
+	}, jobQueue)
+	paymentUseCase := usecase.NewPaymentUseCase(db, invoiceUseCase, webhookUseCase, events)
+	orderUseCase := usecase.NewOrderUseCase(db, stockAlertUseCase, invoiceUseCase, webhookUseCase, events)
+	accountUseCase := usecase.NewAccountUseCase(db)
+	importUseCase := usecase.NewProductImportUseCase(db, stockAlertUseCase)
+	cartUseCase := usecase.NewCartUseCase(db, orderUseCase, usecase.CartPolicy{
+		InactivityTTL: cfg.Cart.InactivityTTL,
+	})
+	addressUseCase := usecase.NewAddressUseCase(db)
+	shipmentUseCase := usecase.NewShipmentUseCase(db)
 
-	// OrderUseCase receives the DB instance directly for transaction management
-	orderUseCase := usecase.NewOrderUseCase(db)
+	// Access tokens for authenticated routes
+	tokens := token.NewManager(cfg.Auth.JWTSecret.Value(), cfg.Auth.TokenTTL)
 
 	// Initialize Handlers
-	userHandler := handler.NewUserHandler(userUseCase)
-	productHandler := handler.NewProductHandler(productUseCase)
-	orderHandler := handler.NewOrderHandler(orderUseCase)
+	userHandler := handler.NewUserHandler(userUseCase, accountUseCase, cartUseCase, tokens)
+	addressHandler := handler.NewAddressHandler(addressUseCase)
+	productHandler := handler.NewProductHandler(productUseCase, mediaUseCase, importUseCase)
+	categoryHandler := handler.NewCategoryHandler(categoryUseCase, productUseCase)
+	inventoryHandler := handler.NewInventoryHandler(inventoryUseCase, warehouseUseCase, stockAlertUseCase)
+	cartHandler := handler.NewCartHandler(cartUseCase)
+	couponHandler := handler.NewCouponHandler(couponUseCase)
+	pricingHandler := handler.NewPricingHandler(pricingUseCase)
+	orderHandler := handler.NewOrderHandler(orderUseCase, shipmentUseCase, paymentUseCase, invoiceUseCase)
+	jobHandler := handler.NewJobHandler(jobUseCase)
+	webhookHandler := handler.NewWebhookHandler(webhookUseCase)
+	auditHandler := handler.NewAuditHandler(auditUseCase)
+
+	// Rate limit store shared by all route groups
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rateLimitStore := newRateLimitStore(ctx, cfg.RateLimit)
+
+	// Background jobs; advisory locks keep each run to one instance
+	locker, err := database.NewAdvisoryLocker(db)
+	if err != nil {
+		log.Fatal("Failed to initialize job locks:", err)
+	}
+	jobs := scheduler.New(locker, slog.Default())
+	jobs.Add(scheduler.Job{
+		Name:     "purge-expired-carts",
+		Interval: cfg.Cart.CleanupInterval,
+		Run: func(ctx context.Context) error {
+			deleted, err := cartUseCase.PurgeExpired(ctx)
+			if deleted > 0 {
+				slog.Info("purged expired carts", "count", deleted)
+			}
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "expire-pending-orders",
+		Interval: cfg.Orders.ExpiryInterval,
+		Run: func(ctx context.Context) error {
+			cancelled, err := orderUseCase.ExpirePendingOrders(ctx, cfg.Orders.PendingTTL)
+			if cancelled > 0 {
+				slog.Info("cancelled expired pending orders", "count", cancelled)
+			}
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "release-stale-jobs",
+		Interval: cfg.Jobs.Timeout,
+		Run: func(ctx context.Context) error {
+			released, err := jobQueue.ReleaseStale(ctx)
+			if released > 0 {
+				slog.Warn("released jobs of unresponsive workers", "count", released)
+			}
+			return err
+		},
+	})
+	jobs.Start(ctx)
+
+	// Queued jobs run on a pool of workers
+	jobQueue.Start(ctx)
 
 	// Setup Router
-	app := http.SetupRouter(userHandler, productHandler, orderHandler)
+	app := http.SetupRouter(cfg, rateLimitStore, tokens, userHandler, addressHandler, productHandler, categoryHandler, inventoryHandler, cartHandler, couponHandler, pricingHandler, orderHandler, jobHandler, webhookHandler, auditHandler)
+
+	// Shut down gracefully on SIGINT/SIGTERM
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		<-quit
+		log.Println("Shutting down server...")
+		if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+			log.Printf("Server shutdown error: %v", err)
+		}
+	}()
 
 	// Start server
 	log.Printf("Server starting on port %s...", cfg.Server.Port)
 	if err := app.Listen(":" + cfg.Server.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
+
+	// Let running jobs finish before exiting
+	cancel()
+	jobs.Wait()
+	jobQueue.Wait()
+}
+
+// newRateLimitStore builds the configured rate limit store. The memory store
+// is per instance; the redis store shares limits across instances.
+func newRateLimitStore(ctx context.Context, cfg config.RateLimitConfig) ratelimit.Store {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Store == "redis" {
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword.Value(),
+			DB:       cfg.RedisDB,
+		})
+		return ratelimit.NewRedisStore(client, "ratelimit:")
+	}
+	return ratelimit.NewMemoryStore(ctx, time.Minute)
+}
+
+// newMailer builds the configured mailer
+func newMailer(cfg config.MailConfig) (gateway.Mailer, error) {
+	if cfg.Driver == "smtp" {
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword.Value(), cfg.From), nil
+	}
+	return mail.NewFileMailer(cfg.FileDir, cfg.From)
+}
+
+// newNotifier builds the configured low-stock alert notifier
+func newNotifier(cfg config.AlertsConfig, mailer gateway.Mailer) gateway.Notifier {
+	switch cfg.Notifier {
+	case "webhook":
+		return notify.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookTimeout)
+	case "email":
+		return notify.NewEmailNotifier(mailer, cfg.EmailTo)
+	default:
+		return notify.NewLogNotifier(slog.Default())
+	}
+}
+
+// newBlobStorage builds the configured storage for product media
+func newBlobStorage(cfg config.MediaConfig) (gateway.BlobStorage, error) {
+	if cfg.Driver == "s3" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return storage.NewS3Storage(ctx, storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey.Value(),
+			UseSSL:    cfg.S3UseSSL,
+			PublicURL: cfg.PublicURL,
+		})
+	}
+	return storage.NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+}
+
+// newInvoiceStorage builds the private storage for invoice PDFs. The s3
+// driver reaches its bucket with the media S3 settings.
+func newInvoiceStorage(cfg config.InvoiceConfig, media config.MediaConfig) (gateway.BlobStorage, error) {
+	if cfg.Driver == "s3" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return storage.NewS3Storage(ctx, storage.S3Config{
+			Endpoint:  media.S3Endpoint,
+			Region:    media.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: media.S3AccessKey,
+			SecretKey: media.S3SecretKey.Value(),
+			UseSSL:    media.S3UseSSL,
+		})
+	}
+	// Invoices are only served through the API, so they have no public URL
+	return storage.NewLocalStorage(cfg.LocalDir, "")
 }
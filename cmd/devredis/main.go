@@ -0,0 +1,52 @@
+// Command devredis runs an in-memory, Redis-compatible server (miniredis)
+// for local development, so the shared rate limit store can be used
+// without installing Redis. Data is lost when it stops.
+//
+// Usage:
+//
+//	devredis [-addr host:port]
+//
+// Then start the API with RATE_LIMIT_STORE=redis and RATE_LIMIT_REDIS_ADDR
+// set to the same address.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:6379", "address to listen on")
+	flag.Parse()
+
+	server := miniredis.NewMiniRedis()
+	if err := server.StartAddr(*addr); err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+	defer server.Close()
+	log.Printf("Redis-compatible stand-in listening on %s", server.Addr())
+
+	// miniredis only expires keys when its clock is moved forward
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	last := time.Now()
+	for {
+		select {
+		case <-quit:
+			log.Println("Shutting down")
+			return
+		case now := <-ticker.C:
+			server.FastForward(now.Sub(last))
+			last = now
+		}
+	}
+}
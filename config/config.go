@@ -2,55 +2,417 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	CORS      CORSConfig      `yaml:"cors" toml:"cors"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+	Media     MediaConfig     `yaml:"media" toml:"media"`
+	Alerts    AlertsConfig    `yaml:"alerts" toml:"alerts"`
+	Cart      CartConfig      `yaml:"cart" toml:"cart"`
+	Orders    OrdersConfig    `yaml:"orders" toml:"orders"`
+	Jobs      JobsConfig      `yaml:"jobs" toml:"jobs"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks" toml:"webhooks"`
+	Invoices  InvoiceConfig   `yaml:"invoices" toml:"invoices"`
+}
+
+type ServerConfig struct {
+	Port            string        `yaml:"port" toml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password Secret `yaml:"password" toml:"password"`
+	DBName   string `yaml:"dbname" toml:"dbname"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+
+	ConnectTimeout   time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" toml:"statement_timeout"`
+	LockTimeout      time.Duration `yaml:"lock_timeout" toml:"lock_timeout"`
+
+	ConnectRetries         int           `yaml:"connect_retries" toml:"connect_retries"`
+	ConnectRetryBackoff    time.Duration `yaml:"connect_retry_backoff" toml:"connect_retry_backoff"`
+	ConnectRetryMaxBackoff time.Duration `yaml:"connect_retry_max_backoff" toml:"connect_retry_max_backoff"`
+
+	// ReplicaHosts lists read replicas as "host" or "host:port". Replicas
+	// share the primary's credentials, database name and settings.
+	ReplicaHosts []string `yaml:"replica_hosts" toml:"replica_hosts"`
 }
 
-type ServerConfig struct {
-	Port string
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins" toml:"allow_origins"`
+}
+
+type AuthConfig struct {
+	JWTSecret Secret        `yaml:"jwt_secret" toml:"jwt_secret"`
+	TokenTTL  time.Duration `yaml:"token_ttl" toml:"token_ttl"`
+
+	// Login throttling per email: progressive delays after each failure and
+	// a temporary lockout after MaxFailedLogins failures
+	MaxFailedLogins    int           `yaml:"max_failed_logins" toml:"max_failed_logins"`
+	LoginFailureWindow time.Duration `yaml:"login_failure_window" toml:"login_failure_window"`
+	LockoutDuration    time.Duration `yaml:"lockout_duration" toml:"lockout_duration"`
+	LoginDelayBase     time.Duration `yaml:"login_delay_base" toml:"login_delay_base"`
+	LoginDelayMax      time.Duration `yaml:"login_delay_max" toml:"login_delay_max"`
+
+	VerificationTokenTTL  time.Duration `yaml:"verification_token_ttl" toml:"verification_token_ttl"`
+	PasswordResetTokenTTL time.Duration `yaml:"password_reset_token_ttl" toml:"password_reset_token_ttl"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+type MailConfig struct {
+	// Driver is "file" (write .eml files to FileDir) or "smtp"
+	Driver string `yaml:"driver" toml:"driver"`
+	From   string `yaml:"from" toml:"from"`
+
+	FileDir string `yaml:"file_dir" toml:"file_dir"`
+
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port" toml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword Secret `yaml:"smtp_password" toml:"smtp_password"`
+
+	// LinkBaseURL is the frontend URL that links in emails point to
+	LinkBaseURL string `yaml:"link_base_url" toml:"link_base_url"`
+}
+
+type MediaConfig struct {
+	// Driver is "local" (files under LocalDir, served at /media) or "s3"
+	// (any S3-compatible object store such as AWS S3 or MinIO)
+	Driver string `yaml:"driver" toml:"driver"`
+
+	// MaxUploadSize is the largest accepted image in bytes
+	MaxUploadSize int `yaml:"max_upload_size" toml:"max_upload_size"`
+	// MaxPixels is the largest accepted image area (width times height);
+	// it bounds the memory decoding takes, whatever the file size
+	MaxPixels int `yaml:"max_pixels" toml:"max_pixels"`
+	// ThumbnailSize is the longest side of generated thumbnails in pixels
+	ThumbnailSize int `yaml:"thumbnail_size" toml:"thumbnail_size"`
+
+	// PublicURL is the base URL stored objects are reachable under
+	PublicURL string `yaml:"public_url" toml:"public_url"`
+	LocalDir  string `yaml:"local_dir" toml:"local_dir"`
+
+	S3Endpoint  string `yaml:"s3_endpoint" toml:"s3_endpoint"`
+	S3Region    string `yaml:"s3_region" toml:"s3_region"`
+	S3Bucket    string `yaml:"s3_bucket" toml:"s3_bucket"`
+	S3AccessKey string `yaml:"s3_access_key" toml:"s3_access_key"`
+	S3SecretKey Secret `yaml:"s3_secret_key" toml:"s3_secret_key"`
+	S3UseSSL    bool   `yaml:"s3_use_ssl" toml:"s3_use_ssl"`
+}
+
+type AlertsConfig struct {
+	// Notifier delivers low-stock alerts: "log" (application log),
+	// "webhook" (JSON POST to WebhookURL) or "email" (to EmailTo through the
+	// configured mailer)
+	Notifier       string        `yaml:"notifier" toml:"notifier"`
+	WebhookURL     string        `yaml:"webhook_url" toml:"webhook_url"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" toml:"webhook_timeout"`
+	EmailTo        string        `yaml:"email_to" toml:"email_to"`
+}
+
+type CartConfig struct {
+	// InactivityTTL is how long a cart lives after its last change
+	InactivityTTL time.Duration `yaml:"inactivity_ttl" toml:"inactivity_ttl"`
+	// CleanupInterval is how often expired carts are deleted
+	CleanupInterval time.Duration `yaml:"cleanup_interval" toml:"cleanup_interval"`
+}
+
+type OrdersConfig struct {
+	// PendingTTL is how long an order may stay unpaid before it is
+	// cancelled and its stock restored
+	PendingTTL time.Duration `yaml:"pending_ttl" toml:"pending_ttl"`
+	// ExpiryInterval is how often expired orders are looked for
+	ExpiryInterval time.Duration `yaml:"expiry_interval" toml:"expiry_interval"`
+}
+
+type JobsConfig struct {
+	// Workers is how many jobs this instance runs at the same time
+	Workers int `yaml:"workers" toml:"workers"`
+	// PollInterval is how often idle workers look for due jobs
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// MaxAttempts is how often a job runs before it is dead, unless it was
+	// enqueued with its own limit
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// BackoffBase is the delay before the first retry; it doubles with every
+	// further attempt up to BackoffMax
+	BackoffBase time.Duration `yaml:"backoff_base" toml:"backoff_base"`
+	BackoffMax  time.Duration `yaml:"backoff_max" toml:"backoff_max"`
+	// Timeout is how long one attempt may run. Jobs running for twice as
+	// long are presumed lost with their worker and released.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+}
+
+type WebhooksConfig struct {
+	// Timeout is how long a subscriber has to respond to a webhook before
+	// the attempt fails
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+}
+
+type InvoiceConfig struct {
+	// Driver stores invoice PDFs: "local" (files under LocalDir) or "s3"
+	// (S3Bucket on the media S3 endpoint, with the media credentials).
+	// Invoices hold personal data, so the storage must not be public.
+	Driver   string `yaml:"driver" toml:"driver"`
+	LocalDir string `yaml:"local_dir" toml:"local_dir"`
+	S3Bucket string `yaml:"s3_bucket" toml:"s3_bucket"`
+
+	// Seller details printed on invoices; SellerAddress is printed one
+	// comma-separated part per line
+	SellerName    string `yaml:"seller_name" toml:"seller_name"`
+	SellerAddress string `yaml:"seller_address" toml:"seller_address"`
+	SellerTaxID   string `yaml:"seller_tax_id" toml:"seller_tax_id"`
+	// Currency is printed with every amount, e.g. "USD"
+	Currency string `yaml:"currency" toml:"currency"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// Store is either "memory" (per instance) or "redis" (shared)
+	Store         string `yaml:"store" toml:"store"`
+	RedisAddr     string `yaml:"redis_addr" toml:"redis_addr"`
+	RedisPassword Secret `yaml:"redis_password" toml:"redis_password"`
+	RedisDB       int    `yaml:"redis_db" toml:"redis_db"`
+
+	// Policies are keyed by route group name ("api", "auth", "users",
+	// "products", "orders"). Groups without a policy are not limited.
+	Policies map[string]RateLimitPolicy `yaml:"policies" toml:"policies"`
 }
 
-// LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
+type RateLimitPolicy struct {
+	Limit  int           `yaml:"limit" toml:"limit"`
+	Period time.Duration `yaml:"period" toml:"period"`
+	// KeyBy is "ip" or "user"; "user" falls back to the IP when anonymous
+	KeyBy string `yaml:"key_by" toml:"key_by"`
+}
+
+// Default returns the configuration used as the base layer before any file
+// or environment overrides are applied. Credentials have no defaults.
+func Default() *Config {
 	return &Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "clean_arch_db"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:            "localhost",
+			Port:            "5432",
+			SSLMode:         "disable",
+			MaxOpenConns:    100,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ConnectTimeout:   5 * time.Second,
+			StatementTimeout: 30 * time.Second,
+			LockTimeout:      10 * time.Second,
+
+			ConnectRetries:         5,
+			ConnectRetryBackoff:    time.Second,
+			ConnectRetryMaxBackoff: 30 * time.Second,
 		},
-		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+		},
+		Auth: AuthConfig{
+			TokenTTL:           24 * time.Hour,
+			MaxFailedLogins:    5,
+			LoginFailureWindow: 15 * time.Minute,
+			LockoutDuration:    15 * time.Minute,
+			LoginDelayBase:     time.Second,
+			LoginDelayMax:      30 * time.Second,
+
+			VerificationTokenTTL:  24 * time.Hour,
+			PasswordResetTokenTTL: time.Hour,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Mail: MailConfig{
+			Driver:      "file",
+			From:        "no-reply@example.com",
+			FileDir:     "tmp/mail",
+			SMTPPort:    "25",
+			LinkBaseURL: "http://localhost:3000",
+		},
+		Media: MediaConfig{
+			Driver:        "local",
+			MaxUploadSize: 5 << 20,
+			MaxPixels:     40_000_000,
+			ThumbnailSize: 320,
+			PublicURL:     "http://localhost:8080/media",
+			LocalDir:      "tmp/media",
+			S3Region:      "us-east-1",
+			S3UseSSL:      true,
+		},
+		Alerts: AlertsConfig{
+			Notifier:       "log",
+			WebhookTimeout: 5 * time.Second,
+		},
+		Cart: CartConfig{
+			InactivityTTL:   30 * 24 * time.Hour,
+			CleanupInterval: time.Hour,
+		},
+		Orders: OrdersConfig{
+			PendingTTL:     24 * time.Hour,
+			ExpiryInterval: 5 * time.Minute,
+		},
+		Jobs: JobsConfig{
+			Workers:      4,
+			PollInterval: time.Second,
+			MaxAttempts:  10,
+			BackoffBase:  10 * time.Second,
+			BackoffMax:   time.Hour,
+			Timeout:      5 * time.Minute,
+		},
+		Webhooks: WebhooksConfig{
+			Timeout: 10 * time.Second,
+		},
+		Invoices: InvoiceConfig{
+			Driver:     "local",
+			LocalDir:   "tmp/invoices",
+			SellerName: "Example Store",
+			Currency:   "USD",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Policies: map[string]RateLimitPolicy{
+				"api":  {Limit: 300, Period: time.Minute, KeyBy: "user"},
+				"auth": {Limit: 10, Period: time.Minute, KeyBy: "ip"},
+			},
 		},
 	}
 }
 
 // GetDSN returns PostgreSQL connection string
 func (c *DatabaseConfig) GetDSN() string {
-	return fmt.Sprintf(
+	return c.dsn(c.Host, c.Port)
+}
+
+// GetReplicaDSNs returns one connection string per configured read replica
+func (c *DatabaseConfig) GetReplicaDSNs() []string {
+	dsns := make([]string, 0, len(c.ReplicaHosts))
+	for _, replica := range c.ReplicaHosts {
+		host, port := replica, c.Port
+		if h, p, err := net.SplitHostPort(replica); err == nil {
+			host, port = h, p
+		}
+		dsns = append(dsns, c.dsn(host, port))
+	}
+	return dsns
+}
+
+func (c *DatabaseConfig) dsn(host, port string) string {
+	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode,
+		quoteDSNValue(host),
+		quoteDSNValue(port),
+		quoteDSNValue(c.User),
+		quoteDSNValue(c.Password.Value()),
+		quoteDSNValue(c.DBName),
+		quoteDSNValue(c.SSLMode),
 	)
+
+	// connect_timeout is in whole seconds; the other two are sent to the
+	// server as runtime parameters in milliseconds
+	if c.ConnectTimeout > 0 {
+		dsn += fmt.Sprintf(" connect_timeout=%d", int(math.Ceil(c.ConnectTimeout.Seconds())))
+	}
+	if c.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", c.StatementTimeout.Milliseconds())
+	}
+	if c.LockTimeout > 0 {
+		dsn += fmt.Sprintf(" lock_timeout=%d", c.LockTimeout.Milliseconds())
+	}
+	return dsn
+}
+
+// quoteDSNValue quotes a keyword/value connection string value as described
+// in the libpq documentation: single quotes and backslashes are escaped and
+// the whole value is wrapped in single quotes.
+func quoteDSNValue(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(v) + "'"
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// String renders the configuration with all secrets redacted so it can be
+// logged safely.
+func (c Config) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server: port=%s read_timeout=%s write_timeout=%s idle_timeout=%s shutdown_timeout=%s\n",
+		c.Server.Port, c.Server.ReadTimeout, c.Server.WriteTimeout, c.Server.IdleTimeout, c.Server.ShutdownTimeout)
+	fmt.Fprintf(&b, "database: host=%s port=%s user=%s password=%s dbname=%s sslmode=%s replica_hosts=%s\n",
+		c.Database.Host, c.Database.Port, c.Database.User, c.Database.Password, c.Database.DBName, c.Database.SSLMode,
+		strings.Join(c.Database.ReplicaHosts, ","))
+	fmt.Fprintf(&b, "database pool: max_open_conns=%d max_idle_conns=%d conn_max_lifetime=%s conn_max_idle_time=%s\n",
+		c.Database.MaxOpenConns, c.Database.MaxIdleConns, c.Database.ConnMaxLifetime, c.Database.ConnMaxIdleTime)
+	fmt.Fprintf(&b, "database timeouts: connect=%s statement=%s lock=%s retries=%d retry_backoff=%s retry_max_backoff=%s\n",
+		c.Database.ConnectTimeout, c.Database.StatementTimeout, c.Database.LockTimeout,
+		c.Database.ConnectRetries, c.Database.ConnectRetryBackoff, c.Database.ConnectRetryMaxBackoff)
+	fmt.Fprintf(&b, "cors: allow_origins=%s\n", strings.Join(c.CORS.AllowOrigins, ","))
+	fmt.Fprintf(&b, "auth: jwt_secret=%s token_ttl=%s max_failed_logins=%d login_failure_window=%s lockout_duration=%s login_delay_base=%s login_delay_max=%s\n",
+		c.Auth.JWTSecret, c.Auth.TokenTTL, c.Auth.MaxFailedLogins, c.Auth.LoginFailureWindow,
+		c.Auth.LockoutDuration, c.Auth.LoginDelayBase, c.Auth.LoginDelayMax)
+	fmt.Fprintf(&b, "auth tokens: verification_token_ttl=%s password_reset_token_ttl=%s\n",
+		c.Auth.VerificationTokenTTL, c.Auth.PasswordResetTokenTTL)
+	fmt.Fprintf(&b, "mail: driver=%s from=%s file_dir=%s smtp_host=%s smtp_port=%s smtp_username=%s smtp_password=%s link_base_url=%s\n",
+		c.Mail.Driver, c.Mail.From, c.Mail.FileDir, c.Mail.SMTPHost, c.Mail.SMTPPort, c.Mail.SMTPUsername,
+		c.Mail.SMTPPassword, c.Mail.LinkBaseURL)
+	fmt.Fprintf(&b, "media: driver=%s max_upload_size=%d max_pixels=%d thumbnail_size=%d public_url=%s local_dir=%s s3_endpoint=%s s3_region=%s s3_bucket=%s s3_access_key=%s s3_secret_key=%s s3_use_ssl=%t\n",
+		c.Media.Driver, c.Media.MaxUploadSize, c.Media.MaxPixels, c.Media.ThumbnailSize, c.Media.PublicURL, c.Media.LocalDir,
+		c.Media.S3Endpoint, c.Media.S3Region, c.Media.S3Bucket, c.Media.S3AccessKey, c.Media.S3SecretKey, c.Media.S3UseSSL)
+	fmt.Fprintf(&b, "alerts: notifier=%s webhook_url=%s webhook_timeout=%s email_to=%s\n",
+		c.Alerts.Notifier, c.Alerts.WebhookURL, c.Alerts.WebhookTimeout, c.Alerts.EmailTo)
+	fmt.Fprintf(&b, "cart: inactivity_ttl=%s cleanup_interval=%s\n", c.Cart.InactivityTTL, c.Cart.CleanupInterval)
+	fmt.Fprintf(&b, "orders: pending_ttl=%s expiry_interval=%s\n", c.Orders.PendingTTL, c.Orders.ExpiryInterval)
+	fmt.Fprintf(&b, "jobs: workers=%d poll_interval=%s max_attempts=%d backoff_base=%s backoff_max=%s timeout=%s\n",
+		c.Jobs.Workers, c.Jobs.PollInterval, c.Jobs.MaxAttempts, c.Jobs.BackoffBase, c.Jobs.BackoffMax, c.Jobs.Timeout)
+	fmt.Fprintf(&b, "webhooks: timeout=%s\n", c.Webhooks.Timeout)
+	fmt.Fprintf(&b, "invoices: driver=%s local_dir=%s s3_bucket=%s seller_name=%s seller_address=%s seller_tax_id=%s currency=%s\n",
+		c.Invoices.Driver, c.Invoices.LocalDir, c.Invoices.S3Bucket, c.Invoices.SellerName, c.Invoices.SellerAddress,
+		c.Invoices.SellerTaxID, c.Invoices.Currency)
+	fmt.Fprintf(&b, "log: level=%s format=%s\n", c.Log.Level, c.Log.Format)
+	fmt.Fprintf(&b, "rate_limit: enabled=%t store=%s redis_addr=%s redis_password=%s redis_db=%d",
+		c.RateLimit.Enabled, c.RateLimit.Store, c.RateLimit.RedisAddr, c.RateLimit.RedisPassword, c.RateLimit.RedisDB)
+	names := make([]string, 0, len(c.RateLimit.Policies))
+	for name := range c.RateLimit.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := c.RateLimit.Policies[name]
+		fmt.Fprintf(&b, " %s=%d/%s/%s", name, p.Limit, p.Period, p.KeyBy)
 	}
-	return defaultValue
+	return b.String()
 }
@@ -0,0 +1,369 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// envPrefixes covers every variable the loader reads
+var envPrefixes = []string{
+	"SERVER_", "DB_", "CORS_", "AUTH_", "LOG_", "MAIL_", "MEDIA_", "ALERTS_",
+	"CART_", "ORDER_", "JOBS_", "WEBHOOK_", "INVOICE_", "RATE_LIMIT_",
+}
+
+// clearEnv unsets the configuration variables of the test's environment for
+// the rest of the test. The loader treats empty variables as unset.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		for _, prefix := range envPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				t.Setenv(key, "")
+			}
+		}
+	}
+}
+
+// setRequiredEnv sets the credentials, which have no defaults
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_USER", "shop")
+	t.Setenv("DB_PASSWORD", "db-password")
+	t.Setenv("DB_NAME", "shop")
+	t.Setenv("AUTH_JWT_SECRET", testJWTSecret)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+// validConfig returns the defaults completed with credentials
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.User = "shop"
+	cfg.Database.Password = "db-password"
+	cfg.Database.DBName = "shop"
+	cfg.Auth.JWTSecret = testJWTSecret
+	return cfg
+}
+
+func TestLoadLayers(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+server:
+  port: "9000"
+  read_timeout: 20s
+log:
+  level: debug
+`,
+		"config.toml": `
+[server]
+port = "9000"
+read_timeout = "20s"
+
+[log]
+level = "debug"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			setRequiredEnv(t)
+			t.Setenv("SERVER_PORT", "9100")
+
+			cfg, err := Load(writeFile(t, name, content))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Port != "9100" {
+				t.Errorf("port = %s, want 9100 from the environment over the file", cfg.Server.Port)
+			}
+			if cfg.Server.ReadTimeout != 20*time.Second || cfg.Log.Level != "debug" {
+				t.Errorf("read timeout %s, log level %s; want 20s and debug from the file over the defaults", cfg.Server.ReadTimeout, cfg.Log.Level)
+			}
+			if cfg.Server.WriteTimeout != Default().Server.WriteTimeout {
+				t.Errorf("write timeout = %s, want the default kept", cfg.Server.WriteTimeout)
+			}
+			if cfg.Database.Password.Value() != "db-password" {
+				t.Errorf("password = %q, want it from the environment", cfg.Database.Password.Value())
+			}
+		})
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := validConfig()
+	if cfg.String() != want.String() {
+		t.Errorf("got\n%s\nwant the defaults\n%s", cfg, want)
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+
+	for name, path := range map[string]string{
+		"missing":     filepath.Join(t.TempDir(), "missing.yaml"),
+		"unsupported": writeFile(t, "config.json", `{}`),
+		"invalid":     writeFile(t, "config.yaml", "server: [port"),
+	} {
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s file: Load succeeded, want an error", name)
+		}
+	}
+}
+
+func TestLoadReportsEveryBadVariable(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+	t.Setenv("SERVER_READ_TIMEOUT", "soon")
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("RATE_LIMIT_ENABLED", "maybe")
+	t.Setenv("RATE_LIMIT_POLICIES", "auth=5/1m")
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load succeeded, want an error")
+	}
+	for _, key := range []string{"SERVER_READ_TIMEOUT", "DB_MAX_OPEN_CONNS", "RATE_LIMIT_ENABLED", "RATE_LIMIT_POLICIES"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q doesn't name %s", err, key)
+		}
+	}
+}
+
+func TestLoadRateLimitPolicies(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+	t.Setenv("RATE_LIMIT_POLICIES", "auth=5/30s/ip, orders=20/1m/user")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string]RateLimitPolicy{
+		"api":    Default().RateLimit.Policies["api"],
+		"auth":   {Limit: 5, Period: 30 * time.Second, KeyBy: "ip"},
+		"orders": {Limit: 20, Period: time.Minute, KeyBy: "user"},
+	}
+	if fmt.Sprint(cfg.RateLimit.Policies) != fmt.Sprint(want) {
+		t.Errorf("policies = %v, want %v", cfg.RateLimit.Policies, want)
+	}
+}
+
+func TestLoadSecretFromFile(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "password", "from-file\r\n"))
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Database.Password.Value(); got != "from-file" {
+		t.Errorf("password = %q, want the file's content without the trailing newline", got)
+	}
+}
+
+func TestLoadSecretRejectsValueAndFile(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "password", "from-file"))
+
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("err = %v, want DB_PASSWORD and DB_PASSWORD_FILE rejected together", err)
+	}
+}
+
+func TestLoadSecretReportsMissingFile(t *testing.T) {
+	clearEnv(t)
+	setRequiredEnv(t)
+	t.Setenv("AUTH_JWT_SECRET", "")
+	t.Setenv("AUTH_JWT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "AUTH_JWT_SECRET_FILE") {
+		t.Errorf("err = %v, want the missing AUTH_JWT_SECRET_FILE reported", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("valid configuration rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string
+	}{
+		{"port out of range", func(c *Config) { c.Server.Port = "70000" }, "server.port"},
+		{"missing password", func(c *Config) { c.Database.Password = "" }, "database.password"},
+		{"unknown sslmode", func(c *Config) { c.Database.SSLMode = "sometimes" }, "database.sslmode"},
+		{"more idle than open connections", func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, "database.max_idle_conns"},
+		{"invalid replica", func(c *Config) { c.Database.ReplicaHosts = []string{"replica:port"} }, "database.replica_hosts"},
+		{"short JWT secret", func(c *Config) { c.Auth.JWTSecret = "short" }, "auth.jwt_secret"},
+		{"lockout longer than the window", func(c *Config) { c.Auth.LockoutDuration = 2 * c.Auth.LoginFailureWindow }, "auth.lockout_duration"},
+		{"smtp without host", func(c *Config) { c.Mail.Driver = "smtp" }, "mail.smtp_host"},
+		{"s3 media without bucket", func(c *Config) { c.Media.Driver = "s3"; c.Media.S3Endpoint = "s3.example.com" }, "media.s3_bucket"},
+		{"invoices inside public media", func(c *Config) { c.Invoices.LocalDir = filepath.Join(c.Media.LocalDir, "invoices") }, "invoices.local_dir"},
+		{"invoices in the media bucket", func(c *Config) {
+			c.Media.S3Endpoint, c.Media.S3Bucket = "s3.example.com", "media"
+			c.Invoices.Driver, c.Invoices.S3Bucket = "s3", "media"
+		}, "invoices.s3_bucket"},
+		{"webhook timeout not under the job timeout", func(c *Config) { c.Webhooks.Timeout = c.Jobs.Timeout }, "webhooks.timeout"},
+		{"unknown log level", func(c *Config) { c.Log.Level = "verbose" }, "log.level"},
+		{"invalid rate limit policy", func(c *Config) {
+			c.RateLimit.Policies["auth"] = RateLimitPolicy{Limit: 0, Period: time.Minute, KeyBy: "ip"}
+		}, "rate_limit.policies.auth.limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %s rejected", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = "0"
+	cfg.Log.Format = "xml"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded, want an error")
+	}
+	for _, field := range []string{"server.port", "log.format"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("error %q doesn't name %s", err, field)
+		}
+	}
+}
+
+func TestQuoteDSNValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"secret", `'secret'`},
+		{"", `''`},
+		{"two words", `'two words'`},
+		{"it's", `'it\'s'`},
+		{`back\slash`, `'back\\slash'`},
+		{`\'`, `'\\\''`},
+		{"x' sslmode='disable", `'x\' sslmode=\'disable'`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteDSNValue(tt.value); got != tt.want {
+			t.Errorf("quoteDSNValue(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetDSN(t *testing.T) {
+	db := validConfig().Database
+	db.Password = `p@ss 'word'`
+	db.ReplicaHosts = []string{"replica1", "replica2:6432"}
+
+	want := `host='localhost' port='5432' user='shop' password='p@ss \'word\'' dbname='shop' sslmode='disable' connect_timeout=5 statement_timeout=30000 lock_timeout=10000`
+	if got := db.GetDSN(); got != want {
+		t.Errorf("GetDSN() =\n%s\nwant\n%s", got, want)
+	}
+
+	replicas := db.GetReplicaDSNs()
+	if len(replicas) != 2 ||
+		!strings.HasPrefix(replicas[0], "host='replica1' port='5432' ") ||
+		!strings.HasPrefix(replicas[1], "host='replica2' port='6432' ") {
+		t.Errorf("GetReplicaDSNs() = %q", replicas)
+	}
+}
+
+func TestSecretIsRedacted(t *testing.T) {
+	const value = "hunter2-hunter2"
+	secret := Secret(value)
+
+	if secret.Value() != value {
+		t.Errorf("Value() = %q, want %q", secret.Value(), value)
+	}
+	if Secret("").String() != "" {
+		t.Errorf("empty secret prints %q, want nothing", Secret("").String())
+	}
+
+	type holder struct {
+		Password Secret `json:"password" yaml:"password"`
+	}
+	outputs := map[string]string{
+		"String": secret.String(),
+		"%s":     fmt.Sprintf("%s", secret),
+		"%v":     fmt.Sprintf("%v", secret),
+		"%+v":    fmt.Sprintf("%+v", holder{secret}),
+		"%#v":    fmt.Sprintf("%#v", secret),
+	}
+	data, err := json.Marshal(holder{secret})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	outputs["JSON"] = string(data)
+	data, err = yaml.Marshal(holder{secret})
+	if err != nil {
+		t.Fatalf("yaml.Marshal: %v", err)
+	}
+	outputs["YAML"] = string(data)
+
+	for name, output := range outputs {
+		if strings.Contains(output, value) || !strings.Contains(output, redacted) {
+			t.Errorf("%s: %s, want the secret redacted", name, output)
+		}
+	}
+}
+
+func TestConfigStringRedactsSecrets(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.Password = "db-secret-value"
+	cfg.Auth.JWTSecret = "jwt-secret-value-0123456789abcdef"
+	cfg.Mail.SMTPPassword = "smtp-secret-value"
+	cfg.Media.S3SecretKey = "s3-secret-value"
+	cfg.RateLimit.RedisPassword = "redis-secret-value"
+
+	for name, output := range map[string]string{
+		"String": cfg.String(),
+		"%v":     fmt.Sprintf("%v", cfg),
+		"%+v":    fmt.Sprintf("%+v", *cfg),
+	} {
+		if strings.Contains(output, "secret-value") {
+			t.Errorf("%s reveals a secret:\n%s", name, output)
+		}
+	}
+}
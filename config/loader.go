@@ -0,0 +1,286 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig loads configuration in layers: built-in defaults, then the file
+// named by CONFIG_FILE (if any), then environment variables. The result is
+// validated and an error is returned if any value is missing or invalid.
+func LoadConfig() (*Config, error) {
+	return Load(os.Getenv("CONFIG_FILE"))
+}
+
+// Load is like LoadConfig but reads the given file instead of CONFIG_FILE.
+// An empty path skips the file layer.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML or TOML file on top of cfg, picking the format from
+// the file extension. Keys absent from the file keep their current values.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(data), cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q", filepath.Ext(path))
+	}
+	return nil
+}
+
+// envLoader applies environment overrides and collects parse errors so that
+// every bad variable is reported at once.
+type envLoader struct {
+	errs []error
+}
+
+func applyEnv(cfg *Config) error {
+	e := &envLoader{}
+
+	e.string("SERVER_PORT", &cfg.Server.Port)
+	e.duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	e.duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	e.duration("SERVER_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	e.duration("SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	e.string("DB_HOST", &cfg.Database.Host)
+	e.string("DB_PORT", &cfg.Database.Port)
+	e.string("DB_USER", &cfg.Database.User)
+	e.secret("DB_PASSWORD", &cfg.Database.Password)
+	e.string("DB_NAME", &cfg.Database.DBName)
+	e.string("DB_SSLMODE", &cfg.Database.SSLMode)
+	e.int("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &cfg.Database.ConnMaxIdleTime)
+	e.duration("DB_CONNECT_TIMEOUT", &cfg.Database.ConnectTimeout)
+	e.duration("DB_STATEMENT_TIMEOUT", &cfg.Database.StatementTimeout)
+	e.duration("DB_LOCK_TIMEOUT", &cfg.Database.LockTimeout)
+	e.int("DB_CONNECT_RETRIES", &cfg.Database.ConnectRetries)
+	e.duration("DB_CONNECT_RETRY_BACKOFF", &cfg.Database.ConnectRetryBackoff)
+	e.duration("DB_CONNECT_RETRY_MAX_BACKOFF", &cfg.Database.ConnectRetryMaxBackoff)
+	e.list("DB_REPLICA_HOSTS", &cfg.Database.ReplicaHosts)
+
+	e.list("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+
+	e.secret("AUTH_JWT_SECRET", &cfg.Auth.JWTSecret)
+	e.duration("AUTH_TOKEN_TTL", &cfg.Auth.TokenTTL)
+	e.int("AUTH_MAX_FAILED_LOGINS", &cfg.Auth.MaxFailedLogins)
+	e.duration("AUTH_LOGIN_FAILURE_WINDOW", &cfg.Auth.LoginFailureWindow)
+	e.duration("AUTH_LOCKOUT_DURATION", &cfg.Auth.LockoutDuration)
+	e.duration("AUTH_LOGIN_DELAY_BASE", &cfg.Auth.LoginDelayBase)
+	e.duration("AUTH_LOGIN_DELAY_MAX", &cfg.Auth.LoginDelayMax)
+	e.duration("AUTH_VERIFICATION_TOKEN_TTL", &cfg.Auth.VerificationTokenTTL)
+	e.duration("AUTH_PASSWORD_RESET_TOKEN_TTL", &cfg.Auth.PasswordResetTokenTTL)
+
+	e.string("LOG_LEVEL", &cfg.Log.Level)
+	e.string("LOG_FORMAT", &cfg.Log.Format)
+
+	e.string("MAIL_DRIVER", &cfg.Mail.Driver)
+	e.string("MAIL_FROM", &cfg.Mail.From)
+	e.string("MAIL_FILE_DIR", &cfg.Mail.FileDir)
+	e.string("MAIL_SMTP_HOST", &cfg.Mail.SMTPHost)
+	e.string("MAIL_SMTP_PORT", &cfg.Mail.SMTPPort)
+	e.string("MAIL_SMTP_USERNAME", &cfg.Mail.SMTPUsername)
+	e.secret("MAIL_SMTP_PASSWORD", &cfg.Mail.SMTPPassword)
+	e.string("MAIL_LINK_BASE_URL", &cfg.Mail.LinkBaseURL)
+
+	e.string("MEDIA_DRIVER", &cfg.Media.Driver)
+	e.int("MEDIA_MAX_UPLOAD_SIZE", &cfg.Media.MaxUploadSize)
+	e.int("MEDIA_MAX_PIXELS", &cfg.Media.MaxPixels)
+	e.int("MEDIA_THUMBNAIL_SIZE", &cfg.Media.ThumbnailSize)
+	e.string("MEDIA_PUBLIC_URL", &cfg.Media.PublicURL)
+	e.string("MEDIA_LOCAL_DIR", &cfg.Media.LocalDir)
+	e.string("MEDIA_S3_ENDPOINT", &cfg.Media.S3Endpoint)
+	e.string("MEDIA_S3_REGION", &cfg.Media.S3Region)
+	e.string("MEDIA_S3_BUCKET", &cfg.Media.S3Bucket)
+	e.string("MEDIA_S3_ACCESS_KEY", &cfg.Media.S3AccessKey)
+	e.secret("MEDIA_S3_SECRET_KEY", &cfg.Media.S3SecretKey)
+	e.bool("MEDIA_S3_USE_SSL", &cfg.Media.S3UseSSL)
+
+	e.string("ALERTS_NOTIFIER", &cfg.Alerts.Notifier)
+	e.string("ALERTS_WEBHOOK_URL", &cfg.Alerts.WebhookURL)
+	e.duration("ALERTS_WEBHOOK_TIMEOUT", &cfg.Alerts.WebhookTimeout)
+	e.string("ALERTS_EMAIL_TO", &cfg.Alerts.EmailTo)
+
+	e.duration("CART_INACTIVITY_TTL", &cfg.Cart.InactivityTTL)
+	e.duration("CART_CLEANUP_INTERVAL", &cfg.Cart.CleanupInterval)
+	e.duration("ORDER_PENDING_TTL", &cfg.Orders.PendingTTL)
+	e.duration("ORDER_EXPIRY_INTERVAL", &cfg.Orders.ExpiryInterval)
+
+	e.int("JOBS_WORKERS", &cfg.Jobs.Workers)
+	e.duration("JOBS_POLL_INTERVAL", &cfg.Jobs.PollInterval)
+	e.int("JOBS_MAX_ATTEMPTS", &cfg.Jobs.MaxAttempts)
+	e.duration("JOBS_BACKOFF_BASE", &cfg.Jobs.BackoffBase)
+	e.duration("JOBS_BACKOFF_MAX", &cfg.Jobs.BackoffMax)
+	e.duration("JOBS_TIMEOUT", &cfg.Jobs.Timeout)
+
+	e.duration("WEBHOOK_TIMEOUT", &cfg.Webhooks.Timeout)
+
+	e.string("INVOICE_DRIVER", &cfg.Invoices.Driver)
+	e.string("INVOICE_LOCAL_DIR", &cfg.Invoices.LocalDir)
+	e.string("INVOICE_S3_BUCKET", &cfg.Invoices.S3Bucket)
+	e.string("INVOICE_SELLER_NAME", &cfg.Invoices.SellerName)
+	e.string("INVOICE_SELLER_ADDRESS", &cfg.Invoices.SellerAddress)
+	e.string("INVOICE_SELLER_TAX_ID", &cfg.Invoices.SellerTaxID)
+	e.string("INVOICE_CURRENCY", &cfg.Invoices.Currency)
+
+	e.bool("RATE_LIMIT_ENABLED", &cfg.RateLimit.Enabled)
+	e.string("RATE_LIMIT_STORE", &cfg.RateLimit.Store)
+	e.string("RATE_LIMIT_REDIS_ADDR", &cfg.RateLimit.RedisAddr)
+	e.secret("RATE_LIMIT_REDIS_PASSWORD", &cfg.RateLimit.RedisPassword)
+	e.int("RATE_LIMIT_REDIS_DB", &cfg.RateLimit.RedisDB)
+	e.rateLimitPolicies("RATE_LIMIT_POLICIES", &cfg.RateLimit.Policies)
+
+	return errors.Join(e.errs...)
+}
+
+func (e *envLoader) string(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func (e *envLoader) int(key string, dst *int) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *envLoader) bool(key string, dst *bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid boolean %q", key, v))
+		return
+	}
+	*dst = b
+}
+
+func (e *envLoader) duration(key string, dst *time.Duration) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid duration %q", key, v))
+		return
+	}
+	*dst = d
+}
+
+func (e *envLoader) list(key string, dst *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+// rateLimitPolicies parses "name=limit/period/key_by" entries separated by
+// commas, e.g. "auth=5/1m/ip,orders=20/1m/user". Listed policies replace
+// the configured policy of the same name; others are kept.
+func (e *envLoader) rateLimitPolicies(key string, dst *map[string]RateLimitPolicy) {
+	var entries []string
+	e.list(key, &entries)
+
+	for _, entry := range entries {
+		name, spec, _ := strings.Cut(entry, "=")
+		parts := strings.Split(spec, "/")
+		if name == "" || len(parts) != 3 {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid policy %q, want name=limit/period/key_by", key, entry))
+			continue
+		}
+		limit, err := strconv.Atoi(parts[0])
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid limit in %q", key, entry))
+			continue
+		}
+		period, err := time.ParseDuration(parts[1])
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid period in %q", key, entry))
+			continue
+		}
+		if *dst == nil {
+			*dst = make(map[string]RateLimitPolicy)
+		}
+		(*dst)[strings.TrimSpace(name)] = RateLimitPolicy{Limit: limit, Period: period, KeyBy: parts[2]}
+	}
+}
+
+// secret reads key directly or, when key_FILE is set, from the named file
+// (e.g. a Docker or Kubernetes secret mount). Setting both is an error.
+func (e *envLoader) secret(key string, dst *Secret) {
+	value, hasValue := os.LookupEnv(key)
+	file, hasFile := os.LookupEnv(key + "_FILE")
+	hasValue = hasValue && value != ""
+	hasFile = hasFile && file != ""
+
+	switch {
+	case hasValue && hasFile:
+		e.errs = append(e.errs, fmt.Errorf("%s and %s_FILE are mutually exclusive", key, key))
+	case hasFile:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s_FILE: %w", key, err))
+			return
+		}
+		*dst = Secret(strings.TrimRight(string(data), "\r\n"))
+	case hasValue:
+		*dst = Secret(value)
+	}
+}
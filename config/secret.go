@@ -0,0 +1,37 @@
+package config
+
+import "encoding/json"
+
+const redacted = "[REDACTED]"
+
+// Secret holds a sensitive configuration value. It formats, marshals and
+// prints as a redacted placeholder; use Value to obtain the real content.
+type Secret string
+
+// Value returns the underlying secret value
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer and never reveals the secret
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted as well
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// MarshalJSON redacts the secret when the configuration is encoded as JSON
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML redacts the secret when the configuration is encoded as YAML
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
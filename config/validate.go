@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const minJWTSecretLength = 32
+
+var (
+	validSSLModes   = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	validLogLevels  = []string{"debug", "info", "warn", "error"}
+	validLogFormats = []string{"text", "json"}
+	validRateStores = []string{"memory", "redis"}
+	validMailers    = []string{"file", "smtp"}
+	validStorages   = []string{"local", "s3"}
+	validNotifiers  = []string{"log", "webhook", "email"}
+	validRateKeys   = []string{"ip", "user"}
+)
+
+// Validate checks that every required value is present and every value is
+// within range. All problems are reported together.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(isPort(c.Server.Port), "server.port: invalid port %q", c.Server.Port)
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout: must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout: must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout: must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be greater than 0")
+
+	check(c.Database.Host != "", "database.host: is required")
+	check(isPort(c.Database.Port), "database.port: invalid port %q", c.Database.Port)
+	check(c.Database.User != "", "database.user: is required")
+	check(c.Database.Password != "", "database.password: is required")
+	check(c.Database.DBName != "", "database.dbname: is required")
+	check(oneOf(c.Database.SSLMode, validSSLModes), "database.sslmode: must be one of %v", validSSLModes)
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns: must be greater than 0")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns: must not be negative")
+	check(c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.max_idle_conns: must not exceed max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime: must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time: must not be negative")
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout: must not be negative")
+	check(c.Database.StatementTimeout >= 0, "database.statement_timeout: must not be negative")
+	check(c.Database.LockTimeout >= 0, "database.lock_timeout: must not be negative")
+	check(c.Database.ConnectRetries >= 0, "database.connect_retries: must not be negative")
+	check(c.Database.ConnectRetryBackoff > 0, "database.connect_retry_backoff: must be greater than 0")
+	check(c.Database.ConnectRetryMaxBackoff >= c.Database.ConnectRetryBackoff, "database.connect_retry_max_backoff: must not be less than connect_retry_backoff")
+	for _, replica := range c.Database.ReplicaHosts {
+		check(isHostPort(replica), "database.replica_hosts: invalid replica %q", replica)
+	}
+
+	check(len(c.CORS.AllowOrigins) > 0, "cors.allow_origins: at least one origin is required")
+
+	check(len(c.Auth.JWTSecret) >= minJWTSecretLength, "auth.jwt_secret: must be at least %d characters", minJWTSecretLength)
+	check(c.Auth.TokenTTL > 0, "auth.token_ttl: must be greater than 0")
+	check(c.Auth.MaxFailedLogins > 0, "auth.max_failed_logins: must be greater than 0")
+	check(c.Auth.LoginFailureWindow > 0, "auth.login_failure_window: must be greater than 0")
+	check(c.Auth.LockoutDuration > 0, "auth.lockout_duration: must be greater than 0")
+	check(c.Auth.LockoutDuration <= c.Auth.LoginFailureWindow, "auth.lockout_duration: must not exceed login_failure_window")
+	check(c.Auth.LoginDelayBase >= 0, "auth.login_delay_base: must not be negative")
+	check(c.Auth.LoginDelayMax >= c.Auth.LoginDelayBase, "auth.login_delay_max: must not be less than login_delay_base")
+	check(c.Auth.VerificationTokenTTL > 0, "auth.verification_token_ttl: must be greater than 0")
+	check(c.Auth.PasswordResetTokenTTL > 0, "auth.password_reset_token_ttl: must be greater than 0")
+
+	check(oneOf(c.Mail.Driver, validMailers), "mail.driver: must be one of %v", validMailers)
+	check(c.Mail.From != "", "mail.from: is required")
+	check(c.Mail.Driver != "file" || c.Mail.FileDir != "", "mail.file_dir: is required for the file driver")
+	check(c.Mail.Driver != "smtp" || c.Mail.SMTPHost != "", "mail.smtp_host: is required for the smtp driver")
+	check(c.Mail.Driver != "smtp" || isPort(c.Mail.SMTPPort), "mail.smtp_port: invalid port %q", c.Mail.SMTPPort)
+	check(c.Mail.LinkBaseURL != "", "mail.link_base_url: is required")
+
+	check(oneOf(c.Media.Driver, validStorages), "media.driver: must be one of %v", validStorages)
+	check(c.Media.MaxUploadSize > 0, "media.max_upload_size: must be greater than 0")
+	check(c.Media.MaxPixels > 0, "media.max_pixels: must be greater than 0")
+	check(c.Media.ThumbnailSize > 0, "media.thumbnail_size: must be greater than 0")
+	check(c.Media.PublicURL != "", "media.public_url: is required")
+	check(c.Media.Driver != "local" || c.Media.LocalDir != "", "media.local_dir: is required for the local driver")
+	check(c.Media.Driver != "s3" || c.Media.S3Endpoint != "", "media.s3_endpoint: is required for the s3 driver")
+	check(c.Media.Driver != "s3" || c.Media.S3Bucket != "", "media.s3_bucket: is required for the s3 driver")
+
+	check(oneOf(c.Alerts.Notifier, validNotifiers), "alerts.notifier: must be one of %v", validNotifiers)
+	check(c.Alerts.Notifier != "webhook" || c.Alerts.WebhookURL != "", "alerts.webhook_url: is required for the webhook notifier")
+	check(c.Alerts.WebhookTimeout > 0, "alerts.webhook_timeout: must be greater than 0")
+	check(c.Alerts.Notifier != "email" || c.Alerts.EmailTo != "", "alerts.email_to: is required for the email notifier")
+
+	check(c.Cart.InactivityTTL > 0, "cart.inactivity_ttl: must be greater than 0")
+	check(c.Cart.CleanupInterval > 0, "cart.cleanup_interval: must be greater than 0")
+	check(c.Orders.PendingTTL > 0, "orders.pending_ttl: must be greater than 0")
+	check(c.Orders.ExpiryInterval > 0, "orders.expiry_interval: must be greater than 0")
+
+	check(c.Jobs.Workers > 0, "jobs.workers: must be greater than 0")
+	check(c.Jobs.PollInterval > 0, "jobs.poll_interval: must be greater than 0")
+	check(c.Jobs.MaxAttempts > 0, "jobs.max_attempts: must be greater than 0")
+	check(c.Jobs.BackoffBase > 0, "jobs.backoff_base: must be greater than 0")
+	check(c.Jobs.BackoffMax >= c.Jobs.BackoffBase, "jobs.backoff_max: must not be less than jobs.backoff_base")
+	check(c.Jobs.Timeout > 0, "jobs.timeout: must be greater than 0")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout: must be greater than 0")
+	check(c.Webhooks.Timeout < c.Jobs.Timeout, "webhooks.timeout: must be less than jobs.timeout")
+
+	check(oneOf(c.Invoices.Driver, validStorages), "invoices.driver: must be one of %v", validStorages)
+	check(c.Invoices.Driver != "local" || c.Invoices.LocalDir != "", "invoices.local_dir: is required for the local driver")
+	check(c.Invoices.Driver != "local" || c.Media.Driver != "local" || !isSubdir(c.Invoices.LocalDir, c.Media.LocalDir),
+		"invoices.local_dir: must not be inside media.local_dir, which is served publicly")
+	check(c.Invoices.Driver != "s3" || c.Media.S3Endpoint != "", "media.s3_endpoint: is required for the s3 invoice driver")
+	check(c.Invoices.Driver != "s3" || c.Invoices.S3Bucket != "", "invoices.s3_bucket: is required for the s3 driver")
+	check(c.Invoices.Driver != "s3" || c.Invoices.S3Bucket != c.Media.S3Bucket, "invoices.s3_bucket: must not be the public media bucket")
+	check(c.Invoices.SellerName != "", "invoices.seller_name: is required")
+
+	check(oneOf(c.Log.Level, validLogLevels), "log.level: must be one of %v", validLogLevels)
+	check(oneOf(c.Log.Format, validLogFormats), "log.format: must be one of %v", validLogFormats)
+
+	if c.RateLimit.Enabled {
+		check(oneOf(c.RateLimit.Store, validRateStores), "rate_limit.store: must be one of %v", validRateStores)
+		check(c.RateLimit.Store != "redis" || c.RateLimit.RedisAddr != "", "rate_limit.redis_addr: is required for the redis store")
+		for name, p := range c.RateLimit.Policies {
+			check(p.Limit > 0, "rate_limit.policies.%s.limit: must be greater than 0", name)
+			check(p.Period > 0, "rate_limit.policies.%s.period: must be greater than 0", name)
+			check(oneOf(p.KeyBy, validRateKeys), "rate_limit.policies.%s.key_by: must be one of %v", name, validRateKeys)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func isPort(v string) bool {
+	n, err := strconv.Atoi(v)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// isHostPort accepts "host" or "host:port"
+func isHostPort(v string) bool {
+	if v == "" {
+		return false
+	}
+	if host, port, err := net.SplitHostPort(v); err == nil {
+		return host != "" && isPort(port)
+	}
+	return !strings.Contains(v, ":")
+}
+
+// isSubdir reports whether dir is parent or a directory inside it
+func isSubdir(dir, parent string) bool {
+	rel, err := filepath.Rel(filepath.Clean(parent), filepath.Clean(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func oneOf(v string, allowed []string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
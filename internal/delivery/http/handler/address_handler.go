@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/example/clean-arch-template/internal/delivery/http/middleware"
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AddressHandler struct {
+	addressUseCase *usecase.AddressUseCase
+}
+
+func NewAddressHandler(addressUseCase *usecase.AddressUseCase) *AddressHandler {
+	return &AddressHandler{
+		addressUseCase: addressUseCase,
+	}
+}
+
+// AddressRequest describes an address book entry. Region is an ISO 3166
+// code such as "US" or "US-CA".
+type AddressRequest struct {
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name" validate:"required"`
+	Line1         string `json:"line1" validate:"required"`
+	Line2         string `json:"line2"`
+	City          string `json:"city" validate:"required"`
+	PostalCode    string `json:"postal_code"`
+	Region        string `json:"region" validate:"required"`
+	Phone         string `json:"phone"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+// ListAddresses retrieves the authenticated user's address book
+func (h *AddressHandler) ListAddresses(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	addresses, err := h.addressUseCase.ListAddresses(c.Context(), userID)
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve addresses")
+	}
+
+	return response.Success(c, "Addresses retrieved", addresses)
+}
+
+// CreateAddress adds an address to the authenticated user's address book
+func (h *AddressHandler) CreateAddress(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req AddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	address, err := h.addressUseCase.CreateAddress(c.Context(), userID, addressInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, "Address created successfully", address)
+}
+
+// UpdateAddress updates an address of the authenticated user's address book
+func (h *AddressHandler) UpdateAddress(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid address ID")
+	}
+
+	var req AddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	address, err := h.addressUseCase.UpdateAddress(c.Context(), userID, uint(id), addressInput(req))
+	if err != nil {
+		if errors.Is(err, usecase.ErrAddressNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Address updated successfully", address)
+}
+
+// DeleteAddress removes an address from the authenticated user's address
+// book
+func (h *AddressHandler) DeleteAddress(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid address ID")
+	}
+
+	if err := h.addressUseCase.DeleteAddress(c.Context(), userID, uint(id)); err != nil {
+		if errors.Is(err, usecase.ErrAddressNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to delete address")
+	}
+
+	return response.Success(c, "Address deleted successfully", nil)
+}
+
+func addressInput(req AddressRequest) usecase.AddressInput {
+	return usecase.AddressInput{
+		Label: req.Label,
+		Address: domain.PostalAddress{
+			RecipientName: req.RecipientName,
+			Line1:         req.Line1,
+			Line2:         req.Line2,
+			City:          req.City,
+			PostalCode:    req.PostalCode,
+			Region:        req.Region,
+			Phone:         req.Phone,
+		},
+		IsDefault: req.IsDefault,
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	auditUseCase *usecase.AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase *usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+// ListAuditLogs lists audit log entries, newest first, optionally filtered by
+// "entity_type" and "entity_id", "actor_id" and an RFC 3339 time range
+// "from" (inclusive) to "to" (exclusive), and paginated with "limit" and
+// "offset" (admin only)
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	entityID, err := optionalQueryID(c, "entity_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid entity ID")
+	}
+	actorID, err := optionalQueryID(c, "actor_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid actor ID")
+	}
+
+	filter := repository.AuditFilter{EntityType: c.Query("entity_type"), ActorID: actorID}
+	if entityID != nil {
+		filter.EntityID = *entityID
+	}
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return response.BadRequest(c, "Invalid from time, expected RFC 3339")
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return response.BadRequest(c, "Invalid to time, expected RFC 3339")
+	}
+
+	entries, err := h.auditUseCase.ListAuditLogs(c.Context(), filter, c.QueryInt("limit", 100), c.QueryInt("offset"))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Audit log retrieved", entries)
+}
+
+// queryTime parses an optional RFC 3339 query parameter; the zero time
+// stands for a missing one
+func queryTime(c *fiber.Ctx, key string) (time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/example/clean-arch-template/internal/delivery/http/middleware"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderCartToken carries the token of a guest cart. It is returned in the
+// cart when a guest adds their first item.
+const HeaderCartToken = "X-Cart-Token"
+
+type CartHandler struct {
+	cartUseCase *usecase.CartUseCase
+}
+
+func NewCartHandler(cartUseCase *usecase.CartUseCase) *CartHandler {
+	return &CartHandler{
+		cartUseCase: cartUseCase,
+	}
+}
+
+type AddCartItemRequest struct {
+	ProductID uint  `json:"product_id" validate:"required"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity" validate:"required,gt=0"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity" validate:"gte=0"`
+}
+
+type CheckoutRequest struct {
+	PaymentMethod string   `json:"payment_method" validate:"required"`
+	CouponCodes   []string `json:"coupon_codes"`
+	AddressID     *uint    `json:"address_id"`
+	Region        string   `json:"region"`
+}
+
+// GetCart retrieves the cart of the authenticated user, or of the guest
+// identified by the X-Cart-Token header
+func (h *CartHandler) GetCart(c *fiber.Ctx) error {
+	cart, err := h.cartUseCase.GetCart(c.Context(), cartOwner(c))
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve cart")
+	}
+
+	return response.Success(c, "Cart retrieved", cart)
+}
+
+// AddItem adds a product or variant to the cart
+func (h *CartHandler) AddItem(c *fiber.Ctx) error {
+	var req AddCartItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	cart, err := h.cartUseCase.AddItem(c.Context(), cartOwner(c), req.ProductID, req.VariantID, req.Quantity)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Item added to cart", cart)
+}
+
+// UpdateItem changes the quantity of a cart line; 0 removes it
+func (h *CartHandler) UpdateItem(c *fiber.Ctx) error {
+	itemID, err := c.ParamsInt("item_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid cart item ID")
+	}
+
+	var req UpdateCartItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	cart, err := h.cartUseCase.UpdateItem(c.Context(), cartOwner(c), uint(itemID), req.Quantity)
+	if err != nil {
+		if errors.Is(err, usecase.ErrCartItemNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Cart item updated", cart)
+}
+
+// RemoveItem removes a line from the cart
+func (h *CartHandler) RemoveItem(c *fiber.Ctx) error {
+	itemID, err := c.ParamsInt("item_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid cart item ID")
+	}
+
+	cart, err := h.cartUseCase.RemoveItem(c.Context(), cartOwner(c), uint(itemID))
+	if err != nil {
+		if errors.Is(err, usecase.ErrCartItemNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to remove cart item")
+	}
+
+	return response.Success(c, "Cart item removed", cart)
+}
+
+// ClearCart empties the cart
+func (h *CartHandler) ClearCart(c *fiber.Ctx) error {
+	if err := h.cartUseCase.ClearCart(c.Context(), cartOwner(c)); err != nil {
+		return response.InternalError(c, "Failed to clear cart")
+	}
+
+	return response.Success(c, "Cart cleared", nil)
+}
+
+// Checkout turns the authenticated user's cart into an order
+func (h *CartHandler) Checkout(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req CheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	order, err := h.cartUseCase.Checkout(c.Context(), userID, usecase.CheckoutInput{
+		PaymentMethod: req.PaymentMethod,
+		CouponCodes:   req.CouponCodes,
+		AddressID:     req.AddressID,
+		Region:        req.Region,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			return response.Forbidden(c, err.Error())
+		case errors.Is(err, usecase.ErrCartChanged):
+			return response.Conflict(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, "Order created successfully", order)
+}
+
+// cartOwner identifies the cart of the request: the authenticated user's,
+// or else the guest cart named by the X-Cart-Token header
+func cartOwner(c *fiber.Ctx) usecase.CartOwner {
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		return usecase.CartOwner{UserID: &userID}
+	}
+	return usecase.CartOwner{GuestToken: c.Get(HeaderCartToken)}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CategoryHandler struct {
+	categoryUseCase *usecase.CategoryUseCase
+	productUseCase  *usecase.ProductUseCase
+}
+
+func NewCategoryHandler(categoryUseCase *usecase.CategoryUseCase, productUseCase *usecase.ProductUseCase) *CategoryHandler {
+	return &CategoryHandler{
+		categoryUseCase: categoryUseCase,
+		productUseCase:  productUseCase,
+	}
+}
+
+type CategoryRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Slug        string `json:"slug" validate:"required"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// CreateCategory handles category creation (admin only)
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var req CategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	category, err := h.categoryUseCase.CreateCategory(c.Context(), req.Name, req.Slug, req.Description, req.ParentID)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, category.Version)
+	return response.Created(c, "Category created successfully", category)
+}
+
+// GetCategory retrieves a category by ID
+func (h *CategoryHandler) GetCategory(c *fiber.Ctx) error {
+	categoryID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid category ID")
+	}
+
+	category, err := h.categoryUseCase.GetCategory(c.Context(), uint(categoryID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	setETag(c, category.Version)
+	return response.Success(c, "Category retrieved", category)
+}
+
+// ListCategories retrieves the category tree
+func (h *CategoryHandler) ListCategories(c *fiber.Ctx) error {
+	categories, err := h.categoryUseCase.ListCategoryTree(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve categories")
+	}
+
+	return response.Success(c, "Categories retrieved", categories)
+}
+
+// ListCategoryProducts retrieves the products of a category and its subcategories
+func (h *CategoryHandler) ListCategoryProducts(c *fiber.Ctx) error {
+	categoryID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid category ID")
+	}
+
+	id := uint(categoryID)
+	products, err := h.productUseCase.SearchProducts(c.Context(), &id, c.Query("tag"))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Products retrieved", products)
+}
+
+// UpdateCategory updates an existing category, only the version named by the
+// required If-Match header (admin only)
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	categoryID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid category ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req CategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	category, err := h.categoryUseCase.UpdateCategory(c.Context(), uint(categoryID), version, req.Name, req.Slug, req.Description, req.ParentID)
+	if err != nil {
+		return versionedError(c, err)
+	}
+
+	setETag(c, category.Version)
+	return response.Success(c, "Category updated successfully", category)
+}
+
+// DeleteCategory deletes an empty category, only the version named by the
+// required If-Match header (admin only)
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	categoryID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid category ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.categoryUseCase.DeleteCategory(c.Context(), uint(categoryID), version); err != nil {
+		return versionedError(c, err)
+	}
+
+	return response.Success(c, "Category deleted successfully", nil)
+}
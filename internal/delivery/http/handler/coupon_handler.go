@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CouponHandler struct {
+	couponUseCase *usecase.CouponUseCase
+}
+
+func NewCouponHandler(couponUseCase *usecase.CouponUseCase) *CouponHandler {
+	return &CouponHandler{
+		couponUseCase: couponUseCase,
+	}
+}
+
+// CouponRequest describes a coupon. Limits of 0 are unlimited; Active
+// defaults to true.
+type CouponRequest struct {
+	Code                  string            `json:"code" validate:"required"`
+	Description           string            `json:"description"`
+	Type                  domain.CouponType `json:"type" validate:"required,oneof=percentage fixed free_item"`
+	Value                 float64           `json:"value"`
+	FreeProductID         *uint             `json:"free_product_id"`
+	FreeVariantID         *uint             `json:"free_variant_id"`
+	FreeQuantity          int               `json:"free_quantity"`
+	MinOrderAmount        float64           `json:"min_order_amount"`
+	StartsAt              *time.Time        `json:"starts_at"`
+	EndsAt                *time.Time        `json:"ends_at"`
+	MaxRedemptions        int               `json:"max_redemptions"`
+	MaxRedemptionsPerUser int               `json:"max_redemptions_per_user"`
+	Stackable             bool              `json:"stackable"`
+	Active                *bool             `json:"active"`
+}
+
+// CreateCoupon creates a new coupon (admin only)
+func (h *CouponHandler) CreateCoupon(c *fiber.Ctx) error {
+	var req CouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	coupon, err := h.couponUseCase.CreateCoupon(c.Context(), couponInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, coupon.Version)
+	return response.Created(c, "Coupon created successfully", coupon)
+}
+
+// GetCoupon retrieves a coupon by ID (admin only)
+func (h *CouponHandler) GetCoupon(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid coupon ID")
+	}
+
+	coupon, err := h.couponUseCase.GetCoupon(c.Context(), uint(couponID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	setETag(c, coupon.Version)
+	return response.Success(c, "Coupon retrieved", coupon)
+}
+
+// ListCoupons retrieves all coupons (admin only)
+func (h *CouponHandler) ListCoupons(c *fiber.Ctx) error {
+	coupons, err := h.couponUseCase.ListCoupons(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve coupons")
+	}
+
+	return response.Success(c, "Coupons retrieved", coupons)
+}
+
+// UpdateCoupon updates a coupon, only the version named by the required
+// If-Match header (admin only)
+func (h *CouponHandler) UpdateCoupon(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid coupon ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req CouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	coupon, err := h.couponUseCase.UpdateCoupon(c.Context(), uint(couponID), version, couponInput(req))
+	if err != nil {
+		return versionedError(c, err)
+	}
+
+	setETag(c, coupon.Version)
+	return response.Success(c, "Coupon updated successfully", coupon)
+}
+
+// DeleteCoupon deletes a coupon that was never redeemed, only the version
+// named by the required If-Match header (admin only)
+func (h *CouponHandler) DeleteCoupon(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid coupon ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.couponUseCase.DeleteCoupon(c.Context(), uint(couponID), version); err != nil {
+		return versionedError(c, err)
+	}
+
+	return response.Success(c, "Coupon deleted successfully", nil)
+}
+
+func couponInput(req CouponRequest) usecase.CouponInput {
+	return usecase.CouponInput{
+		Code:                  req.Code,
+		Description:           req.Description,
+		Type:                  req.Type,
+		Value:                 req.Value,
+		FreeProductID:         req.FreeProductID,
+		FreeVariantID:         req.FreeVariantID,
+		FreeQuantity:          req.FreeQuantity,
+		MinOrderAmount:        req.MinOrderAmount,
+		StartsAt:              req.StartsAt,
+		EndsAt:                req.EndsAt,
+		MaxRedemptions:        req.MaxRedemptions,
+		MaxRedemptionsPerUser: req.MaxRedemptionsPerUser,
+		Stackable:             req.Stackable,
+		Active:                req.Active == nil || *req.Active,
+	}
+}
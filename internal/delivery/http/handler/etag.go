@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// errETagMismatch is returned for If-Match headers that can't match any
+	// ETag set by setETag
+	errETagMismatch = errors.New("no current ETag matches If-Match")
+	// errIfMatchRequired is returned for writes without an If-Match header
+	errIfMatchRequired = errors.New("If-Match is required: send the ETag you read, or * to change any version")
+)
+
+// setETag sets the ETag of a versioned resource, its version in quotes
+func setETag(c *fiber.Ctx, version uint) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// ifMatch returns the version that the If-Match header requires, or nil for
+// "*". Writes must send the header, so that a client can't overwrite changes
+// it never saw by leaving it out. Weak ETags never match, as If-Match
+// compares strongly.
+func ifMatch(c *fiber.Ctx) (*uint, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return nil, errIfMatchRequired
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return nil, errETagMismatch
+	}
+	version, err := strconv.ParseUint(tag, 10, 0)
+	if err != nil {
+		return nil, errETagMismatch
+	}
+	v := uint(version)
+	return &v, nil
+}
+
+// ifMatchError answers a request whose If-Match header ifMatch rejected:
+// 428 Precondition Required without one, else 412 Precondition Failed
+func ifMatchError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errIfMatchRequired) {
+		return response.PreconditionRequired(c, err.Error())
+	}
+	return response.PreconditionFailed(c, err.Error())
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIfMatch(t *testing.T) {
+	app := fiber.New()
+	app.Put("/", func(c *fiber.Ctx) error {
+		version, err := ifMatch(c)
+		if err != nil {
+			return ifMatchError(c, err)
+		}
+		if version == nil {
+			return c.SendString("any")
+		}
+		return c.SendString(strconv.FormatUint(uint64(*version), 10))
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"missing", "", fiber.StatusPreconditionRequired, ""},
+		{"any version", "*", fiber.StatusOK, "any"},
+		{"strong ETag", `"3"`, fiber.StatusOK, "3"},
+		{"weak ETag", `W/"3"`, fiber.StatusPreconditionFailed, ""},
+		{"unquoted", "3", fiber.StatusPreconditionFailed, ""},
+		{"not a version", `"abc"`, fiber.StatusPreconditionFailed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPut, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if got := string(body); got != tt.wantBody {
+					t.Errorf("version = %q, want %q", got, tt.wantBody)
+				}
+			}
+		})
+	}
+}
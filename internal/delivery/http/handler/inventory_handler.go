@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type InventoryHandler struct {
+	inventoryUseCase  *usecase.InventoryUseCase
+	warehouseUseCase  *usecase.WarehouseUseCase
+	stockAlertUseCase *usecase.StockAlertUseCase
+}
+
+func NewInventoryHandler(
+	inventoryUseCase *usecase.InventoryUseCase,
+	warehouseUseCase *usecase.WarehouseUseCase,
+	stockAlertUseCase *usecase.StockAlertUseCase,
+) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryUseCase:  inventoryUseCase,
+		warehouseUseCase:  warehouseUseCase,
+		stockAlertUseCase: stockAlertUseCase,
+	}
+}
+
+// StockAdjustmentRequest changes the stock of a product, or of one of its
+// variants, by a signed quantity. Without a warehouse the default one is
+// adjusted.
+type StockAdjustmentRequest struct {
+	VariantID   *uint  `json:"variant_id"`
+	WarehouseID *uint  `json:"warehouse_id"`
+	Quantity    int    `json:"quantity" validate:"required"`
+	Reason      string `json:"reason" validate:"required"`
+}
+
+type StockTransferRequest struct {
+	ProductID       uint   `json:"product_id" validate:"required"`
+	VariantID       *uint  `json:"variant_id"`
+	FromWarehouseID uint   `json:"from_warehouse_id" validate:"required"`
+	ToWarehouseID   uint   `json:"to_warehouse_id" validate:"required"`
+	Quantity        int    `json:"quantity" validate:"required,gt=0"`
+	Reason          string `json:"reason"`
+}
+
+type WarehouseRequest struct {
+	Code      string `json:"code" validate:"required"`
+	Name      string `json:"name" validate:"required"`
+	Address   string `json:"address"`
+	Priority  int    `json:"priority"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// GetStockHistory lists the inventory movements of a product, newest first,
+// optionally filtered by "variant_id" and "warehouse_id" and paginated with
+// "limit" and "offset" (admin only)
+func (h *InventoryHandler) GetStockHistory(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	filter := repository.MovementFilter{ProductID: uint(productID)}
+	if filter.VariantID, err = optionalQueryID(c, "variant_id"); err != nil {
+		return response.BadRequest(c, "Invalid variant ID")
+	}
+	if filter.WarehouseID, err = optionalQueryID(c, "warehouse_id"); err != nil {
+		return response.BadRequest(c, "Invalid warehouse ID")
+	}
+
+	history, err := h.inventoryUseCase.GetStockHistory(c.Context(), filter, c.QueryInt("limit", 100), c.QueryInt("offset"))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Stock history retrieved", history)
+}
+
+// AdjustStock records a manual stock adjustment (admin only)
+func (h *InventoryHandler) AdjustStock(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	var req StockAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	movement, err := h.inventoryUseCase.AdjustStock(c.Context(), uint(productID), req.VariantID, req.WarehouseID, req.Quantity, req.Reason)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, "Stock adjusted successfully", movement)
+}
+
+// TransferStock moves stock between warehouses (admin only)
+func (h *InventoryHandler) TransferStock(c *fiber.Ctx) error {
+	var req StockTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	movements, err := h.inventoryUseCase.TransferStock(c.Context(), usecase.TransferInput{
+		ProductID:       req.ProductID,
+		VariantID:       req.VariantID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, "Stock transferred successfully", movements)
+}
+
+// ListLowStock reports products and variants at or below their reorder
+// threshold (admin only)
+func (h *InventoryHandler) ListLowStock(c *fiber.Ctx) error {
+	items, err := h.stockAlertUseCase.ListLowStock(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve low-stock report")
+	}
+
+	return response.Success(c, "Low-stock report retrieved", items)
+}
+
+// CreateWarehouse handles warehouse creation (admin only)
+func (h *InventoryHandler) CreateWarehouse(c *fiber.Ctx) error {
+	var req WarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	warehouse, err := h.warehouseUseCase.CreateWarehouse(c.Context(), warehouseInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, warehouse.Version)
+	return response.Created(c, "Warehouse created successfully", warehouse)
+}
+
+// GetWarehouse retrieves a warehouse by ID (admin only)
+func (h *InventoryHandler) GetWarehouse(c *fiber.Ctx) error {
+	warehouseID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid warehouse ID")
+	}
+
+	warehouse, err := h.warehouseUseCase.GetWarehouse(c.Context(), uint(warehouseID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	setETag(c, warehouse.Version)
+	return response.Success(c, "Warehouse retrieved", warehouse)
+}
+
+// ListWarehouses retrieves all warehouses in allocation order (admin only)
+func (h *InventoryHandler) ListWarehouses(c *fiber.Ctx) error {
+	warehouses, err := h.warehouseUseCase.ListWarehouses(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve warehouses")
+	}
+
+	return response.Success(c, "Warehouses retrieved", warehouses)
+}
+
+// UpdateWarehouse updates a warehouse, only the version named by the
+// required If-Match header (admin only)
+func (h *InventoryHandler) UpdateWarehouse(c *fiber.Ctx) error {
+	warehouseID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid warehouse ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req WarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	warehouse, err := h.warehouseUseCase.UpdateWarehouse(c.Context(), uint(warehouseID), version, warehouseInput(req))
+	if err != nil {
+		return versionedError(c, err)
+	}
+
+	setETag(c, warehouse.Version)
+	return response.Success(c, "Warehouse updated successfully", warehouse)
+}
+
+// DeleteWarehouse deletes an empty warehouse, only the version named by the
+// required If-Match header (admin only)
+func (h *InventoryHandler) DeleteWarehouse(c *fiber.Ctx) error {
+	warehouseID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid warehouse ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.warehouseUseCase.DeleteWarehouse(c.Context(), uint(warehouseID), version); err != nil {
+		return versionedError(c, err)
+	}
+
+	return response.Success(c, "Warehouse deleted successfully", nil)
+}
+
+func warehouseInput(req WarehouseRequest) usecase.WarehouseInput {
+	return usecase.WarehouseInput{
+		Code:      req.Code,
+		Name:      req.Name,
+		Address:   req.Address,
+		Priority:  req.Priority,
+		IsDefault: req.IsDefault,
+	}
+}
+
+// optionalQueryID parses an optional positive ID query parameter
+func optionalQueryID(c *fiber.Ctx, key string) (*uint, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(c.Query(key), 10, 0)
+	if err != nil || id == 0 {
+		return nil, fiber.ErrBadRequest
+	}
+	v := uint(id)
+	return &v, nil
+}
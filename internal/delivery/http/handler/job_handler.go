@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobHandler struct {
+	jobUseCase *usecase.JobUseCase
+}
+
+func NewJobHandler(jobUseCase *usecase.JobUseCase) *JobHandler {
+	return &JobHandler{
+		jobUseCase: jobUseCase,
+	}
+}
+
+// ListJobs lists the jobs of the queue, newest first, optionally filtered by
+// "status" and "type" and paginated with "limit" and "offset" (admin only)
+func (h *JobHandler) ListJobs(c *fiber.Ctx) error {
+	filter := repository.JobFilter{
+		Status: domain.JobStatus(c.Query("status")),
+		Type:   c.Query("type"),
+	}
+
+	jobs, err := h.jobUseCase.ListJobs(c.Context(), filter, c.QueryInt("limit", 100), c.QueryInt("offset"))
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve jobs")
+	}
+
+	return response.Success(c, "Jobs retrieved", jobs)
+}
+
+// GetJob retrieves a job by ID (admin only)
+func (h *JobHandler) GetJob(c *fiber.Ctx) error {
+	jobID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid job ID")
+	}
+
+	job, err := h.jobUseCase.GetJob(c.Context(), uint(jobID))
+	if err != nil {
+		if errors.Is(err, usecase.ErrJobNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to retrieve job")
+	}
+
+	return response.Success(c, "Job retrieved", job)
+}
+
+// RetryJob runs a dead or discarded job again (admin only)
+func (h *JobHandler) RetryJob(c *fiber.Ctx) error {
+	jobID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid job ID")
+	}
+
+	job, err := h.jobUseCase.RetryJob(c.Context(), uint(jobID))
+	if err != nil {
+		return jobError(c, err)
+	}
+
+	return response.Success(c, "Job queued for retry", job)
+}
+
+// DiscardJob drops a pending or dead job (admin only)
+func (h *JobHandler) DiscardJob(c *fiber.Ctx) error {
+	jobID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid job ID")
+	}
+
+	job, err := h.jobUseCase.DiscardJob(c.Context(), uint(jobID))
+	if err != nil {
+		return jobError(c, err)
+	}
+
+	return response.Success(c, "Job discarded", job)
+}
+
+// jobError maps the errors of changing a job to responses
+func jobError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrJobNotFound):
+		return response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrJobNotRetryable), errors.Is(err, domain.ErrJobNotDiscardable),
+		errors.Is(err, usecase.ErrJobDuplicate):
+		return response.Conflict(c, err.Error())
+	default:
+		return response.InternalError(c, "Failed to update job")
+	}
+}
@@ -1,58 +1,113 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/delivery/http/middleware"
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
 	"github.com/example/clean-arch-template/internal/usecase"
 	"github.com/example/clean-arch-template/pkg/response"
 	"github.com/gofiber/fiber/v2"
 )
 
 type OrderHandler struct {
-	orderUseCase *usecase.OrderUseCase
+	orderUseCase    *usecase.OrderUseCase
+	shipmentUseCase *usecase.ShipmentUseCase
+	paymentUseCase  *usecase.PaymentUseCase
+	invoiceUseCase  *usecase.InvoiceUseCase
 }
 
-func NewOrderHandler(orderUseCase *usecase.OrderUseCase) *OrderHandler {
+func NewOrderHandler(
+	orderUseCase *usecase.OrderUseCase,
+	shipmentUseCase *usecase.ShipmentUseCase,
+	paymentUseCase *usecase.PaymentUseCase,
+	invoiceUseCase *usecase.InvoiceUseCase,
+) *OrderHandler {
 	return &OrderHandler{
-		orderUseCase: orderUseCase,
+		orderUseCase:    orderUseCase,
+		shipmentUseCase: shipmentUseCase,
+		paymentUseCase:  paymentUseCase,
+		invoiceUseCase:  invoiceUseCase,
 	}
 }
 
-// CreateOrder handles order creation with transaction
+// CreateShipmentRequest describes a shipment of an order. Without items
+// everything not shipped yet goes in the shipment.
+type CreateShipmentRequest struct {
+	Carrier        string                      `json:"carrier"`
+	TrackingNumber string                      `json:"tracking_number"`
+	Note           string                      `json:"note"`
+	Items          []CreateShipmentItemRequest `json:"items"`
+}
+
+type CreateShipmentItemRequest struct {
+	OrderItemID uint `json:"order_item_id" validate:"required"`
+	Quantity    int  `json:"quantity" validate:"required,gt=0"`
+}
+
+// ShipmentStatusRequest moves a shipment to "shipped" or "delivered"
+type ShipmentStatusRequest struct {
+	Status         domain.ShipmentStatus `json:"status" validate:"required"`
+	Note           string                `json:"note"`
+	Carrier        string                `json:"carrier"`
+	TrackingNumber string                `json:"tracking_number"`
+}
+
+// CreateOrder places an order for the authenticated user with transaction
 func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
 	var req usecase.CreateOrderRequest
 	if err := c.BodyParser(&req); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
 	// Create order (with automatic transaction handling)
-	order, err := h.orderUseCase.CreateOrder(c.Context(), req)
+	order, err := h.orderUseCase.CreateOrder(c.Context(), userID, req)
 	if err != nil {
+		if errors.Is(err, usecase.ErrEmailNotVerified) {
+			return response.Forbidden(c, err.Error())
+		}
 		return response.BadRequest(c, err.Error())
 	}
 
 	return response.Created(c, "Order created successfully", order)
 }
 
-// GetOrderDetail retrieves order details
+// GetOrderDetail retrieves order details. Customers get only their own
+// orders.
 func (h *OrderHandler) GetOrderDetail(c *fiber.Ctx) error {
 	orderID, err := c.ParamsInt("id")
 	if err != nil {
 		return response.BadRequest(c, "Invalid order ID")
 	}
 
-	order, err := h.orderUseCase.GetOrderDetail(c.Context(), uint(orderID))
+	order, err := h.orderUseCase.GetOrderDetail(c.Context(), uint(orderID), orderViewer(c))
 	if err != nil {
-		return response.NotFound(c, err.Error())
+		if errors.Is(err, usecase.ErrOrderNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to retrieve order")
 	}
 
 	return response.Success(c, "Order retrieved", order)
 }
 
-// ListUserOrders retrieves all orders for a user
+// ListUserOrders retrieves all orders for a user. Customers can list only
+// their own orders.
 func (h *OrderHandler) ListUserOrders(c *fiber.Ctx) error {
 	userID, err := c.ParamsInt("user_id")
 	if err != nil {
 		return response.BadRequest(c, "Invalid user ID")
 	}
+	if viewer := orderViewer(c); viewer != nil && *viewer != uint(userID) {
+		return response.Forbidden(c, "You can only list your own orders")
+	}
 
 	orders, err := h.orderUseCase.ListUserOrders(c.Context(), uint(userID))
 	if err != nil {
@@ -61,3 +116,247 @@ func (h *OrderHandler) ListUserOrders(c *fiber.Ctx) error {
 
 	return response.Success(c, "Orders retrieved", orders)
 }
+
+// SearchOrders searches the orders of all users, filtered by comma-separated
+// "ids", "status", "email", "product_id", an RFC 3339 time range "from"
+// (inclusive) to "to" (exclusive) and "min_total"/"max_total", sorted with
+// "sort" (e.g. "-total_amount") and paginated with "limit" and "offset"
+// (admin only)
+func (h *OrderHandler) SearchOrders(c *fiber.Ctx) error {
+	filter, err := orderFilter(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	orders, err := h.orderUseCase.SearchOrders(c.Context(), filter, c.QueryInt("limit", 100), c.QueryInt("offset"))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Orders retrieved", orders)
+}
+
+// ExportOrders streams every order matching the SearchOrders filters as CSV,
+// in the same order (admin only)
+func (h *OrderHandler) ExportOrders(c *fiber.Ctx) error {
+	filter, err := orderFilter(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	if err := usecase.ValidateOrderFilter(filter); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	c.Attachment("orders.csv")
+	c.Set(fiber.HeaderContentType, "text/csv")
+
+	streamBody(c, "order export", func(ctx context.Context, w io.Writer) error {
+		return h.orderUseCase.ExportOrders(ctx, w, filter)
+	})
+
+	return nil
+}
+
+// CompletePayment records that the payment of an order was received, which
+// marks the order paid and issues its invoice (admin only)
+func (h *OrderHandler) CompletePayment(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid order ID")
+	}
+
+	payment, err := h.paymentUseCase.CompletePayment(c.Context(), uint(orderID))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrOrderNotFound), errors.Is(err, usecase.ErrPaymentNotFound):
+			return response.NotFound(c, err.Error())
+		case errors.Is(err, usecase.ErrPaymentNotPending):
+			return response.Conflict(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to complete payment")
+	}
+
+	return response.Success(c, "Payment completed", payment)
+}
+
+// GetInvoice downloads the invoice of a paid order as PDF. Customers get
+// only the invoices of their own orders.
+func (h *OrderHandler) GetInvoice(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid order ID")
+	}
+
+	invoice, data, err := h.invoiceUseCase.GetInvoice(c.Context(), uint(orderID), orderViewer(c))
+	if err != nil {
+		if errors.Is(err, usecase.ErrOrderNotFound) || errors.Is(err, usecase.ErrInvoiceNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to retrieve invoice")
+	}
+
+	c.Attachment(invoice.Number + ".pdf")
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	return c.Send(data)
+}
+
+// ListShipments retrieves the shipments of an order with their tracking
+// history. Customers see only the shipments of their own orders.
+func (h *OrderHandler) ListShipments(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid order ID")
+	}
+
+	shipments, err := h.shipmentUseCase.ListShipments(c.Context(), uint(orderID), orderViewer(c))
+	if err != nil {
+		if errors.Is(err, usecase.ErrOrderNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to retrieve shipments")
+	}
+
+	return response.Success(c, "Shipments retrieved", shipments)
+}
+
+// CreateShipment packs a shipment of some or all items of an order (admin
+// only)
+func (h *OrderHandler) CreateShipment(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid order ID")
+	}
+
+	var req CreateShipmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	input := usecase.CreateShipmentInput{
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		Note:           req.Note,
+	}
+	for _, item := range req.Items {
+		input.Items = append(input.Items, usecase.ShipmentItemInput{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	shipment, err := h.shipmentUseCase.CreateShipment(c.Context(), uint(orderID), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrOrderNotFound):
+			return response.NotFound(c, err.Error())
+		case errors.Is(err, usecase.ErrOrderNotShippable):
+			return response.Conflict(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, "Shipment created successfully", shipment)
+}
+
+// UpdateShipmentStatus moves a shipment forward (admin only). Delivering the
+// last items of an order completes it.
+func (h *OrderHandler) UpdateShipmentStatus(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid order ID")
+	}
+	shipmentID, err := c.ParamsInt("shipment_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid shipment ID")
+	}
+
+	var req ShipmentStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	shipment, err := h.shipmentUseCase.UpdateShipmentStatus(c.Context(), uint(orderID), uint(shipmentID), usecase.ShipmentStatusInput{
+		Status:         req.Status,
+		Note:           req.Note,
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+	})
+	if err != nil {
+		if errors.Is(err, usecase.ErrOrderNotFound) || errors.Is(err, usecase.ErrShipmentNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Shipment updated successfully", shipment)
+}
+
+// orderFilter reads the order search filters from the query string
+func orderFilter(c *fiber.Ctx) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		Status:    domain.OrderStatus(c.Query("status")),
+		UserEmail: strings.TrimSpace(c.Query("email")),
+	}
+
+	var err error
+	if filter.IDs, err = queryIDs(c, "ids"); err != nil {
+		return filter, errors.New("invalid order IDs")
+	}
+	if filter.ProductID, err = optionalQueryID(c, "product_id"); err != nil {
+		return filter, errors.New("invalid product ID")
+	}
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return filter, errors.New("invalid from time, expected RFC 3339")
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return filter, errors.New("invalid to time, expected RFC 3339")
+	}
+	if filter.MinTotal, err = queryAmount(c, "min_total"); err != nil {
+		return filter, errors.New("invalid minimum total")
+	}
+	if filter.MaxTotal, err = queryAmount(c, "max_total"); err != nil {
+		return filter, errors.New("invalid maximum total")
+	}
+	if filter.SortBy, filter.Ascending, err = usecase.ParseOrderSort(c.Query("sort")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// queryIDs parses an optional comma-separated list of IDs
+func queryIDs(c *fiber.Ctx, key string) ([]uint, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+	var ids []uint
+	for _, raw := range strings.Split(c.Query(key), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 0)
+		if err != nil || id == 0 {
+			return nil, fiber.ErrBadRequest
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// queryAmount parses an optional non-negative amount
+func queryAmount(c *fiber.Ctx, key string) (*float64, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(c.Query(key), 64)
+	if err != nil || amount < 0 {
+		return nil, fiber.ErrBadRequest
+	}
+	return &amount, nil
+}
+
+// orderViewer limits customers to their own orders: it returns their user
+// ID, or nil for admins, who see all orders
+func orderViewer(c *fiber.Ctx) *uint {
+	if middleware.CurrentUserRole(c) == string(domain.UserRoleAdmin) {
+		return nil
+	}
+	userID, _ := middleware.CurrentUserID(c)
+	return &userID
+}
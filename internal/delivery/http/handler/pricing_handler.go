@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PricingHandler struct {
+	pricingUseCase *usecase.PricingUseCase
+}
+
+func NewPricingHandler(pricingUseCase *usecase.PricingUseCase) *PricingHandler {
+	return &PricingHandler{
+		pricingUseCase: pricingUseCase,
+	}
+}
+
+// TaxRateRequest describes a tax rate. An empty region applies everywhere
+// and a missing category to all products.
+type TaxRateRequest struct {
+	Name       string  `json:"name" validate:"required"`
+	Region     string  `json:"region"`
+	CategoryID *uint   `json:"category_id"`
+	Rate       float64 `json:"rate" validate:"gte=0,lte=100"`
+	Inclusive  bool    `json:"inclusive"`
+}
+
+// ShippingRateRequest describes a shipping rate. An empty region applies
+// everywhere; a free_above of 0 disables free shipping.
+type ShippingRateRequest struct {
+	Region    string  `json:"region"`
+	Amount    float64 `json:"amount" validate:"gte=0"`
+	FreeAbove float64 `json:"free_above" validate:"gte=0"`
+}
+
+// ListTaxRates retrieves all tax rates (admin only)
+func (h *PricingHandler) ListTaxRates(c *fiber.Ctx) error {
+	rates, err := h.pricingUseCase.ListTaxRates(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve tax rates")
+	}
+
+	return response.Success(c, "Tax rates retrieved", rates)
+}
+
+// CreateTaxRate creates a new tax rate (admin only)
+func (h *PricingHandler) CreateTaxRate(c *fiber.Ctx) error {
+	var req TaxRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	rate, err := h.pricingUseCase.CreateTaxRate(c.Context(), taxRateInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, rate.Version)
+	return response.Created(c, "Tax rate created successfully", rate)
+}
+
+// GetTaxRate retrieves a tax rate by ID (admin only)
+func (h *PricingHandler) GetTaxRate(c *fiber.Ctx) error {
+	rateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid tax rate ID")
+	}
+
+	rate, err := h.pricingUseCase.GetTaxRate(c.Context(), uint(rateID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	setETag(c, rate.Version)
+	return response.Success(c, "Tax rate retrieved", rate)
+}
+
+// UpdateTaxRate updates a tax rate, only the version named by the required
+// If-Match header (admin only)
+func (h *PricingHandler) UpdateTaxRate(c *fiber.Ctx) error {
+	rateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid tax rate ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req TaxRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	rate, err := h.pricingUseCase.UpdateTaxRate(c.Context(), uint(rateID), version, taxRateInput(req))
+	if err != nil {
+		return versionedError(c, err)
+	}
+
+	setETag(c, rate.Version)
+	return response.Success(c, "Tax rate updated successfully", rate)
+}
+
+// DeleteTaxRate deletes a tax rate, only the version named by the required
+// If-Match header (admin only)
+func (h *PricingHandler) DeleteTaxRate(c *fiber.Ctx) error {
+	rateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid tax rate ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.pricingUseCase.DeleteTaxRate(c.Context(), uint(rateID), version); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			return response.PreconditionFailed(c, err.Error())
+		}
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Tax rate deleted successfully", nil)
+}
+
+// ListShippingRates retrieves all shipping rates (admin only)
+func (h *PricingHandler) ListShippingRates(c *fiber.Ctx) error {
+	rates, err := h.pricingUseCase.ListShippingRates(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve shipping rates")
+	}
+
+	return response.Success(c, "Shipping rates retrieved", rates)
+}
+
+// CreateShippingRate creates a new shipping rate (admin only)
+func (h *PricingHandler) CreateShippingRate(c *fiber.Ctx) error {
+	var req ShippingRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	rate, err := h.pricingUseCase.CreateShippingRate(c.Context(), shippingRateInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, rate.Version)
+	return response.Created(c, "Shipping rate created successfully", rate)
+}
+
+// GetShippingRate retrieves a shipping rate by ID (admin only)
+func (h *PricingHandler) GetShippingRate(c *fiber.Ctx) error {
+	rateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid shipping rate ID")
+	}
+
+	rate, err := h.pricingUseCase.GetShippingRate(c.Context(), uint(rateID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	setETag(c, rate.Version)
+	return response.Success(c, "Shipping rate retrieved", rate)
+}
+
+// UpdateShippingRate updates a shipping rate, only the version named by the required
+// If-Match header (admin only)
+func (h *PricingHandler) UpdateShippingRate(c *fiber.Ctx) error {
+	rateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid shipping rate ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req ShippingRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	rate, err := h.pricingUseCase.UpdateShippingRate(c.Context(), uint(rateID), version, shippingRateInput(req))
+	if err != nil {
+		return versionedError(c, err)
+	}
+
+	setETag(c, rate.Version)
+	return response.Success(c, "Shipping rate updated successfully", rate)
+}
+
+// DeleteShippingRate deletes a shipping rate, only the version named by the required
+// If-Match header (admin only)
+func (h *PricingHandler) DeleteShippingRate(c *fiber.Ctx) error {
+	rateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid shipping rate ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.pricingUseCase.DeleteShippingRate(c.Context(), uint(rateID), version); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			return response.PreconditionFailed(c, err.Error())
+		}
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Shipping rate deleted successfully", nil)
+}
+
+func taxRateInput(req TaxRateRequest) usecase.TaxRateInput {
+	return usecase.TaxRateInput{
+		Name:       req.Name,
+		Region:     req.Region,
+		CategoryID: req.CategoryID,
+		Rate:       req.Rate,
+		Inclusive:  req.Inclusive,
+	}
+}
+
+func shippingRateInput(req ShippingRateRequest) usecase.ShippingRateInput {
+	return usecase.ShippingRateInput{
+		Region:    req.Region,
+		Amount:    req.Amount,
+		FreeAbove: req.FreeAbove,
+	}
+}
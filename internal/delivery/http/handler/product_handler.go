@@ -1,33 +1,78 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
 	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/mergepatch"
 	"github.com/example/clean-arch-template/pkg/response"
 	"github.com/gofiber/fiber/v2"
 )
 
 type ProductHandler struct {
 	productUseCase *usecase.ProductUseCase
+	mediaUseCase   *usecase.ProductMediaUseCase
+	importUseCase  *usecase.ProductImportUseCase
 }
 
-func NewProductHandler(productUseCase *usecase.ProductUseCase) *ProductHandler {
+func NewProductHandler(
+	productUseCase *usecase.ProductUseCase,
+	mediaUseCase *usecase.ProductMediaUseCase,
+	importUseCase *usecase.ProductImportUseCase,
+) *ProductHandler {
 	return &ProductHandler{
 		productUseCase: productUseCase,
+		mediaUseCase:   mediaUseCase,
+		importUseCase:  importUseCase,
 	}
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Stock       int     `json:"stock" validate:"required,gte=0"`
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price" validate:"required,gt=0"`
+	Stock       int      `json:"stock" validate:"required,gte=0"`
+	CategoryID  *uint    `json:"category_id"`
+	Tags        []string `json:"tags"`
+
+	ReorderThreshold int `json:"reorder_threshold" validate:"gte=0"`
 }
 
+// UpdateProductRequest replaces the product fields. Omitting tags keeps the
+// current tags; an empty list removes them.
 type UpdateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Stock       int     `json:"stock" validate:"required,gte=0"`
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price" validate:"required,gt=0"`
+	Stock       int      `json:"stock" validate:"required,gte=0"`
+	CategoryID  *uint    `json:"category_id"`
+	Tags        []string `json:"tags"`
+
+	ReorderThreshold int `json:"reorder_threshold" validate:"gte=0"`
+}
+
+type VariantRequest struct {
+	SKU   string  `json:"sku" validate:"required"`
+	Size  string  `json:"size"`
+	Color string  `json:"color"`
+	Price float64 `json:"price" validate:"required,gt=0"`
+	Stock int     `json:"stock" validate:"gte=0"`
+}
+
+type UpdateImageRequest struct {
+	AltText string `json:"alt_text"`
+}
+
+type ReorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" validate:"required"`
 }
 
 // CreateProduct handles product creation
@@ -37,11 +82,22 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
-	product, err := h.productUseCase.CreateProduct(c.Context(), req.Name, req.Description, req.Price, req.Stock)
+	product, err := h.productUseCase.CreateProduct(c.Context(), usecase.ProductInput{
+		SKU:         req.SKU,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		Stock:       req.Stock,
+		CategoryID:  req.CategoryID,
+		Tags:        req.Tags,
+
+		ReorderThreshold: req.ReorderThreshold,
+	})
 	if err != nil {
 		return response.BadRequest(c, err.Error())
 	}
 
+	setETag(c, product.Version)
 	return response.Created(c, "Product created successfully", product)
 }
 
@@ -57,49 +113,441 @@ func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
 		return response.NotFound(c, err.Error())
 	}
 
+	setETag(c, product.Version)
 	return response.Success(c, "Product retrieved", product)
 }
 
-// ListProducts retrieves all products
+// ListProducts retrieves all products, optionally filtered by the
+// "category" (ID, including subcategories) and "tag" query parameters
 func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
-	products, err := h.productUseCase.ListProducts(c.Context())
+	tag := c.Query("tag")
+	if c.Query("category") == "" && tag == "" {
+		products, err := h.productUseCase.ListProducts(c.Context())
+		if err != nil {
+			return response.InternalError(c, "Failed to retrieve products")
+		}
+		return response.Success(c, "Products retrieved", products)
+	}
+
+	var categoryID *uint
+	if c.Query("category") != "" {
+		id, err := strconv.ParseUint(c.Query("category"), 10, 0)
+		if err != nil {
+			return response.BadRequest(c, "Invalid category ID")
+		}
+		cid := uint(id)
+		categoryID = &cid
+	}
+
+	products, err := h.productUseCase.SearchProducts(c.Context(), categoryID, tag)
 	if err != nil {
-		return response.InternalError(c, "Failed to retrieve products")
+		return response.BadRequest(c, err.Error())
 	}
 
 	return response.Success(c, "Products retrieved", products)
 }
 
-// UpdateProduct updates an existing product
+// UpdateProduct updates an existing product, only the version named by the
+// required If-Match header (admin only)
 func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	productID, err := c.ParamsInt("id")
 	if err != nil {
 		return response.BadRequest(c, "Invalid product ID")
 	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
 
 	var req UpdateProductRequest
 	if err := c.BodyParser(&req); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
-	product, err := h.productUseCase.UpdateProduct(c.Context(), uint(productID), req.Name, req.Description, req.Price, req.Stock)
+	product, err := h.productUseCase.UpdateProduct(c.Context(), uint(productID), version, usecase.ProductInput{
+		SKU:         req.SKU,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		Stock:       req.Stock,
+		CategoryID:  req.CategoryID,
+		Tags:        req.Tags,
+
+		ReorderThreshold: req.ReorderThreshold,
+	})
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return productError(c, err)
 	}
 
+	setETag(c, product.Version)
 	return response.Success(c, "Product updated successfully", product)
 }
 
-// DeleteProduct deletes a product
+// PatchProduct partially updates a product with a JSON Merge Patch, only the
+// version named by the required If-Match header (admin only)
+func (h *ProductHandler) PatchProduct(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case mergepatch.ContentType, fiber.MIMEApplicationJSON:
+	default:
+		return response.UnsupportedMediaType(c, "Content-Type must be "+mergepatch.ContentType)
+	}
+
+	product, err := h.productUseCase.PatchProduct(c.Context(), uint(productID), version, c.Body())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	setETag(c, product.Version)
+	return response.Success(c, "Product updated successfully", product)
+}
+
+// DeleteProduct deletes a product, only the version named by the required
+// If-Match header (admin only)
 func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	productID, err := c.ParamsInt("id")
 	if err != nil {
 		return response.BadRequest(c, "Invalid product ID")
 	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
 
-	if err := h.productUseCase.DeleteProduct(c.Context(), uint(productID)); err != nil {
-		return response.BadRequest(c, err.Error())
+	if err := h.productUseCase.DeleteProduct(c.Context(), uint(productID), version); err != nil {
+		return productError(c, err)
 	}
 
 	return response.Success(c, "Product deleted successfully", nil)
 }
+
+// RestoreProduct restores a soft-deleted product (admin only)
+func (h *ProductHandler) RestoreProduct(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	product, err := h.productUseCase.RestoreProduct(c.Context(), uint(productID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	setETag(c, product.Version)
+	return response.Success(c, "Product restored successfully", product)
+}
+
+// PurgeProduct permanently deletes a soft-deleted product (admin only)
+func (h *ProductHandler) PurgeProduct(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	// Image files are removed once the records referencing them are gone
+	keys, err := h.mediaUseCase.StorageKeys(c.Context(), uint(productID))
+	if err != nil {
+		return response.InternalError(c, "Failed to purge product")
+	}
+
+	if err := h.productUseCase.PurgeProduct(c.Context(), uint(productID)); err != nil {
+		if errors.Is(err, usecase.ErrDeletedProductNotFound) {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+	h.mediaUseCase.DeleteFiles(c.Context(), keys)
+
+	return response.Success(c, "Product purged successfully", nil)
+}
+
+// ListTags retrieves all product tags
+func (h *ProductHandler) ListTags(c *fiber.Ctx) error {
+	tags, err := h.productUseCase.ListTags(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve tags")
+	}
+
+	return response.Success(c, "Tags retrieved", tags)
+}
+
+// ListVariants retrieves the variants of a product
+func (h *ProductHandler) ListVariants(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	variants, err := h.productUseCase.ListVariants(c.Context(), uint(productID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Variants retrieved", variants)
+}
+
+// CreateVariant adds a variant to a product (admin only)
+func (h *ProductHandler) CreateVariant(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	var req VariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	variant, err := h.productUseCase.CreateVariant(c.Context(), uint(productID), variantInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, variant.Version)
+	return response.Created(c, "Variant created successfully", variant)
+}
+
+// UpdateVariant updates a variant of a product, only the version named by the
+// required If-Match header (admin only)
+func (h *ProductHandler) UpdateVariant(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+	variantID, err := c.ParamsInt("variant_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid variant ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req VariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	variant, err := h.productUseCase.UpdateVariant(c.Context(), uint(productID), uint(variantID), version, variantInput(req))
+	if err != nil {
+		return versionedError(c, err)
+	}
+
+	setETag(c, variant.Version)
+	return response.Success(c, "Variant updated successfully", variant)
+}
+
+// DeleteVariant deletes a variant of a product, only the version named by the
+// required If-Match header (admin only)
+func (h *ProductHandler) DeleteVariant(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+	variantID, err := c.ParamsInt("variant_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid variant ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.productUseCase.DeleteVariant(c.Context(), uint(productID), uint(variantID), version); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			return response.PreconditionFailed(c, err.Error())
+		}
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Variant deleted successfully", nil)
+}
+
+// versionedError maps a version conflict to 412 Precondition Failed; anything
+// else is a bad request
+func versionedError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, domain.ErrVersionConflict) {
+		return response.PreconditionFailed(c, err.Error())
+	}
+	return response.BadRequest(c, err.Error())
+}
+
+// productError maps a missing product to 404 Not Found and other errors of
+// a versioned write like versionedError
+func productError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, usecase.ErrProductNotFound) {
+		return response.NotFound(c, err.Error())
+	}
+	return versionedError(c, err)
+}
+
+func variantInput(req VariantRequest) usecase.VariantInput {
+	return usecase.VariantInput{
+		SKU:   req.SKU,
+		Size:  req.Size,
+		Color: req.Color,
+		Price: req.Price,
+		Stock: req.Stock,
+	}
+}
+
+// UploadImage handles a multipart image upload in the "image" field with an
+// optional "alt_text" field (admin only)
+func (h *ProductHandler) UploadImage(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return response.BadRequest(c, "Missing image file")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.BadRequest(c, "Invalid image file")
+	}
+	defer file.Close()
+
+	image, err := h.mediaUseCase.UploadImage(c.Context(), uint(productID), file, c.FormValue("alt_text"))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrImageTooLarge), errors.Is(err, usecase.ErrImageDimensions):
+			return response.PayloadTooLarge(c, err.Error())
+		case errors.Is(err, usecase.ErrUnsupportedImage):
+			return response.UnsupportedMediaType(c, err.Error())
+		default:
+			return response.BadRequest(c, err.Error())
+		}
+	}
+
+	return response.Created(c, "Image uploaded successfully", image)
+}
+
+// ListImages retrieves the images of a product in display order
+func (h *ProductHandler) ListImages(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	images, err := h.mediaUseCase.ListImages(c.Context(), uint(productID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Images retrieved", images)
+}
+
+// UpdateImage updates the alt text of an image (admin only)
+func (h *ProductHandler) UpdateImage(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+	imageID, err := c.ParamsInt("image_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid image ID")
+	}
+
+	var req UpdateImageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	image, err := h.mediaUseCase.UpdateImage(c.Context(), uint(productID), uint(imageID), req.AltText)
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Image updated successfully", image)
+}
+
+// ReorderImages sets the display order of a product's images (admin only)
+func (h *ProductHandler) ReorderImages(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+
+	var req ReorderImagesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	images, err := h.mediaUseCase.ReorderImages(c.Context(), uint(productID), req.ImageIDs)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Images reordered successfully", images)
+}
+
+// DeleteImage deletes an image of a product (admin only)
+func (h *ProductHandler) DeleteImage(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid product ID")
+	}
+	imageID, err := c.ParamsInt("image_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid image ID")
+	}
+
+	if err := h.mediaUseCase.DeleteImage(c.Context(), uint(productID), uint(imageID)); err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Image deleted successfully", nil)
+}
+
+// ImportProducts upserts products by SKU from a CSV or JSON Lines request
+// body, selected with ?format= or the Content-Type header (admin only).
+// The body is read as a stream and may be arbitrarily large.
+func (h *ProductHandler) ImportProducts(c *fiber.Ctx) error {
+	format, err := usecase.ParseCatalogFormat(c.Query("format", c.Get(fiber.HeaderContentType)))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	batchSize := c.QueryInt("batch_size", usecase.DefaultImportBatchSize)
+
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	report, err := h.importUseCase.Import(c.Context(), body, format, batchSize)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Products imported", report)
+}
+
+// ExportProducts streams the full catalog as CSV or JSON Lines, selected
+// with ?format= (admin only)
+func (h *ProductHandler) ExportProducts(c *fiber.Ctx) error {
+	format, err := usecase.ParseCatalogFormat(c.Query("format", string(usecase.CatalogFormatCSV)))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	c.Attachment("products." + string(format))
+	c.Set(fiber.HeaderContentType, format.ContentType())
+
+	streamBody(c, "product export", func(ctx context.Context, w io.Writer) error {
+		return h.importUseCase.Export(ctx, w, format)
+	})
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamBody streams the response body from write. The stream runs after the
+// handler returns, when the request context can no longer be used, so write
+// gets its own context, cancelled as soon as writing to the client fails;
+// that stops the queries of a client that went away. Failures can only be
+// logged once streaming started.
+func streamBody(c *fiber.Ctx, name string, write func(ctx context.Context, w io.Writer) error) {
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if err := write(ctx, &cancelWriter{w: w, cancel: cancel}); err != nil {
+			slog.Error(name+" failed", "error", err)
+		}
+	})
+}
+
+// cancelWriter cancels its context when a write or flush fails
+type cancelWriter struct {
+	w      *bufio.Writer
+	cancel context.CancelFunc
+}
+
+func (cw *cancelWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	if err != nil {
+		cw.cancel()
+	}
+	return n, err
+}
+
+// Flush sends what was buffered to the client
+func (cw *cancelWriter) Flush() error {
+	err := cw.w.Flush()
+	if err != nil {
+		cw.cancel()
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"testing"
+)
+
+// brokenConn fails every write, like a client that went away
+type brokenConn struct{}
+
+func (brokenConn) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestCancelWriterCancelsWhenClientFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &cancelWriter{w: bufio.NewWriterSize(brokenConn{}, 16), cancel: cancel}
+
+	if _, err := w.Write([]byte("id,status\n")); err != nil {
+		t.Fatalf("buffered write failed: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("context cancelled before anything reached the client")
+	}
+
+	if err := w.Flush(); err == nil {
+		t.Fatal("Flush succeeded on a broken connection")
+	}
+	if ctx.Err() == nil {
+		t.Error("context not cancelled after the flush failed")
+	}
+}
+
+func TestCancelWriterCancelsOnOverflow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &cancelWriter{w: bufio.NewWriterSize(brokenConn{}, 16), cancel: cancel}
+
+	if _, err := w.Write(make([]byte, 64)); err == nil {
+		t.Fatal("write past the buffer succeeded on a broken connection")
+	}
+	if ctx.Err() == nil {
+		t.Error("context not cancelled after the write failed")
+	}
+}
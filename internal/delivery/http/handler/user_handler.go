@@ -1,18 +1,34 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/delivery/http/middleware"
+	"github.com/example/clean-arch-template/internal/domain"
 	"github.com/example/clean-arch-template/internal/usecase"
 	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/example/clean-arch-template/pkg/token"
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserHandler struct {
-	userUseCase *usecase.UserUseCase
+	userUseCase    *usecase.UserUseCase
+	accountUseCase *usecase.AccountUseCase
+	cartUseCase    *usecase.CartUseCase
+	tokens         *token.Manager
 }
 
-func NewUserHandler(userUseCase *usecase.UserUseCase) *UserHandler {
+func NewUserHandler(userUseCase *usecase.UserUseCase, accountUseCase *usecase.AccountUseCase, cartUseCase *usecase.CartUseCase, tokens *token.Manager) *UserHandler {
 	return &UserHandler{
-		userUseCase: userUseCase,
+		userUseCase:    userUseCase,
+		accountUseCase: accountUseCase,
+		cartUseCase:    cartUseCase,
+		tokens:         tokens,
 	}
 }
 
@@ -27,6 +43,35 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginResponse struct {
+	User        *domain.User `json:"user"`
+	AccessToken string       `json:"access_token"`
+	TokenType   string       `json:"token_type"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+}
+
 // Register handles user registration
 func (h *UserHandler) Register(c *fiber.Ctx) error {
 	var req RegisterRequest
@@ -49,12 +94,38 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
-	user, err := h.userUseCase.Login(c.Context(), req.Email, req.Password)
+	user, err := h.userUseCase.Login(c.Context(), req.Email, req.Password, c.IP())
 	if err != nil {
-		return response.Unauthorized(c, err.Error())
+		var throttled *usecase.LoginThrottledError
+		if errors.As(err, &throttled) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			return response.TooManyRequests(c, err.Error())
+		}
+		if errors.Is(err, usecase.ErrInvalidCredentials) {
+			return response.Unauthorized(c, err.Error())
+		}
+		return response.InternalError(c, "Failed to log in")
 	}
 
-	return response.Success(c, "Login successful", user)
+	accessToken, expiresAt, err := h.tokens.Issue(user.ID, string(user.Role))
+	if err != nil {
+		return response.InternalError(c, "Failed to issue access token")
+	}
+
+	// A guest cart follows the user into their account; failing to merge it
+	// must not fail the login
+	if guestToken := c.Get(HeaderCartToken); guestToken != "" {
+		if _, err := h.cartUseCase.MergeGuestCart(c.Context(), user.ID, guestToken); err != nil {
+			slog.ErrorContext(c.Context(), "failed to merge guest cart", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return response.Success(c, "Login successful", LoginResponse{
+		User:        user,
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+	})
 }
 
 // GetProfile retrieves user profile
@@ -71,3 +142,208 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 
 	return response.Success(c, "User profile retrieved", user)
 }
+
+// GetSecurity retrieves recent sign-in activity of the authenticated user
+func (h *UserHandler) GetSecurity(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	overview, err := h.userUseCase.GetSecurityOverview(c.Context(), userID)
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "Security overview retrieved", overview)
+}
+
+// DeleteUser soft-deletes a user (admin only)
+func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	if err := h.userUseCase.DeleteUser(c.Context(), uint(userID)); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "User deleted successfully", nil)
+}
+
+// RestoreUser restores a soft-deleted user (admin only)
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	user, err := h.userUseCase.RestoreUser(c.Context(), uint(userID))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "User restored successfully", user)
+}
+
+// PurgeUser permanently deletes a soft-deleted user (admin only)
+func (h *UserHandler) PurgeUser(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	if err := h.userUseCase.PurgeUser(c.Context(), uint(userID)); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "User purged successfully", nil)
+}
+
+// UnlockUser clears the login lockout of a user (admin only)
+func (h *UserHandler) UnlockUser(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID")
+	}
+
+	if err := h.userUseCase.UnlockUser(c.Context(), uint(userID), c.IP()); err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "User unlocked successfully", nil)
+}
+
+// VerifyEmail confirms a user's email address with an emailed token
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	user, err := h.userUseCase.VerifyEmail(c.Context(), req.Token)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Email verified successfully", user)
+}
+
+// ResendVerificationEmail sends a new verification email to the authenticated user
+func (h *UserHandler) ResendVerificationEmail(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	if err := h.userUseCase.ResendVerificationEmail(c.Context(), userID); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Verification email sent", nil)
+}
+
+// RequestPasswordReset emails a password reset link
+func (h *UserHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var req PasswordResetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.userUseCase.RequestPasswordReset(c.Context(), req.Email); err != nil {
+		return response.InternalError(c, "Failed to request password reset")
+	}
+
+	// Same response whether or not the email is registered
+	return response.Success(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ConfirmPasswordReset sets a new password using an emailed token
+func (h *UserHandler) ConfirmPasswordReset(c *fiber.Ctx) error {
+	var req PasswordResetConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.userUseCase.ResetPassword(c.Context(), req.Token, req.Password); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Password reset successfully", nil)
+}
+
+// GetMe retrieves the authenticated user's profile
+func (h *UserHandler) GetMe(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	user, err := h.userUseCase.GetProfile(c.Context(), userID)
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+
+	return response.Success(c, "User profile retrieved", user)
+}
+
+// UpdateMe updates the authenticated user's profile
+func (h *UserHandler) UpdateMe(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req usecase.UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	user, err := h.userUseCase.UpdateProfile(c.Context(), userID, req)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Profile updated successfully", user)
+}
+
+// ChangePassword changes the authenticated user's password
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.userUseCase.ChangePassword(c.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, usecase.ErrIncorrectPassword) {
+			return response.Forbidden(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Password changed successfully", nil)
+}
+
+// DeleteMe deletes and anonymizes the authenticated user's account
+func (h *UserHandler) DeleteMe(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var req DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.accountUseCase.DeleteAccount(c.Context(), userID, req.Password); err != nil {
+		if errors.Is(err, usecase.ErrIncorrectPassword) {
+			return response.Forbidden(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Success(c, "Account deleted successfully", nil)
+}
+
+// ExportMe returns everything stored about the authenticated user as a
+// downloadable JSON archive
+func (h *UserHandler) ExportMe(c *fiber.Ctx) error {
+	userID, _ := middleware.CurrentUserID(c)
+
+	export, err := h.accountUseCase.ExportData(c.Context(), userID)
+	if err != nil {
+		return response.InternalError(c, "Failed to export account data")
+	}
+
+	c.Attachment(fmt.Sprintf("user-%d-export.json", userID))
+	return c.Status(fiber.StatusOK).JSON(export)
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	webhookUseCase *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUseCase *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUseCase: webhookUseCase,
+	}
+}
+
+// WebhookRequest describes a webhook subscription. Secret is generated when
+// left empty on create and kept when left empty on update; Active defaults
+// to true.
+type WebhookRequest struct {
+	URL         string   `json:"url" validate:"required,url"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events" validate:"required,min=1"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// CreateWebhook subscribes a URL to events (admin only)
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	var req WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	subscription, err := h.webhookUseCase.CreateWebhook(c.Context(), webhookInput(req))
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	setETag(c, subscription.Version)
+	return response.Created(c, "Webhook created successfully", subscription)
+}
+
+// GetWebhook retrieves a webhook subscription by ID (admin only)
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid webhook ID")
+	}
+
+	subscription, err := h.webhookUseCase.GetWebhook(c.Context(), uint(webhookID))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	setETag(c, subscription.Version)
+	return response.Success(c, "Webhook retrieved", subscription)
+}
+
+// ListWebhooks retrieves all webhook subscriptions (admin only)
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	subscriptions, err := h.webhookUseCase.ListWebhooks(c.Context())
+	if err != nil {
+		return response.InternalError(c, "Failed to retrieve webhooks")
+	}
+
+	return response.Success(c, "Webhooks retrieved", subscriptions)
+}
+
+// UpdateWebhook updates a webhook subscription, only the version named by the
+// required If-Match header (admin only)
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid webhook ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	var req WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	subscription, err := h.webhookUseCase.UpdateWebhook(c.Context(), uint(webhookID), version, webhookInput(req))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	setETag(c, subscription.Version)
+	return response.Success(c, "Webhook updated successfully", subscription)
+}
+
+// DeleteWebhook deletes a webhook subscription with its delivery log, only
+// the version named by the required If-Match header (admin only)
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid webhook ID")
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	if err := h.webhookUseCase.DeleteWebhook(c.Context(), uint(webhookID), version); err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, "Webhook deleted successfully", nil)
+}
+
+// ListDeliveries lists the deliveries of a webhook subscription, newest
+// first, optionally filtered by "status" and "event_type" and paginated with
+// "limit" and "offset" (admin only)
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid webhook ID")
+	}
+
+	filter := repository.WebhookDeliveryFilter{
+		SubscriptionID: uint(webhookID),
+		Status:         domain.WebhookDeliveryStatus(c.Query("status")),
+		EventType:      c.Query("event_type"),
+	}
+	deliveries, err := h.webhookUseCase.ListDeliveries(c.Context(), filter, c.QueryInt("limit", 100), c.QueryInt("offset"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, "Webhook deliveries retrieved", deliveries)
+}
+
+// GetDelivery retrieves a delivery with every attempt and its response
+// (admin only)
+func (h *WebhookHandler) GetDelivery(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid webhook ID")
+	}
+	deliveryID, err := c.ParamsInt("delivery_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid delivery ID")
+	}
+
+	delivery, err := h.webhookUseCase.GetDelivery(c.Context(), uint(webhookID), uint(deliveryID))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, "Webhook delivery retrieved", delivery)
+}
+
+// Redeliver sends a delivery again (admin only)
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid webhook ID")
+	}
+	deliveryID, err := c.ParamsInt("delivery_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid delivery ID")
+	}
+
+	delivery, err := h.webhookUseCase.Redeliver(c.Context(), uint(webhookID), uint(deliveryID))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, "Webhook delivery queued", delivery)
+}
+
+// webhookError maps webhook and version errors to responses; anything else is a bad
+// request
+func webhookError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, usecase.ErrWebhookNotFound) || errors.Is(err, usecase.ErrWebhookDeliveryNotFound) {
+		return response.NotFound(c, err.Error())
+	}
+	if errors.Is(err, domain.ErrVersionConflict) {
+		return response.PreconditionFailed(c, err.Error())
+	}
+	return response.BadRequest(c, err.Error())
+}
+
+func webhookInput(req WebhookRequest) usecase.WebhookInput {
+	return usecase.WebhookInput{
+		URL:         req.URL,
+		Secret:      req.Secret,
+		Events:      req.Events,
+		Description: req.Description,
+		Active:      req.Active,
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/example/clean-arch-template/internal/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Actor records who performs the request so use cases can attribute changes.
+// It must run after Authenticate, and after the requestid middleware to tie
+// changes to the request. The actor is stored as a fiber local, which
+// c.Context() exposes to use cases through ctx.Value.
+func Actor() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := usecase.Actor{
+			IP:        c.IP(),
+			RequestID: c.GetRespHeader(fiber.HeaderXRequestID),
+		}
+		if userID, ok := CurrentUserID(c); ok {
+			actor.UserID = &userID
+		}
+		c.Locals(usecase.ActorContextKey, actor)
+		return c.Next()
+	}
+}
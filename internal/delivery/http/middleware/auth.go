@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/example/clean-arch-template/pkg/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocalsUserRole is the fiber.Ctx locals key holding the authenticated
+// user's role (a string)
+const LocalsUserRole = "user_role"
+
+// Authenticate verifies a "Bearer" token when one is sent and stores the
+// caller's identity in the request locals. Requests without a token pass
+// through anonymously; use RequireAuth to reject them.
+func Authenticate(tokens *token.Manager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			return c.Next()
+		}
+
+		scheme, raw, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return response.Unauthorized(c, "Invalid authorization header")
+		}
+
+		claims, err := tokens.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return response.Unauthorized(c, err.Error())
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			return response.Unauthorized(c, err.Error())
+		}
+
+		c.Locals(LocalsUserID, userID)
+		c.Locals(LocalsUserRole, claims.Role)
+		return c.Next()
+	}
+}
+
+// RequireAuth rejects requests that were not authenticated
+func RequireAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := CurrentUserID(c); !ok {
+			return response.Unauthorized(c, "Authentication required")
+		}
+		return c.Next()
+	}
+}
+
+// RequireRole rejects requests whose authenticated user lacks the role
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := CurrentUserID(c); !ok {
+			return response.Unauthorized(c, "Authentication required")
+		}
+		if CurrentUserRole(c) != role {
+			return response.Forbidden(c, "Insufficient permissions")
+		}
+		return c.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated user's ID, if any
+func CurrentUserID(c *fiber.Ctx) (uint, bool) {
+	userID, ok := c.Locals(LocalsUserID).(uint)
+	return userID, ok && userID != 0
+}
+
+// CurrentUserRole returns the authenticated user's role, or "" for
+// anonymous requests
+func CurrentUserRole(c *fiber.Ctx) string {
+	role, _ := c.Locals(LocalsUserRole).(string)
+	return role
+}
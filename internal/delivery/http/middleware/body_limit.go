@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies larger than limit bytes. The server
+// streams large bodies instead of rejecting them so that bulk endpoints can
+// read them incrementally; every other route is protected by this
+// middleware. Requests for which stream returns true are not limited.
+func BodyLimit(limit int, stream func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if stream(c) {
+			return c.Next()
+		}
+
+		length := c.Request().Header.ContentLength()
+		if length > limit {
+			return response.PayloadTooLarge(c, "Request body too large")
+		}
+
+		// Chunked bodies have no declared length; buffer them up to the limit
+		if body := c.Context().RequestBodyStream(); length < 0 && body != nil {
+			data, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+			if err != nil {
+				return response.BadRequest(c, "Invalid request body")
+			}
+			if len(data) > limit {
+				return response.PayloadTooLarge(c, "Request body too large")
+			}
+			c.Request().SetBody(data)
+		}
+
+		return c.Next()
+	}
+}
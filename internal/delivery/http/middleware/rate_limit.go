@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/example/clean-arch-template/pkg/ratelimit"
+	"github.com/example/clean-arch-template/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocalsUserID is the fiber.Ctx locals key holding the authenticated user's
+// ID (a uint). Authenticate sets it; rate limiting and handlers read it.
+const LocalsUserID = "user_id"
+
+// KeyFunc extracts the identity a rate limit applies to
+type KeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP limits each client IP address separately
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUser limits each authenticated user separately and falls back to the
+// client IP for anonymous requests
+func KeyByUser(c *fiber.Ctx) string {
+	if userID, ok := CurrentUserID(c); ok {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return KeyByIP(c)
+}
+
+// RateLimit enforces a token bucket policy per key and reports the state of
+// the bucket in the RateLimit-* headers. Rejected requests get 429 with a
+// Retry-After header. If the store fails the request is let through.
+func RateLimit(store ratelimit.Store, policy ratelimit.Policy, keyFunc KeyFunc) fiber.Handler {
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Period.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		key := policy.Name + ":" + keyFunc(c)
+
+		res, err := store.Take(c.UserContext(), key, policy)
+		if err != nil {
+			log.Printf("Rate limit store error for policy %s: %v", policy.Name, err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Policy", policyHeader)
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			return response.TooManyRequests(c, "Too many requests, please try again later")
+		}
+
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/clean-arch-template/pkg/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fixedStore answers every Take with the same result and records the keys
+type fixedStore struct {
+	result ratelimit.Result
+	err    error
+	keys   []string
+}
+
+func (s *fixedStore) Take(_ context.Context, key string, _ ratelimit.Policy) (ratelimit.Result, error) {
+	s.keys = append(s.keys, key)
+	return s.result, s.err
+}
+
+func newRateLimitedApp(store ratelimit.Store) *fiber.App {
+	policy := ratelimit.Policy{Name: "auth", Limit: 10, Period: time.Minute}
+	app := fiber.New()
+	app.Use(RateLimit(store, policy, KeyByIP))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     ratelimit.Result
+		wantStatus int
+		wantHeader map[string]string
+	}{
+		{
+			name:       "allowed",
+			result:     ratelimit.Result{Allowed: true, Limit: 10, Remaining: 7, ResetAfter: 17500 * time.Millisecond},
+			wantStatus: fiber.StatusOK,
+			wantHeader: map[string]string{
+				"RateLimit-Policy":    "10;w=60",
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "7",
+				"RateLimit-Reset":     "18",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:       "limited",
+			result:     ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, ResetAfter: time.Minute, RetryAfter: 5100 * time.Millisecond},
+			wantStatus: fiber.StatusTooManyRequests,
+			wantHeader: map[string]string{
+				"RateLimit-Policy":    "10;w=60",
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "6",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fixedStore{result: tt.result}
+			resp, err := newRateLimitedApp(store).Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			for name, want := range tt.wantHeader {
+				if got := resp.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if len(store.keys) != 1 || store.keys[0] != "auth:ip:0.0.0.0" {
+				t.Errorf("bucket keys = %v, want the policy and client IP", store.keys)
+			}
+		})
+	}
+}
+
+func TestRateLimitLetsRequestsThroughOnStoreErrors(t *testing.T) {
+	store := &fixedStore{err: errors.New("connection refused")}
+	resp, err := newRateLimitedApp(store).Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("RateLimit-Limit"); got != "" {
+		t.Errorf("RateLimit-Limit = %q without a store result", got)
+	}
+}
+
+func TestRateLimitWithMemoryStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := ratelimit.NewMemoryStore(ctx, time.Minute)
+
+	policy := ratelimit.Policy{Name: "auth", Limit: 2, Period: time.Minute}
+	app := fiber.New()
+	app.Use(RateLimit(store, policy, KeyByIP))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	wantStatus := []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests}
+	for i, want := range wantStatus {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("request %d: status = %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+}
@@ -1,21 +1,51 @@
 package http
 
 import (
+	"strings"
+
+	"github.com/example/clean-arch-template/config"
 	"github.com/example/clean-arch-template/internal/delivery/http/handler"
 	"github.com/example/clean-arch-template/internal/delivery/http/middleware"
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/pkg/ratelimit"
+	"github.com/example/clean-arch-template/pkg/token"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
+// productImportPath accepts request bodies of any size, read as a stream
+const productImportPath = "/api/v1/products/import"
+
 // SetupRouter configures all routes and middlewares
 func SetupRouter(
+	cfg *config.Config,
+	rateLimitStore ratelimit.Store,
+	tokens *token.Manager,
 	userHandler *handler.UserHandler,
+	addressHandler *handler.AddressHandler,
 	productHandler *handler.ProductHandler,
+	categoryHandler *handler.CategoryHandler,
+	inventoryHandler *handler.InventoryHandler,
+	cartHandler *handler.CartHandler,
+	couponHandler *handler.CouponHandler,
+	pricingHandler *handler.PricingHandler,
 	orderHandler *handler.OrderHandler,
+	jobHandler *handler.JobHandler,
+	webhookHandler *handler.WebhookHandler,
+	auditHandler *handler.AuditHandler,
 ) *fiber.App {
 	app := fiber.New(fiber.Config{
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		BodyLimit:    bodyLimit(cfg.Media),
+		// Bodies above BodyLimit are streamed to the handler; BodyLimit
+		// middleware below rejects them everywhere except bulk imports
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -28,11 +58,25 @@ func SetupRouter(
 		},
 	})
 
-	// Middlewares
+	// Middlewares (the request ID is taken from X-Request-ID or generated,
+	// and echoed in the response)
+	app.Use(requestid.New())
 	app.Use(logger.New())
 	app.Use(recover.New())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		AllowOrigins: strings.Join(cfg.CORS.AllowOrigins, ","),
+		// Browser clients need the ETag to send it back in If-Match
+		ExposeHeaders: fiber.HeaderETag,
+	}))
 	app.Use(middleware.ErrorHandler())
+	app.Use(middleware.BodyLimit(bodyLimit(cfg.Media), func(c *fiber.Ctx) bool {
+		return c.Method() == fiber.MethodPost && c.Path() == productImportPath
+	}))
+
+	// Locally stored media is served by the API itself
+	if cfg.Media.Driver == "local" {
+		app.Static("/media", cfg.Media.LocalDir, fiber.Static{MaxAge: 31536000})
+	}
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -41,28 +85,176 @@ func SetupRouter(
 		})
 	})
 
-	// API v1 routes
-	api := app.Group("/api/v1")
+	limit := rateLimiter(cfg.RateLimit, rateLimitStore)
+
+	requireAuth := middleware.RequireAuth()
+	requireAdmin := middleware.RequireRole(string(domain.UserRoleAdmin))
+
+	// API v1 routes (identity is resolved first so limits can key by user)
+	api := app.Group("/api/v1", middleware.Authenticate(tokens), middleware.Actor(), limit("api"))
 
 	// User routes
-	users := api.Group("/users")
-	users.Post("/register", userHandler.Register)
-	users.Post("/login", userHandler.Login)
+	users := api.Group("/users", limit("users"))
+	users.Post("/register", limit("auth"), userHandler.Register)
+	users.Post("/login", limit("auth"), userHandler.Login)
+	users.Post("/verify-email", limit("auth"), userHandler.VerifyEmail)
+	users.Post("/password-reset", limit("auth"), userHandler.RequestPasswordReset)
+	users.Post("/password-reset/confirm", limit("auth"), userHandler.ConfirmPasswordReset)
+	users.Get("/me", requireAuth, userHandler.GetMe)
+	users.Patch("/me", requireAuth, userHandler.UpdateMe)
+	users.Delete("/me", requireAuth, userHandler.DeleteMe)
+	users.Post("/me/password", requireAuth, limit("auth"), userHandler.ChangePassword)
+	users.Get("/me/export", requireAuth, userHandler.ExportMe)
+	users.Get("/me/security", requireAuth, userHandler.GetSecurity)
+	users.Post("/me/verify-email", requireAuth, limit("auth"), userHandler.ResendVerificationEmail)
+	users.Get("/me/addresses", requireAuth, addressHandler.ListAddresses)
+	users.Post("/me/addresses", requireAuth, addressHandler.CreateAddress)
+	users.Put("/me/addresses/:id", requireAuth, addressHandler.UpdateAddress)
+	users.Delete("/me/addresses/:id", requireAuth, addressHandler.DeleteAddress)
 	users.Get("/:id", userHandler.GetProfile)
+	users.Delete("/:id", requireAdmin, userHandler.DeleteUser)
+	users.Post("/:id/unlock", requireAdmin, userHandler.UnlockUser)
+	users.Post("/:id/restore", requireAdmin, userHandler.RestoreUser)
+	users.Delete("/:id/purge", requireAdmin, userHandler.PurgeUser)
 
 	// Product routes
-	products := api.Group("/products")
-	products.Post("/", productHandler.CreateProduct)
+	products := api.Group("/products", limit("products"))
+	products.Post("/", requireAdmin, productHandler.CreateProduct)
+	products.Post("/import", requireAdmin, productHandler.ImportProducts)
+	products.Get("/export", requireAdmin, productHandler.ExportProducts)
 	products.Get("/:id", productHandler.GetProduct)
 	products.Get("/", productHandler.ListProducts)
-	products.Put("/:id", productHandler.UpdateProduct)
-	products.Delete("/:id", productHandler.DeleteProduct)
+	products.Put("/:id", requireAdmin, productHandler.UpdateProduct)
+	products.Patch("/:id", requireAdmin, productHandler.PatchProduct)
+	products.Delete("/:id", requireAdmin, productHandler.DeleteProduct)
+	products.Post("/:id/restore", requireAdmin, productHandler.RestoreProduct)
+	products.Delete("/:id/purge", requireAdmin, productHandler.PurgeProduct)
+	products.Get("/:id/variants", productHandler.ListVariants)
+	products.Post("/:id/variants", requireAdmin, productHandler.CreateVariant)
+	products.Put("/:id/variants/:variant_id", requireAdmin, productHandler.UpdateVariant)
+	products.Delete("/:id/variants/:variant_id", requireAdmin, productHandler.DeleteVariant)
+	products.Get("/:id/stock-history", requireAdmin, inventoryHandler.GetStockHistory)
+	products.Post("/:id/stock-adjustments", requireAdmin, inventoryHandler.AdjustStock)
+	products.Get("/:id/images", productHandler.ListImages)
+	products.Post("/:id/images", requireAdmin, productHandler.UploadImage)
+	products.Put("/:id/images/order", requireAdmin, productHandler.ReorderImages)
+	products.Patch("/:id/images/:image_id", requireAdmin, productHandler.UpdateImage)
+	products.Delete("/:id/images/:image_id", requireAdmin, productHandler.DeleteImage)
+
+	// Catalog routes
+	categories := api.Group("/categories", limit("products"))
+	categories.Get("/", categoryHandler.ListCategories)
+	categories.Post("/", requireAdmin, categoryHandler.CreateCategory)
+	categories.Get("/:id", categoryHandler.GetCategory)
+	categories.Get("/:id/products", categoryHandler.ListCategoryProducts)
+	categories.Put("/:id", requireAdmin, categoryHandler.UpdateCategory)
+	categories.Delete("/:id", requireAdmin, categoryHandler.DeleteCategory)
+	api.Get("/tags", limit("products"), productHandler.ListTags)
+
+	// Inventory routes
+	warehouses := api.Group("/warehouses", limit("products"), requireAdmin)
+	warehouses.Get("/", inventoryHandler.ListWarehouses)
+	warehouses.Post("/", inventoryHandler.CreateWarehouse)
+	warehouses.Get("/:id", inventoryHandler.GetWarehouse)
+	warehouses.Put("/:id", inventoryHandler.UpdateWarehouse)
+	warehouses.Delete("/:id", inventoryHandler.DeleteWarehouse)
+	inventory := api.Group("/inventory", limit("products"), requireAdmin)
+	inventory.Post("/transfers", inventoryHandler.TransferStock)
+	inventory.Get("/low-stock", inventoryHandler.ListLowStock)
+
+	// Cart routes (guests are identified by the X-Cart-Token header)
+	cart := api.Group("/cart", limit("orders"))
+	cart.Get("/", cartHandler.GetCart)
+	cart.Delete("/", cartHandler.ClearCart)
+	cart.Post("/items", cartHandler.AddItem)
+	cart.Put("/items/:item_id", cartHandler.UpdateItem)
+	cart.Delete("/items/:item_id", cartHandler.RemoveItem)
+	cart.Post("/checkout", requireAuth, cartHandler.Checkout)
+
+	// Promotion routes
+	coupons := api.Group("/coupons", limit("products"), requireAdmin)
+	coupons.Get("/", couponHandler.ListCoupons)
+	coupons.Post("/", couponHandler.CreateCoupon)
+	coupons.Get("/:id", couponHandler.GetCoupon)
+	coupons.Put("/:id", couponHandler.UpdateCoupon)
+	coupons.Delete("/:id", couponHandler.DeleteCoupon)
+
+	// Pricing routes
+	taxRates := api.Group("/tax-rates", limit("products"), requireAdmin)
+	taxRates.Get("/", pricingHandler.ListTaxRates)
+	taxRates.Post("/", pricingHandler.CreateTaxRate)
+	taxRates.Get("/:id", pricingHandler.GetTaxRate)
+	taxRates.Put("/:id", pricingHandler.UpdateTaxRate)
+	taxRates.Delete("/:id", pricingHandler.DeleteTaxRate)
+	shippingRates := api.Group("/shipping-rates", limit("products"), requireAdmin)
+	shippingRates.Get("/", pricingHandler.ListShippingRates)
+	shippingRates.Post("/", pricingHandler.CreateShippingRate)
+	shippingRates.Get("/:id", pricingHandler.GetShippingRate)
+	shippingRates.Put("/:id", pricingHandler.UpdateShippingRate)
+	shippingRates.Delete("/:id", pricingHandler.DeleteShippingRate)
 
 	// Order routes
-	orders := api.Group("/orders")
-	orders.Post("/", orderHandler.CreateOrder)
-	orders.Get("/:id", orderHandler.GetOrderDetail)
-	orders.Get("/user/:user_id", orderHandler.ListUserOrders)
+	orders := api.Group("/orders", limit("orders"))
+	orders.Post("/", requireAuth, orderHandler.CreateOrder)
+	orders.Get("/:id", requireAuth, orderHandler.GetOrderDetail)
+	orders.Get("/user/:user_id", requireAuth, orderHandler.ListUserOrders)
+	orders.Post("/:id/payment/complete", requireAdmin, orderHandler.CompletePayment)
+	orders.Get("/:id/invoice", requireAuth, orderHandler.GetInvoice)
+	orders.Get("/:id/shipments", requireAuth, orderHandler.ListShipments)
+	orders.Post("/:id/shipments", requireAdmin, orderHandler.CreateShipment)
+	orders.Post("/:id/shipments/:shipment_id/status", requireAdmin, orderHandler.UpdateShipmentStatus)
+
+	// Job queue routes
+	jobs := api.Group("/jobs", limit("products"), requireAdmin)
+	jobs.Get("/", jobHandler.ListJobs)
+	jobs.Get("/:id", jobHandler.GetJob)
+	jobs.Post("/:id/retry", jobHandler.RetryJob)
+	jobs.Post("/:id/discard", jobHandler.DiscardJob)
+
+	// Webhook routes
+	webhooks := api.Group("/webhooks", limit("products"), requireAdmin)
+	webhooks.Get("/", webhookHandler.ListWebhooks)
+	webhooks.Post("/", webhookHandler.CreateWebhook)
+	webhooks.Get("/:id", webhookHandler.GetWebhook)
+	webhooks.Put("/:id", webhookHandler.UpdateWebhook)
+	webhooks.Delete("/:id", webhookHandler.DeleteWebhook)
+	webhooks.Get("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.Get("/:id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+	webhooks.Post("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+
+	// Admin routes
+	admin := api.Group("/admin", limit("products"), requireAdmin)
+	admin.Get("/audit", auditHandler.ListAuditLogs)
+	admin.Get("/orders", orderHandler.SearchOrders)
+	admin.Get("/orders/export", orderHandler.ExportOrders)
 
 	return app
 }
+
+// rateLimiter returns a factory for the rate limit middleware of a route
+// group. Groups without a configured policy get a no-op handler.
+func rateLimiter(cfg config.RateLimitConfig, store ratelimit.Store) func(group string) fiber.Handler {
+	return func(group string) fiber.Handler {
+		p, ok := cfg.Policies[group]
+		if !cfg.Enabled || !ok || store == nil {
+			return func(c *fiber.Ctx) error { return c.Next() }
+		}
+
+		keyFunc := middleware.KeyByIP
+		if p.KeyBy == "user" {
+			keyFunc = middleware.KeyByUser
+		}
+
+		return middleware.RateLimit(store, ratelimit.Policy{
+			Name:   group,
+			Limit:  p.Limit,
+			Period: p.Period,
+		}, keyFunc)
+	}
+}
+
+// bodyLimit leaves room for multipart overhead on top of the largest
+// accepted image, and never goes below Fiber's default limit
+func bodyLimit(cfg config.MediaConfig) int {
+	return max(fiber.DefaultBodyLimit, cfg.MaxUploadSize+1<<20)
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// PostalAddress is where an order is delivered. Orders keep a copy, so
+// editing or deleting an address book entry never changes past orders.
+type PostalAddress struct {
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	PostalCode    string `json:"postal_code"`
+	// Region is the ISO 3166 code of the country or subdivision; it selects
+	// the tax and shipping rates of orders
+	Region string `json:"region"`
+	Phone  string `json:"phone"`
+}
+
+// IsZero reports whether no address was given
+func (a PostalAddress) IsZero() bool {
+	return a == PostalAddress{}
+}
+
+// Validate performs domain-level validation
+func (a *PostalAddress) Validate() error {
+	if strings.TrimSpace(a.RecipientName) == "" {
+		return errors.New("recipient name is required")
+	}
+	if strings.TrimSpace(a.Line1) == "" {
+		return errors.New("address line 1 is required")
+	}
+	if strings.TrimSpace(a.City) == "" {
+		return errors.New("city is required")
+	}
+	if a.Region == "" {
+		return errors.New("region is required")
+	}
+	return validateRegion(a.Region)
+}
+
+// Address is an entry of a user's address book. At most one address per
+// user is the default, used for orders that name no address.
+type Address struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	UserID        uint   `json:"user_id" gorm:"not null;index;uniqueIndex:idx_addresses_default,where:is_default"`
+	Label         string `json:"label"` // e.g. "Home"
+	PostalAddress `gorm:"embedded"`
+	IsDefault     bool      `json:"is_default" gorm:"not null;default:false;uniqueIndex:idx_addresses_default,where:is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Address) TableName() string {
+	return "addresses"
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditAction is the kind of change an audit log entry records
+type AuditAction string
+
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"  // Soft delete
+	AuditActionRestore AuditAction = "restore" // Undone soft delete
+	AuditActionPurge   AuditAction = "purge"   // Permanent delete
+	AuditActionUnlock  AuditAction = "unlock"  // Cleared login lockout
+	// AuditActionPasswordChange records a new password; the hash itself is
+	// never logged
+	AuditActionPasswordChange AuditAction = "password_change"
+)
+
+// Entity types of audit log entries
+const (
+	AuditEntityProduct = "product"
+	AuditEntityVariant = "product_variant"
+	AuditEntityUser    = "user"
+	AuditEntityOrder   = "order"
+)
+
+// ErrAuditLogImmutable is returned on attempts to change recorded entries
+var ErrAuditLogImmutable = errors.New("audit log entries are append-only")
+
+// AuditChange is the value of one field before and after a change. Before is
+// null for created entities, After for purged ones.
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditLog records who changed an entity, how and from where. It is written
+// in the transaction of the change, so every committed change has an entry.
+type AuditLog struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	ActorID    *uint                  `json:"actor_id" gorm:"index"` // User who made the change; nil for system jobs
+	Action     AuditAction            `json:"action" gorm:"size:20;not null"`
+	EntityType string                 `json:"entity_type" gorm:"size:50;not null;index:idx_audit_logs_entity"`
+	EntityID   uint                   `json:"entity_id" gorm:"not null;index:idx_audit_logs_entity"`
+	Changes    map[string]AuditChange `json:"changes" gorm:"serializer:json;type:jsonb;not null"` // Changed fields only
+	RequestID  string                 `json:"request_id" gorm:"type:text"`
+	IP         string                 `json:"ip" gorm:"size:45"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// Validate performs domain-level validation
+func (l *AuditLog) Validate() error {
+	if l.Action == "" {
+		return errors.New("audit action is required")
+	}
+	if l.EntityType == "" || l.EntityID == 0 {
+		return errors.New("audited entity is required")
+	}
+	return nil
+}
+
+// BeforeUpdate is a GORM hook that keeps the audit log append-only
+func (l *AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete is a GORM hook that keeps the audit log append-only
+func (l *AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// auditIgnoredFields change with every write and say nothing about it
+var auditIgnoredFields = []string{"id", "created_at", "updated_at"}
+
+// DiffAudit compares the JSON representations of an entity before and after
+// a change, field by field. Either side may be nil. Fields hidden from JSON,
+// such as password hashes, never appear in the diff.
+func DiffAudit(before, after any) (map[string]AuditChange, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// A side without the field counts as null
+	null := json.RawMessage("null")
+	changes := make(map[string]AuditChange)
+	for field, value := range from {
+		next, ok := to[field]
+		if !ok {
+			next = null
+		}
+		if string(next) != string(value) {
+			changes[field] = AuditChange{Before: value, After: next}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok && string(value) != string(null) {
+			changes[field] = AuditChange{Before: null, After: value}
+		}
+	}
+	return changes, nil
+}
+
+// auditFields returns the top-level JSON fields of v
+func auditFields(v any) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range auditIgnoredFields {
+		delete(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Cart holds the items a user, or an anonymous guest, intends to order.
+// Guests are identified by an opaque token of which only the hash is
+// stored. Carts expire after a period of inactivity.
+type Cart struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         *uint      `json:"user_id" gorm:"uniqueIndex:idx_carts_user,where:user_id IS NOT NULL"`
+	GuestTokenHash string     `json:"-" gorm:"uniqueIndex:idx_carts_guest,where:guest_token_hash <> ''"`
+	Items          []CartItem `json:"items" gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Cart) TableName() string {
+	return "carts"
+}
+
+// IsExpired reports whether the cart has been inactive for too long
+func (c *Cart) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// FindItem returns the line for a product or variant, if the cart has one
+func (c *Cart) FindItem(productID uint, variantID *uint) *CartItem {
+	for i := range c.Items {
+		item := &c.Items[i]
+		if item.ProductID == productID && sameVariant(item.VariantID, variantID) {
+			return item
+		}
+	}
+	return nil
+}
+
+func sameVariant(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// CartItem is a line of a cart. Prices are not stored: they are always
+// taken from the current catalog.
+type CartItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CartID    uint      `json:"cart_id" gorm:"not null;index"`
+	ProductID uint      `json:"product_id" gorm:"not null"`
+	VariantID *uint     `json:"variant_id"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (CartItem) TableName() string {
+	return "cart_items"
+}
+
+// Validate performs domain-level validation
+func (i *CartItem) Validate() error {
+	if i.ProductID == 0 {
+		return errors.New("product ID is required")
+	}
+	if i.Quantity <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// Category represents a node in the hierarchical product catalog
+type Category struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ParentID    *uint      `json:"parent_id" gorm:"index"`
+	Parent      *Category  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	Children    []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	Name        string     `json:"name" gorm:"not null"`
+	Slug        string     `json:"slug" gorm:"not null;uniqueIndex"`
+	Description string     `json:"description"`
+	Version     uint       `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Category) TableName() string {
+	return "categories"
+}
+
+// Validate performs domain-level validation
+func (c *Category) Validate() error {
+	if c.Name == "" {
+		return errors.New("category name is required")
+	}
+	if !slugPattern.MatchString(c.Slug) {
+		return errors.New("category slug must contain only lowercase letters, digits and dashes")
+	}
+	if c.ParentID != nil && *c.ParentID == c.ID && c.ID != 0 {
+		return errors.New("category cannot be its own parent")
+	}
+	return nil
+}
+
+// BuildCategoryTree nests a flat list of categories under their parents and
+// returns the roots
+func BuildCategoryTree(categories []Category) []Category {
+	children := make(map[uint][]Category)
+	var rootIDs []int
+	for i, c := range categories {
+		if c.ParentID == nil {
+			rootIDs = append(rootIDs, i)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var attach func(c Category) Category
+	attach = func(c Category) Category {
+		for _, child := range children[c.ID] {
+			c.Children = append(c.Children, attach(child))
+		}
+		return c
+	}
+
+	roots := make([]Category, 0, len(rootIDs))
+	for _, i := range rootIDs {
+		roots = append(roots, attach(categories[i]))
+	}
+	return roots
+}
@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]*$`)
+
+// ErrCouponExhausted is returned when a coupon has reached its redemption
+// limit
+var ErrCouponExhausted = errors.New("coupon has reached its usage limit")
+
+// CouponType selects how a coupon discounts an order
+type CouponType string
+
+const (
+	// CouponPercentage takes Value percent off every item
+	CouponPercentage CouponType = "percentage"
+	// CouponFixed takes the amount Value off the order
+	CouponFixed CouponType = "fixed"
+	// CouponFreeItem makes FreeQuantity units of FreeProductID (or of
+	// FreeVariantID) free when the order contains them
+	CouponFreeItem CouponType = "free_item"
+)
+
+// Coupon is a discount code customers enter when ordering. Limits of 0 are
+// unlimited. A coupon that isn't Stackable can't be combined with any other
+// coupon.
+type Coupon struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Code        string     `json:"code" gorm:"not null;uniqueIndex"`
+	Description string     `json:"description"`
+	Type        CouponType `json:"type" gorm:"not null"`
+	Value       float64    `json:"value" gorm:"not null;default:0"`
+
+	FreeProductID *uint `json:"free_product_id"`
+	FreeVariantID *uint `json:"free_variant_id"`
+	FreeQuantity  int   `json:"free_quantity" gorm:"not null;default:0"`
+
+	MinOrderAmount float64    `json:"min_order_amount" gorm:"not null;default:0"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+
+	MaxRedemptions        int  `json:"max_redemptions" gorm:"not null;default:0"`
+	MaxRedemptionsPerUser int  `json:"max_redemptions_per_user" gorm:"not null;default:0"`
+	RedemptionCount       int  `json:"redemption_count" gorm:"not null;default:0"`
+	Stackable             bool `json:"stackable" gorm:"not null;default:false"`
+	Active                bool `json:"active" gorm:"not null"`
+
+	Version   uint      `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// NormalizeCouponCode returns code the way it is stored: trimmed and in
+// upper case
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate performs domain-level validation
+func (c *Coupon) Validate() error {
+	if !couponCodePattern.MatchString(c.Code) {
+		return errors.New("coupon code must contain only uppercase letters, digits, dashes and underscores")
+	}
+	switch c.Type {
+	case CouponPercentage:
+		if c.Value <= 0 || c.Value > 100 {
+			return errors.New("percentage must be greater than 0 and at most 100")
+		}
+	case CouponFixed:
+		if c.Value <= 0 {
+			return errors.New("discount amount must be greater than 0")
+		}
+	case CouponFreeItem:
+		if c.FreeProductID == nil {
+			return errors.New("free product ID is required")
+		}
+		if c.FreeQuantity <= 0 {
+			return errors.New("free quantity must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("invalid coupon type %q", c.Type)
+	}
+	if c.MinOrderAmount < 0 {
+		return errors.New("minimum order amount must not be negative")
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		return errors.New("coupon must end after it starts")
+	}
+	if c.MaxRedemptions < 0 || c.MaxRedemptionsPerUser < 0 {
+		return errors.New("redemption limits must not be negative")
+	}
+	return nil
+}
+
+// IsValidAt reports whether the coupon is active and within its validity
+// window
+func (c *Coupon) IsValidAt(now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return false
+	}
+	return c.EndsAt == nil || now.Before(*c.EndsAt)
+}
+
+// IsExhausted reports whether the coupon reached its global limit
+func (c *Coupon) IsExhausted() bool {
+	return c.MaxRedemptions > 0 && c.RedemptionCount >= c.MaxRedemptions
+}
+
+// CouponRedemption records the use of a coupon by an order
+type CouponRedemption struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CouponID  uint      `json:"coupon_id" gorm:"not null;index:idx_coupon_redemptions_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_coupon_redemptions_user"`
+	OrderID   uint      `json:"order_id" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// ApplyCoupons discounts the items of an order, already validated against
+// their limits and validity windows. Free items are taken off first, then
+// percentages, then fixed amounts, each from what is left to pay, so the
+// total never goes below zero. The discount of each item is set on the item
+// and the discount of each coupon is returned.
+func ApplyCoupons(items []OrderItem, coupons []Coupon) ([]OrderDiscount, error) {
+	if len(coupons) > 1 {
+		for _, coupon := range coupons {
+			if !coupon.Stackable {
+				return nil, fmt.Errorf("coupon %s can't be combined with other coupons", coupon.Code)
+			}
+		}
+	}
+
+	var subtotal float64
+	for _, item := range items {
+		subtotal += item.Subtotal()
+	}
+
+	rank := map[CouponType]int{CouponFreeItem: 0, CouponPercentage: 1, CouponFixed: 2}
+	sorted := slices.Clone(coupons)
+	slices.SortStableFunc(sorted, func(a, b Coupon) int {
+		return rank[a.Type] - rank[b.Type]
+	})
+
+	discounts := make([]OrderDiscount, 0, len(sorted))
+	for _, coupon := range sorted {
+		if subtotal < coupon.MinOrderAmount {
+			return nil, fmt.Errorf("coupon %s requires a minimum order of %.2f", coupon.Code, coupon.MinOrderAmount)
+		}
+
+		var amount float64
+		switch coupon.Type {
+		case CouponFreeItem:
+			remaining := coupon.FreeQuantity
+			for i := range items {
+				item := &items[i]
+				if remaining == 0 || !coupon.isFreeItem(item) {
+					continue
+				}
+				free := min(remaining, item.Quantity)
+				remaining -= free
+				amount += item.discount(roundCents(item.Price * float64(free)))
+			}
+			if amount == 0 {
+				return nil, fmt.Errorf("coupon %s requires its free item in the order", coupon.Code)
+			}
+		case CouponPercentage:
+			for i := range items {
+				item := &items[i]
+				amount += item.discount(roundCents(item.Payable() * coupon.Value / 100))
+			}
+		case CouponFixed:
+			amount = spreadDiscount(items, coupon.Value)
+		}
+
+		discounts = append(discounts, OrderDiscount{CouponID: coupon.ID, Code: coupon.Code, Amount: roundCents(amount)})
+	}
+	return discounts, nil
+}
+
+func (c *Coupon) isFreeItem(item *OrderItem) bool {
+	if item.ProductID != *c.FreeProductID {
+		return false
+	}
+	return c.FreeVariantID == nil || (item.VariantID != nil && *item.VariantID == *c.FreeVariantID)
+}
+
+// spreadDiscount takes amount off the items in proportion to what is left
+// to pay for each, and returns how much was taken off
+func spreadDiscount(items []OrderItem, amount float64) float64 {
+	var payable float64
+	for _, item := range items {
+		payable += item.Payable()
+	}
+	amount = math.Min(amount, payable)
+	if amount <= 0 {
+		return 0
+	}
+
+	var taken float64
+	last := -1
+	for i := range items {
+		if items[i].Payable() > 0 {
+			last = i
+		}
+	}
+	for i := range items {
+		item := &items[i]
+		if i == last {
+			// The last item takes the rounding difference
+			taken += item.discount(roundCents(amount - taken))
+			break
+		}
+		taken += item.discount(roundCents(amount * item.Payable() / payable))
+	}
+	return taken
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MovementType explains why stock changed
+type MovementType string
+
+const (
+	MovementSale          MovementType = "sale"
+	MovementCancellation  MovementType = "cancellation"
+	MovementRefundRestock MovementType = "refund_restock"
+	MovementAdjustment    MovementType = "adjustment"
+	MovementImport        MovementType = "import"
+	MovementTransfer      MovementType = "transfer"
+)
+
+var (
+	// ErrInsufficientStock is returned when a movement would make stock negative
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrLedgerImmutable is returned on attempts to change recorded movements
+	ErrLedgerImmutable = errors.New("inventory movements are append-only")
+)
+
+// InventoryMovement is one entry of the append-only stock ledger. The stock
+// of a product (or of one of its variants) in a warehouse is the sum of its
+// movements there.
+type InventoryMovement struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	ProductID   uint         `json:"product_id" gorm:"not null;index:idx_inventory_movements_item"`
+	VariantID   *uint        `json:"variant_id" gorm:"index:idx_inventory_movements_item"`
+	WarehouseID *uint        `json:"warehouse_id" gorm:"index"` // Only NULL for movements recorded before warehouses existed
+	Type        MovementType `json:"type" gorm:"type:varchar(20);not null"`
+	Quantity    int          `json:"quantity" gorm:"not null"`    // Signed change in stock
+	StockAfter  int          `json:"stock_after" gorm:"not null"` // Warehouse stock once the movement was applied
+	OrderID     *uint        `json:"order_id,omitempty" gorm:"index"`
+	ActorID     *uint        `json:"actor_id,omitempty"` // User who caused the change; nil for system jobs
+	Reason      string       `json:"reason"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (InventoryMovement) TableName() string {
+	return "inventory_movements"
+}
+
+// Validate performs domain-level validation
+func (m *InventoryMovement) Validate() error {
+	if m.ProductID == 0 {
+		return errors.New("product ID is required")
+	}
+	switch m.Type {
+	case MovementSale, MovementCancellation, MovementRefundRestock, MovementAdjustment, MovementImport, MovementTransfer:
+	default:
+		return errors.New("invalid movement type")
+	}
+	if m.Quantity == 0 {
+		return errors.New("movement quantity cannot be 0")
+	}
+	if m.Type == MovementSale && m.Quantity > 0 {
+		return errors.New("sales must decrease stock")
+	}
+	if (m.Type == MovementCancellation || m.Type == MovementRefundRestock) && m.Quantity < 0 {
+		return errors.New("restocks must increase stock")
+	}
+	return nil
+}
+
+// BeforeUpdate is a GORM hook that keeps the ledger append-only
+func (m *InventoryMovement) BeforeUpdate(*gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// BeforeDelete is a GORM hook that keeps the ledger append-only
+func (m *InventoryMovement) BeforeDelete(*gorm.DB) error {
+	return ErrLedgerImmutable
+}
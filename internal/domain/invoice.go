@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Invoice is the invoice of a paid order. Numbers run without gaps within
+// each calendar year (UTC) of issue: INV-2024-000001, INV-2024-000002, ...
+// The customer is billed as they were at issue, whatever later happens to
+// their account.
+type Invoice struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OrderID     uint      `json:"order_id" gorm:"not null;uniqueIndex"`
+	Number      string    `json:"number" gorm:"not null;uniqueIndex"`
+	Year        int       `json:"year" gorm:"not null"`
+	Sequence    int       `json:"sequence" gorm:"not null"`
+	IssuedAt    time.Time `json:"issued_at" gorm:"not null"`
+	BillToName  string    `json:"bill_to_name" gorm:"not null;default:''"`
+	BillToEmail string    `json:"bill_to_email" gorm:"not null;default:''"`
+	StorageKey  string    `json:"-" gorm:"not null"` // Key of the PDF in invoice storage
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceNumber formats the sequence-th invoice number of year
+func InvoiceNumber(year, sequence int) string {
+	return fmt.Sprintf("INV-%d-%06d", year, sequence)
+}
+
+// InvoiceSequence holds the last invoice number issued in a year
+type InvoiceSequence struct {
+	Year       int `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
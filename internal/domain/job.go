@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// JobStatus is where a background job is in its life cycle
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"   // Waiting for RunAt
+	JobStatusRunning   JobStatus = "running"   // Claimed by a worker
+	JobStatusCompleted JobStatus = "completed" // Ran successfully
+	JobStatusDead      JobStatus = "dead"      // Failed for good; only retried by hand
+	JobStatusDiscarded JobStatus = "discarded" // Dropped by an admin
+)
+
+var (
+	// ErrJobNotRetryable is returned when retrying a job that is not dead or
+	// discarded
+	ErrJobNotRetryable = errors.New("only dead or discarded jobs can be retried")
+	// ErrJobNotDiscardable is returned when discarding a job that is running
+	// or finished
+	ErrJobNotDiscardable = errors.New("only pending or dead jobs can be discarded")
+)
+
+// Job is a unit of asynchronous work stored in the job queue. Jobs with a
+// UniqueKey are enqueued once while they are unfinished.
+type Job struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	Type        string          `json:"type" gorm:"size:100;not null;index"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status      JobStatus       `json:"status" gorm:"size:20;not null;default:'pending';index:idx_jobs_ready,priority:1"`
+	UniqueKey   *string         `json:"unique_key" gorm:"size:255;uniqueIndex:idx_jobs_unique_key,where:finished_at IS NULL"`
+	Attempts    int             `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time       `json:"run_at" gorm:"not null;index:idx_jobs_ready,priority:2"`
+	LockedAt    *time.Time      `json:"locked_at"`
+	LockedBy    string          `json:"locked_by,omitempty" gorm:"size:255"`
+	LastError   string          `json:"last_error,omitempty" gorm:"type:text"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// Validate performs domain-level validation
+func (j *Job) Validate() error {
+	if j.Type == "" {
+		return errors.New("job type is required")
+	}
+	if !json.Valid(j.Payload) {
+		return errors.New("job payload must be valid JSON")
+	}
+	if j.MaxAttempts <= 0 {
+		return errors.New("max attempts must be greater than 0")
+	}
+	if j.UniqueKey != nil && *j.UniqueKey == "" {
+		return errors.New("unique key must not be empty")
+	}
+	return nil
+}
+
+// Complete marks a running job as done
+func (j *Job) Complete(now time.Time) {
+	j.Status = JobStatusCompleted
+	j.LastError = ""
+	j.finish(now)
+}
+
+// Fail records a failed attempt. The job runs again at retryAt unless it is
+// out of attempts or permanent is set, in which case it is dead.
+func (j *Job) Fail(cause error, permanent bool, retryAt, now time.Time) {
+	j.LastError = cause.Error()
+	if permanent || j.Attempts >= j.MaxAttempts {
+		j.Status = JobStatusDead
+		j.finish(now)
+		return
+	}
+	j.Status = JobStatusPending
+	j.RunAt = retryAt
+	j.LockedAt = nil
+	j.LockedBy = ""
+}
+
+// Retry puts a dead or discarded job back in the queue with a fresh set of
+// attempts
+func (j *Job) Retry(now time.Time) error {
+	if j.Status != JobStatusDead && j.Status != JobStatusDiscarded {
+		return ErrJobNotRetryable
+	}
+	j.Status = JobStatusPending
+	j.Attempts = 0
+	j.RunAt = now
+	j.FinishedAt = nil
+	return nil
+}
+
+// Discard drops a job that is waiting or dead, so it never runs again
+func (j *Job) Discard(now time.Time) error {
+	if j.Status != JobStatusPending && j.Status != JobStatusDead {
+		return ErrJobNotDiscardable
+	}
+	j.Status = JobStatusDiscarded
+	j.finish(now)
+	return nil
+}
+
+func (j *Job) finish(now time.Time) {
+	j.FinishedAt = &now
+	j.LockedAt = nil
+	j.LockedBy = ""
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginOutcome represents the result of a login attempt
+type LoginOutcome string
+
+const (
+	LoginOutcomeSuccess  LoginOutcome = "success"
+	LoginOutcomeFailure  LoginOutcome = "failure"
+	LoginOutcomeLocked   LoginOutcome = "locked"   // rejected without checking the password
+	LoginOutcomeUnlocked LoginOutcome = "unlocked" // lockout cleared by an administrator
+)
+
+// LoginAttempt records a sign-in attempt for auditing and lockout decisions.
+// Attempts are keyed by normalized email so that unknown emails are
+// throttled exactly like existing ones.
+type LoginAttempt struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	Email     string       `json:"email" gorm:"not null;index:idx_login_attempts_email_created,priority:1"`
+	UserID    *uint        `json:"user_id,omitempty" gorm:"index"`
+	IPAddress string       `json:"ip_address"`
+	Outcome   LoginOutcome `json:"outcome" gorm:"not null"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index:idx_login_attempts_email_created,priority:2"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// ResetsFailures reports whether the attempt ends a run of failures
+func (a *LoginAttempt) ResetsFailures() bool {
+	return a.Outcome == LoginOutcomeSuccess || a.Outcome == LoginOutcomeUnlocked
+}
+
+// NormalizeEmail returns the canonical form of an email used as lookup key
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
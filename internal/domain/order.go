@@ -15,16 +15,27 @@ const (
 	OrderStatusCompleted OrderStatus = "completed"
 )
 
-// Order represents the order entity in the domain layer
+// Order represents the order entity in the domain layer. Subtotal is the
+// sum of the items before discounts; TotalAmount is what is paid once
+// DiscountAmount is taken off and exclusive tax and ShippingAmount are
+// added. TaxAmount includes tax that is part of the item prices.
+// ShippingAddress is a copy of the address the order ships to.
 type Order struct {
-	ID          uint        `json:"id" gorm:"primaryKey"`
-	UserID      uint        `json:"user_id" gorm:"not null;index"`
-	User        *User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	TotalAmount float64     `json:"total_amount" gorm:"not null"`
-	Status      OrderStatus `json:"status" gorm:"not null;default:'pending'"`
-	Items       []OrderItem `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	UserID          uint            `json:"user_id" gorm:"not null;index"`
+	User            *User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Region          string          `json:"region" gorm:"not null;default:''"` // Region taxed and shipped to
+	ShippingAddress PostalAddress   `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
+	Subtotal        float64         `json:"subtotal" gorm:"not null;default:0"`
+	DiscountAmount  float64         `json:"discount_amount" gorm:"not null;default:0"`
+	TaxAmount       float64         `json:"tax_amount" gorm:"not null;default:0"`
+	ShippingAmount  float64         `json:"shipping_amount" gorm:"not null;default:0"`
+	TotalAmount     float64         `json:"total_amount" gorm:"not null;index"`
+	Status          OrderStatus     `json:"status" gorm:"not null;default:'pending';index:idx_orders_status_created_at,priority:1"`
+	Items           []OrderItem     `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	Discounts       []OrderDiscount `json:"discounts" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"index;index:idx_orders_status_created_at,priority:2"`
+	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
 // TableName specifies the table name for GORM
@@ -34,13 +45,23 @@ func (Order) TableName() string {
 
 // OrderItem represents an item in an order
 type OrderItem struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	OrderID   uint      `json:"order_id" gorm:"not null;index"`
-	ProductID uint      `json:"product_id" gorm:"not null"`
-	Product   *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
-	Quantity  int       `json:"quantity" gorm:"not null"`
-	Price     float64   `json:"price" gorm:"not null"` // Price at the time of order
-	CreatedAt time.Time `json:"created_at"`
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	OrderID        uint              `json:"order_id" gorm:"not null;index"`
+	ProductID      uint              `json:"product_id" gorm:"not null;index"`
+	Product        *Product          `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	VariantID      *uint             `json:"variant_id" gorm:"index"`
+	Variant        *ProductVariant   `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	ProductName    string            `json:"product_name"` // Product name at the time of order
+	SKU            string            `json:"sku"`          // Variant SKU at the time of order
+	Quantity       int               `json:"quantity" gorm:"not null"`
+	Price          float64           `json:"price" gorm:"not null"`                     // Price at the time of order
+	DiscountAmount float64           `json:"discount_amount" gorm:"not null;default:0"` // Share of the order's discounts
+	TaxRate        float64           `json:"tax_rate" gorm:"not null;default:0"`        // Percent applied at the time of order
+	TaxInclusive   bool              `json:"tax_inclusive" gorm:"not null;default:false"`
+	TaxAmount      float64           `json:"tax_amount" gorm:"not null;default:0"`
+	Total          float64           `json:"total" gorm:"not null;default:0"` // After discounts, with exclusive tax
+	Allocations    []OrderAllocation `json:"allocations" gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time         `json:"created_at"`
 }
 
 // TableName specifies the table name for GORM
@@ -48,6 +69,70 @@ func (OrderItem) TableName() string {
 	return "order_items"
 }
 
+// Subtotal is the price of the item before discounts
+func (item *OrderItem) Subtotal() float64 {
+	return item.Price * float64(item.Quantity)
+}
+
+// Payable is the price of the item after discounts
+func (item *OrderItem) Payable() float64 {
+	return item.Subtotal() - item.DiscountAmount
+}
+
+// ApplyTax taxes the item, after discounts, at rate; a nil rate leaves it
+// untaxed
+func (item *OrderItem) ApplyTax(rate *TaxRate) {
+	item.TaxRate, item.TaxInclusive, item.TaxAmount = 0, false, 0
+	payable := roundCents(item.Payable())
+	item.Total = payable
+	if rate == nil {
+		return
+	}
+
+	item.TaxRate = rate.Rate
+	item.TaxInclusive = rate.Inclusive
+	if rate.Inclusive {
+		item.TaxAmount = roundCents(payable - payable/(1+rate.Rate/100))
+		return
+	}
+	item.TaxAmount = roundCents(payable * rate.Rate / 100)
+	item.Total = roundCents(payable + item.TaxAmount)
+}
+
+// discount takes up to amount off the item and returns what was taken off
+func (item *OrderItem) discount(amount float64) float64 {
+	amount = max(0, min(amount, item.Payable()))
+	item.DiscountAmount += amount
+	return amount
+}
+
+// OrderDiscount is the discount a coupon gave an order
+type OrderDiscount struct {
+	ID       uint    `json:"id" gorm:"primaryKey"`
+	OrderID  uint    `json:"order_id" gorm:"not null;index"`
+	CouponID uint    `json:"coupon_id" gorm:"not null;index"`
+	Code     string  `json:"code" gorm:"not null"` // Coupon code at the time of order
+	Amount   float64 `json:"amount" gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (OrderDiscount) TableName() string {
+	return "order_discounts"
+}
+
+// OrderAllocation is the part of an order item shipped from one warehouse
+type OrderAllocation struct {
+	ID          uint `json:"id" gorm:"primaryKey"`
+	OrderItemID uint `json:"order_item_id" gorm:"not null;index"`
+	WarehouseID uint `json:"warehouse_id" gorm:"not null;index"`
+	Quantity    int  `json:"quantity" gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (OrderAllocation) TableName() string {
+	return "order_allocations"
+}
+
 // Validate performs domain-level validation for Order
 func (o *Order) Validate() error {
 	if o.UserID == 0 {
@@ -56,19 +141,35 @@ func (o *Order) Validate() error {
 	if len(o.Items) == 0 {
 		return errors.New("order must have at least one item")
 	}
-	if o.TotalAmount <= 0 {
+	if o.Subtotal <= 0 {
+		return errors.New("subtotal must be greater than 0")
+	}
+	// Discounts may make an order free, but never negative
+	if o.TotalAmount < 0 || (o.TotalAmount == 0 && o.DiscountAmount == 0) {
 		return errors.New("total amount must be greater than 0")
 	}
 	return nil
 }
 
-// CalculateTotal calculates the total amount based on items
+// CalculateTotal calculates the subtotal, discount, tax and total amount
+// based on items, which must be taxed already, and ShippingAmount
 func (o *Order) CalculateTotal() {
-	var total float64
+	var subtotal, discount, tax, total float64
 	for _, item := range o.Items {
-		total += item.Price * float64(item.Quantity)
+		subtotal += item.Subtotal()
+		discount += item.DiscountAmount
+		tax += item.TaxAmount
+		total += item.Total
 	}
-	o.TotalAmount = total
+	o.Subtotal = roundCents(subtotal)
+	o.DiscountAmount = roundCents(discount)
+	o.TaxAmount = roundCents(tax)
+	o.TotalAmount = roundCents(total + o.ShippingAmount)
+}
+
+// IsFree reports whether discounts cover the whole order
+func (o *Order) IsFree() bool {
+	return o.TotalAmount == 0
 }
 
 // ValidateItem validates an order item
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Regions are ISO 3166 codes: a country ("US") or a subdivision ("US-CA")
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// NormalizeRegion returns region the way it is stored: trimmed and in upper
+// case
+func NormalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+func validateRegion(region string) error {
+	if region != "" && !regionPattern.MatchString(region) {
+		return errors.New("region must be a country code such as \"US\" or a subdivision code such as \"US-CA\"")
+	}
+	return nil
+}
+
+// regionRank tells how specifically ruleRegion matches region: 2 for the
+// region itself, 1 for its country, 0 for rules without a region and -1 for
+// rules of other regions
+func regionRank(ruleRegion, region string) int {
+	switch {
+	case ruleRegion == "":
+		return 0
+	case ruleRegion == region:
+		return 2
+	case strings.HasPrefix(region, ruleRegion+"-"):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// TaxRate is the tax charged on products shipped to a region. A rate without
+// a region applies everywhere, and one without a category applies to all
+// products. Inclusive rates are already part of the price; exclusive ones
+// are added to it.
+type TaxRate struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"not null"`
+	Region     string    `json:"region" gorm:"not null;default:'';uniqueIndex:idx_tax_rates_region,where:category_id IS NULL;uniqueIndex:idx_tax_rates_category,where:category_id IS NOT NULL"`
+	CategoryID *uint     `json:"category_id" gorm:"uniqueIndex:idx_tax_rates_category,where:category_id IS NOT NULL"`
+	Rate       float64   `json:"rate" gorm:"not null"` // Percent
+	Inclusive  bool      `json:"inclusive" gorm:"not null;default:false"`
+	Version    uint      `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (TaxRate) TableName() string {
+	return "tax_rates"
+}
+
+// Validate performs domain-level validation
+func (r *TaxRate) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("tax rate name is required")
+	}
+	if err := validateRegion(r.Region); err != nil {
+		return err
+	}
+	if r.Rate < 0 || r.Rate > 100 {
+		return errors.New("tax rate must be between 0 and 100 percent")
+	}
+	return nil
+}
+
+// MatchTaxRate picks the rate for a product shipped to region. categoryIDs
+// are the product's category followed by its ancestors. A rate for a more
+// specific region wins, then one for a closer category; nil means untaxed.
+func MatchTaxRate(rates []TaxRate, region string, categoryIDs []uint) *TaxRate {
+	var best *TaxRate
+	bestRegion, bestCategory := -1, -1
+	for i := range rates {
+		rate := &rates[i]
+		regionMatch := regionRank(rate.Region, region)
+		if regionMatch < 0 {
+			continue
+		}
+
+		// Closer categories rank higher; rates for all products rank lowest
+		categoryMatch := 0
+		if rate.CategoryID != nil {
+			categoryMatch = -1
+			for depth, id := range categoryIDs {
+				if id == *rate.CategoryID {
+					categoryMatch = len(categoryIDs) - depth
+					break
+				}
+			}
+			if categoryMatch < 0 {
+				continue
+			}
+		}
+
+		if regionMatch > bestRegion || (regionMatch == bestRegion && categoryMatch > bestCategory) {
+			best, bestRegion, bestCategory = rate, regionMatch, categoryMatch
+		}
+	}
+	return best
+}
+
+// ShippingRate is the shipping charged on orders to a region. A rate without
+// a region applies everywhere. Orders of at least FreeAbove, after
+// discounts, ship for free; 0 disables free shipping.
+type ShippingRate struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Region    string    `json:"region" gorm:"not null;default:'';uniqueIndex"`
+	Amount    float64   `json:"amount" gorm:"not null"`
+	FreeAbove float64   `json:"free_above" gorm:"not null;default:0"`
+	Version   uint      `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ShippingRate) TableName() string {
+	return "shipping_rates"
+}
+
+// Validate performs domain-level validation
+func (r *ShippingRate) Validate() error {
+	if err := validateRegion(r.Region); err != nil {
+		return err
+	}
+	if r.Amount < 0 {
+		return errors.New("shipping amount must not be negative")
+	}
+	if r.FreeAbove < 0 {
+		return errors.New("free shipping threshold must not be negative")
+	}
+	return nil
+}
+
+// MatchShippingRate picks the rate for orders to region; nil means shipping
+// is free
+func MatchShippingRate(rates []ShippingRate, region string) *ShippingRate {
+	var best *ShippingRate
+	bestRank := -1
+	for i := range rates {
+		if rank := regionRank(rates[i].Region, region); rank > bestRank {
+			best, bestRank = &rates[i], rank
+		}
+	}
+	return best
+}
+
+// ShippingFor returns the shipping charged on an order worth amount
+func (r *ShippingRate) ShippingFor(amount float64) float64 {
+	if r == nil || (r.FreeAbove > 0 && amount >= r.FreeAbove) {
+		return 0
+	}
+	return r.Amount
+}
@@ -3,17 +3,35 @@ package domain
 import (
 	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrVersionConflict is returned when an entity was changed by someone else
+// since it was read, i.e. its version no longer matches
+var ErrVersionConflict = errors.New("resource was modified by another request")
+
 // Product represents the product entity in the domain layer
 type Product struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price" gorm:"not null"`
-	Stock       int       `json:"stock" gorm:"not null;default:0"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	SKU         string  `json:"sku" gorm:"uniqueIndex:idx_products_sku,where:sku <> '' AND deleted_at IS NULL"` // Optional; unique among live products
+	Name        string  `json:"name" gorm:"not null"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price" gorm:"not null"`
+	Stock       int     `json:"stock" gorm:"not null;default:0"` // Total over all warehouses
+	// ReorderThreshold raises a low-stock alert once stock (of each variant,
+	// for products sold through variants) falls to it; 0 disables alerts
+	ReorderThreshold int              `json:"reorder_threshold" gorm:"not null;default:0"`
+	CategoryID       *uint            `json:"category_id" gorm:"index"`
+	Category         *Category        `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Tags             []Tag            `json:"tags" gorm:"many2many:product_tags"`
+	Variants         []ProductVariant `json:"variants" gorm:"foreignKey:ProductID"`
+	Images           []ProductImage   `json:"images" gorm:"foreignKey:ProductID"`
+	StockLevels      []StockLevel     `json:"stock_levels" gorm:"foreignKey:ProductID"` // Stock per warehouse, of the product and its variants
+	CreatedAt        time.Time        `json:"created_at"`
+	Version          uint             `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	UpdatedAt        time.Time        `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt   `json:"deleted_at,omitempty" gorm:"index"` // Soft delete; hidden from queries by default
 }
 
 // TableName specifies the table name for GORM
@@ -32,6 +50,9 @@ func (p *Product) Validate() error {
 	if p.Stock < 0 {
 		return errors.New("product stock cannot be negative")
 	}
+	if p.ReorderThreshold < 0 {
+		return errors.New("reorder threshold cannot be negative")
+	}
 	return nil
 }
 
@@ -49,6 +70,16 @@ func (p *Product) ReduceStock(quantity int) error {
 	return nil
 }
 
+// IsLowStock reports whether stock has fallen to the reorder threshold
+func (p *Product) IsLowStock(stock int) bool {
+	return p.ReorderThreshold > 0 && stock <= p.ReorderThreshold
+}
+
+// HasVariants reports whether the product is sold through its variants
+func (p *Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
 // BeforeCreate is a GORM hook that runs before creating a product
 func (p *Product) BeforeCreate() error {
 	return p.Validate()
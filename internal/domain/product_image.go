@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ProductImage is an uploaded image of a product. Images are returned in
+// ascending Position; the first one is the product's main image.
+type ProductImage struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ProductID    uint      `json:"product_id" gorm:"not null;index"`
+	Position     int       `json:"position" gorm:"not null;default:0"`
+	StorageKey   string    `json:"-" gorm:"not null"`
+	ThumbnailKey string    `json:"-" gorm:"not null"`
+	URL          string    `json:"url" gorm:"not null"`
+	ThumbnailURL string    `json:"thumbnail_url" gorm:"not null"`
+	ContentType  string    `json:"content_type" gorm:"not null"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	AltText      string    `json:"alt_text"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+// Validate performs domain-level validation
+func (i *ProductImage) Validate() error {
+	if i.ProductID == 0 {
+		return errors.New("product ID is required")
+	}
+	if i.StorageKey == "" || i.ThumbnailKey == "" {
+		return errors.New("image storage key is required")
+	}
+	if i.Position < 0 {
+		return errors.New("image position cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductVariant is a purchasable version of a product (e.g. a size/color
+// combination) with its own SKU, price and stock. Products without variants
+// are sold directly using the product's own price and stock.
+type ProductVariant struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ProductID uint           `json:"product_id" gorm:"not null;index"`
+	SKU       string         `json:"sku" gorm:"not null;uniqueIndex"`
+	Size      string         `json:"size"`
+	Color     string         `json:"color"`
+	Price     float64        `json:"price" gorm:"not null"`
+	Stock     int            `json:"stock" gorm:"not null;default:0"` // Total over all warehouses
+	CreatedAt time.Time      `json:"created_at"`
+	Version   uint           `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // Soft delete; ordered variants stay resolvable
+}
+
+// TableName specifies the table name for GORM
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+// Validate performs domain-level validation
+func (v *ProductVariant) Validate() error {
+	if v.ProductID == 0 {
+		return errors.New("product ID is required")
+	}
+	if strings.TrimSpace(v.SKU) == "" {
+		return errors.New("variant SKU is required")
+	}
+	if v.Price <= 0 {
+		return errors.New("variant price must be greater than 0")
+	}
+	if v.Stock < 0 {
+		return errors.New("variant stock cannot be negative")
+	}
+	return nil
+}
+
+// Label describes the variant options, e.g. "M / Red"
+func (v *ProductVariant) Label() string {
+	var parts []string
+	if v.Size != "" {
+		parts = append(parts, v.Size)
+	}
+	if v.Color != "" {
+		parts = append(parts, v.Color)
+	}
+	if len(parts) == 0 {
+		return v.SKU
+	}
+	return strings.Join(parts, " / ")
+}
+
+// IsAvailable checks if the variant has sufficient stock
+func (v *ProductVariant) IsAvailable(quantity int) bool {
+	return v.Stock >= quantity
+}
+
+// ReduceStock reduces the variant stock by the given quantity
+func (v *ProductVariant) ReduceStock(quantity int) error {
+	if !v.IsAvailable(quantity) {
+		return errors.New("insufficient stock")
+	}
+	v.Stock -= quantity
+	return nil
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShipmentStatus is where a shipment is on its way to the customer
+type ShipmentStatus string
+
+const (
+	ShipmentStatusPacked    ShipmentStatus = "packed"
+	ShipmentStatusShipped   ShipmentStatus = "shipped"
+	ShipmentStatusDelivered ShipmentStatus = "delivered"
+)
+
+// shipmentStatusRank orders the statuses; shipments only move forward
+var shipmentStatusRank = map[ShipmentStatus]int{
+	ShipmentStatusPacked:    0,
+	ShipmentStatusShipped:   1,
+	ShipmentStatusDelivered: 2,
+}
+
+// Shipment is a parcel sent for an order. An order may ship in several
+// shipments, each holding part of its items.
+type Shipment struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	OrderID        uint            `json:"order_id" gorm:"not null;index"`
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"tracking_number"`
+	Status         ShipmentStatus  `json:"status" gorm:"not null;default:'packed'"`
+	Items          []ShipmentItem  `json:"items" gorm:"foreignKey:ShipmentID;constraint:OnDelete:CASCADE"`
+	Events         []ShipmentEvent `json:"events" gorm:"foreignKey:ShipmentID;constraint:OnDelete:CASCADE"` // Status history, oldest first
+	ShippedAt      *time.Time      `json:"shipped_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// Validate performs domain-level validation
+func (s *Shipment) Validate() error {
+	if s.OrderID == 0 {
+		return errors.New("order ID is required")
+	}
+	if len(s.Items) == 0 {
+		return errors.New("shipment must have at least one item")
+	}
+	for _, item := range s.Items {
+		if item.Quantity <= 0 {
+			return errors.New("shipped quantity must be greater than 0")
+		}
+	}
+	if s.Status == ShipmentStatusShipped && strings.TrimSpace(s.Carrier) == "" {
+		return errors.New("carrier is required once a shipment is shipped")
+	}
+	return nil
+}
+
+// Advance moves the shipment to status at now and records it in the
+// history. Statuses can't be skipped backwards or repeated.
+func (s *Shipment) Advance(status ShipmentStatus, note string, now time.Time) error {
+	rank, ok := shipmentStatusRank[status]
+	if !ok {
+		return fmt.Errorf("invalid shipment status %q", status)
+	}
+	if rank <= shipmentStatusRank[s.Status] {
+		return fmt.Errorf("shipment is already %s", s.Status)
+	}
+	if status != ShipmentStatusPacked && strings.TrimSpace(s.Carrier) == "" {
+		return errors.New("carrier is required once a shipment is shipped")
+	}
+
+	s.Status = status
+	if s.ShippedAt == nil && rank >= shipmentStatusRank[ShipmentStatusShipped] {
+		s.ShippedAt = &now
+	}
+	if status == ShipmentStatusDelivered {
+		s.DeliveredAt = &now
+	}
+	s.Events = append(s.Events, ShipmentEvent{ShipmentID: s.ID, Status: status, Note: note, CreatedAt: now})
+	return nil
+}
+
+// ShipmentItem is the quantity of an order item that a shipment holds
+type ShipmentItem struct {
+	ID          uint `json:"id" gorm:"primaryKey"`
+	ShipmentID  uint `json:"shipment_id" gorm:"not null;index"`
+	OrderItemID uint `json:"order_item_id" gorm:"not null;index"`
+	Quantity    int  `json:"quantity" gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
+
+// ShipmentEvent records a status a shipment reached
+type ShipmentEvent struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	ShipmentID uint           `json:"shipment_id" gorm:"not null;index"`
+	Status     ShipmentStatus `json:"status" gorm:"not null"`
+	Note       string         `json:"note"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ShipmentEvent) TableName() string {
+	return "shipment_events"
+}
+
+// ShippedQuantities returns how many units of each order item the shipments
+// hold, by order item ID. With delivered set, only delivered shipments count.
+func ShippedQuantities(shipments []Shipment, delivered bool) map[uint]int {
+	quantities := make(map[uint]int)
+	for _, shipment := range shipments {
+		if delivered && shipment.Status != ShipmentStatusDelivered {
+			continue
+		}
+		for _, item := range shipment.Items {
+			quantities[item.OrderItemID] += item.Quantity
+		}
+	}
+	return quantities
+}
+
+// CanShip reports whether shipments may still be created for the order
+func (o *Order) CanShip() bool {
+	return o.Status == OrderStatusPending || o.Status == OrderStatusPaid
+}
+
+// IsDelivered reports whether the shipments delivered every item of the order
+func (o *Order) IsDelivered(shipments []Shipment) bool {
+	delivered := ShippedQuantities(shipments, true)
+	for _, item := range o.Items {
+		if delivered[item.ID] < item.Quantity {
+			return false
+		}
+	}
+	return len(o.Items) > 0
+}
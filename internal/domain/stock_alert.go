@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// StockAlert records that a product, or one of its variants, fell to the
+// product's reorder threshold. An item has at most one open alert; it is
+// resolved once stock is back above the threshold, so that the next
+// crossing raises a new one.
+type StockAlert struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ProductID  uint       `json:"product_id" gorm:"not null;index;uniqueIndex:idx_stock_alerts_open_product,where:variant_id IS NULL AND resolved_at IS NULL"`
+	VariantID  *uint      `json:"variant_id" gorm:"uniqueIndex:idx_stock_alerts_open_variant,where:variant_id IS NOT NULL AND resolved_at IS NULL"`
+	Stock      int        `json:"stock" gorm:"not null"`
+	Threshold  int        `json:"threshold" gorm:"not null"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+// TableName specifies the table name for GORM
+func (StockAlert) TableName() string {
+	return "stock_alerts"
+}
@@ -2,17 +2,34 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserRole represents the role of a user
+type UserRole string
+
+const (
+	UserRoleCustomer UserRole = "customer"
+	UserRoleAdmin    UserRole = "admin"
 )
 
 // User represents the user entity in the domain layer
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
-	FullName  string    `json:"full_name" gorm:"not null"`
-	Password  string    `json:"-" gorm:"not null"` // Never expose password in JSON
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Email      string     `json:"email" gorm:"uniqueIndex;not null"`
+	FullName   string     `json:"full_name" gorm:"not null"`
+	Password   string     `json:"-" gorm:"not null"` // Never expose password in JSON
+	Role       UserRole   `json:"role" gorm:"not null;default:'customer'"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	// AnonymizedAt is set when the account was deleted by its owner; the row
+	// is kept with PII removed so past orders remain for accounting
+	AnonymizedAt *time.Time     `json:"anonymized_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // Soft delete; hidden from queries by default
 }
 
 // TableName specifies the table name for GORM
@@ -34,6 +51,31 @@ func (u *User) Validate() error {
 	return nil
 }
 
+// IsAdmin checks if the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == UserRoleAdmin
+}
+
+// IsVerified checks if the user has verified their email address
+func (u *User) IsVerified() bool {
+	return u.VerifiedAt != nil
+}
+
+// IsAnonymized checks if the account has been deleted and anonymized
+func (u *User) IsAnonymized() bool {
+	return u.AnonymizedAt != nil
+}
+
+// Anonymize removes personal data from the user. The password is replaced
+// with a value that is not a valid bcrypt hash so the account can't log in.
+func (u *User) Anonymize(now time.Time) {
+	u.Email = fmt.Sprintf("deleted-user-%d@deleted.invalid", u.ID)
+	u.FullName = "Deleted User"
+	u.Password = "!"
+	u.VerifiedAt = nil
+	u.AnonymizedAt = &now
+}
+
 // BeforeCreate is a GORM hook that runs before creating a user
 func (u *User) BeforeCreate() error {
 	return u.Validate()
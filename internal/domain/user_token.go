@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+)
+
+// UserTokenPurpose represents what a user token can be used for
+type UserTokenPurpose string
+
+const (
+	UserTokenEmailVerification UserTokenPurpose = "email_verification"
+	UserTokenPasswordReset     UserTokenPurpose = "password_reset"
+)
+
+// UserToken is a single-use token sent to a user by email. Only a hash of
+// the token is stored so a database leak does not expose usable links.
+type UserToken struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    uint             `json:"user_id" gorm:"not null;index"`
+	Purpose   UserTokenPurpose `json:"purpose" gorm:"not null"`
+	TokenHash string           `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time        `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time       `json:"used_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// IsUsable checks that the token has not been used and has not expired
+func (t *UserToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
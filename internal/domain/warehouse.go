@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var warehouseCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]*$`)
+
+// DefaultWarehouseCode is the code of the warehouse created for stock that
+// predates multi-warehouse inventory
+const DefaultWarehouseCode = "DEFAULT"
+
+// Warehouse is a location holding stock. Orders are allocated from
+// warehouses in ascending Priority; stock changes that name no warehouse
+// (product edits, imports) are booked on the default one.
+type Warehouse struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"not null;uniqueIndex"`
+	Name      string    `json:"name" gorm:"not null"`
+	Address   string    `json:"address"`
+	Priority  int       `json:"priority" gorm:"not null;default:0"`
+	IsDefault bool      `json:"is_default" gorm:"not null;default:false;uniqueIndex:idx_warehouses_default,where:is_default"`
+	Version   uint      `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// Validate performs domain-level validation
+func (w *Warehouse) Validate() error {
+	if !warehouseCodePattern.MatchString(w.Code) {
+		return errors.New("warehouse code must contain only uppercase letters, digits, dashes and underscores")
+	}
+	if strings.TrimSpace(w.Name) == "" {
+		return errors.New("warehouse name is required")
+	}
+	return nil
+}
+
+// StockLevel is the stock of a product, or of one of its variants, in one
+// warehouse. Product.Stock and ProductVariant.Stock hold the total over all
+// warehouses.
+type StockLevel struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_stock_levels_product,where:variant_id IS NULL;uniqueIndex:idx_stock_levels_variant,where:variant_id IS NOT NULL"`
+	Warehouse   *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	ProductID   uint       `json:"product_id" gorm:"not null;index;uniqueIndex:idx_stock_levels_product,where:variant_id IS NULL"`
+	VariantID   *uint      `json:"variant_id" gorm:"uniqueIndex:idx_stock_levels_variant,where:variant_id IS NOT NULL"`
+	Quantity    int        `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (StockLevel) TableName() string {
+	return "stock_levels"
+}
+
+// AllocateStock picks the warehouses an order line of quantity is shipped
+// from. levels must be sorted by allocation preference. The first warehouse
+// that can ship everything is used; otherwise the line is split, draining
+// warehouses in order.
+func AllocateStock(levels []StockLevel, quantity int) ([]OrderAllocation, error) {
+	for _, level := range levels {
+		if level.Quantity >= quantity {
+			return []OrderAllocation{{WarehouseID: level.WarehouseID, Quantity: quantity}}, nil
+		}
+	}
+
+	var allocations []OrderAllocation
+	remaining := quantity
+	for _, level := range levels {
+		if level.Quantity <= 0 {
+			continue
+		}
+		take := min(level.Quantity, remaining)
+		allocations = append(allocations, OrderAllocation{WarehouseID: level.WarehouseID, Quantity: take})
+		if remaining -= take; remaining == 0 {
+			return allocations, nil
+		}
+	}
+	return nil, ErrInsufficientStock
+}
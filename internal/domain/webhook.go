@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// WebhookSubscription sends the events of the listed types to a partner's
+// URL, signed with Secret
+type WebhookSubscription struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	URL         string    `json:"url" gorm:"not null"`
+	Secret      string    `json:"secret" gorm:"not null"`
+	Events      []string  `json:"events" gorm:"serializer:json;type:text;not null"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active" gorm:"not null;default:true"`
+	Version     uint      `json:"version" gorm:"not null;default:1"` // Counts updates, for optimistic concurrency
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Deliveries []WebhookDelivery `json:"-" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Validate performs domain-level validation
+func (s *WebhookSubscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL must be an absolute http or https URL")
+	}
+	if len(s.Secret) < 16 {
+		return errors.New("secret must be at least 16 characters")
+	}
+	if len(s.Events) == 0 {
+		return errors.New("subscription must have at least one event type")
+	}
+	return nil
+}
+
+// Subscribes reports whether the subscription receives events of eventType
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return s.Active && slices.Contains(s.Events, eventType)
+}
+
+// WebhookDeliveryStatus is the outcome of a webhook delivery so far
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // Not attempted yet
+	WebhookDeliveryRetrying  WebhookDeliveryStatus = "retrying"  // Failed, will be retried
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // Acknowledged with a 2xx response
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // Out of retries
+)
+
+// WebhookDelivery is one event sent to one subscription. Payload is the
+// exact body sent with every attempt.
+type WebhookDelivery struct {
+	ID             uint                     `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint                     `json:"subscription_id" gorm:"not null;index"`
+	EventID        string                   `json:"event_id" gorm:"size:64;not null;index"`
+	EventType      string                   `json:"event_type" gorm:"size:100;not null"`
+	Payload        json.RawMessage          `json:"payload" gorm:"type:jsonb;not null"`
+	Status         WebhookDeliveryStatus    `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	Attempts       []WebhookDeliveryAttempt `json:"attempts,omitempty" gorm:"foreignKey:DeliveryID;constraint:OnDelete:CASCADE"` // Oldest first
+	DeliveredAt    *time.Time               `json:"delivered_at"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryAttempt records one request of a delivery: the response
+// status, or the error when no response came
+type WebhookDeliveryAttempt struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID   uint      `json:"delivery_id" gorm:"not null;index"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	Error        string    `json:"error,omitempty" gorm:"type:text"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+// Succeeded reports whether the subscriber acknowledged the attempt
+func (a *WebhookDeliveryAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
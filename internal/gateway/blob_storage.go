@@ -0,0 +1,20 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a key does not exist in the storage
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStorage defines the interface for storing binary objects such as
+// product images. Keys are slash separated paths, e.g. "products/1/a.jpg".
+type BlobStorage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL the object is served under
+	URL(key string) string
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"time"
+)
+
+// Event types published to other systems
+const (
+	EventOrderCreated     = "order.created"
+	EventOrderPaid        = "order.paid"
+	EventOrderCancelled   = "order.cancelled"
+	EventPaymentCompleted = "payment.completed"
+	EventPaymentFailed    = "payment.failed"
+)
+
+// EventTypes lists every event type published
+var EventTypes = []string{
+	EventOrderCreated,
+	EventOrderPaid,
+	EventOrderCancelled,
+	EventPaymentCompleted,
+	EventPaymentFailed,
+}
+
+// Event tells other systems that something happened, e.g. an order was
+// cancelled. Data is the affected entity as of the event.
+type Event struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// EventPublisher defines the interface for publishing domain events.
+// Events are published once the change they describe is committed.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
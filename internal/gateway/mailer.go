@@ -0,0 +1,15 @@
+package gateway
+
+import "context"
+
+// Email is a plain text message sent to a single recipient
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer defines the interface for delivering emails
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
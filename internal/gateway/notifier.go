@@ -0,0 +1,23 @@
+package gateway
+
+import (
+	"context"
+	"time"
+)
+
+// LowStockAlert tells that a product, or one of its variants, fell to its
+// reorder threshold
+type LowStockAlert struct {
+	ProductID   uint      `json:"product_id"`
+	VariantID   *uint     `json:"variant_id,omitempty"`
+	SKU         string    `json:"sku"`
+	Name        string    `json:"name"`
+	Stock       int       `json:"stock"`
+	Threshold   int       `json:"threshold"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// Notifier defines the interface for delivering operational alerts
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alert LowStockAlert) error
+}
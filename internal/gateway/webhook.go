@@ -0,0 +1,26 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+)
+
+// WebhookRequest is a POST of a JSON body to a subscriber's URL
+type WebhookRequest struct {
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// WebhookResponse is what a subscriber answered. Body is cut short for
+// logging.
+type WebhookResponse struct {
+	StatusCode int
+	Body       string
+}
+
+// WebhookSender defines the interface for calling webhook subscribers. It
+// returns an error only when no response was received.
+type WebhookSender interface {
+	Send(ctx context.Context, req WebhookRequest) (*WebhookResponse, error)
+}
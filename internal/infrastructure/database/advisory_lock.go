@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"log/slog"
+	"time"
+
+	"github.com/example/clean-arch-template/pkg/scheduler"
+	"gorm.io/gorm"
+)
+
+type advisoryLocker struct {
+	db *sql.DB
+}
+
+// NewAdvisoryLocker creates a scheduler.Locker backed by Postgres session
+// advisory locks, shared by every instance using the same database. A lock
+// is released when its holder unlocks it or its connection drops.
+func NewAdvisoryLocker(db *gorm.DB) (scheduler.Locker, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return &advisoryLocker{db: sqlDB}, nil
+}
+
+func (l *advisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	// Session locks belong to a connection, so the lock keeps one until
+	// it is released
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := advisoryKey(name)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// The job's context may be done already
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key); err != nil {
+			slog.Error("failed to release advisory lock", "lock", name, "error", err)
+			// Drop the connection rather than return it to the pool still
+			// holding the lock; closing it releases the lock
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+// advisoryKey maps a lock name to the 64-bit key Postgres locks on
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
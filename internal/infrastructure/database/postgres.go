@@ -1,24 +1,29 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/example/clean-arch-template/config"
 	"github.com/example/clean-arch-template/internal/domain"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
+// ReplicaResolver is the dbresolver name under which read replicas are
+// registered. Queries opt in with dbresolver.Use(ReplicaResolver); everything
+// else, including all writes and transactions, goes to the primary.
+const ReplicaResolver = "read-replicas"
+
 // NewPostgresConnection creates a new PostgreSQL database connection
 func NewPostgresConnection(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	dsn := cfg.GetDSN()
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-
+	db, err := openWithRetry(cfg, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -32,28 +37,230 @@ func NewPostgresConnection(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	}
 
 	// Connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := registerReplicas(db, cfg); err != nil {
+		return nil, fmt.Errorf("failed to connect to read replicas: %w", err)
+	}
 
 	return db, nil
 }
 
+// openWithRetry opens the connection, retrying with exponential backoff so
+// the service can start before the database is ready to accept connections
+func openWithRetry(cfg *config.DatabaseConfig, dsn string) (*gorm.DB, error) {
+	backoff := cfg.ConnectRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Info),
+		})
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, err
+		}
+
+		log.Printf("Database connection attempt %d failed, retrying in %s: %v", attempt+1, backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > cfg.ConnectRetryMaxBackoff {
+			backoff = cfg.ConnectRetryMaxBackoff
+		}
+	}
+}
+
+// registerReplicas installs the dbresolver plugin when read replicas are
+// configured. Replica pools use the same pool settings as the primary.
+func registerReplicas(db *gorm.DB, cfg *config.DatabaseConfig) error {
+	dsns := cfg.GetReplicaDSNs()
+	if len(dsns) == 0 {
+		return nil
+	}
+
+	replicas := make([]gorm.Dialector, 0, len(dsns))
+	for _, dsn := range dsns {
+		replicas = append(replicas, postgres.Open(dsn))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}, ReplicaResolver).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := db.Use(resolver); err != nil {
+		return err
+	}
+
+	log.Printf("Registered %d read replica(s)", len(dsns))
+	return nil
+}
+
 // AutoMigrate runs database migrations for all models
 func AutoMigrate(db *gorm.DB) error {
 	log.Println("Running auto migration...")
 
 	err := db.AutoMigrate(
 		&domain.User{},
+		&domain.Address{},
+		&domain.Category{},
+		&domain.Tag{},
 		&domain.Product{},
+		&domain.ProductVariant{},
+		&domain.ProductImage{},
+		&domain.Warehouse{},
+		&domain.StockLevel{},
+		&domain.InventoryMovement{},
+		&domain.StockAlert{},
+		&domain.Cart{},
+		&domain.CartItem{},
+		&domain.Coupon{},
+		&domain.TaxRate{},
+		&domain.ShippingRate{},
 		&domain.Order{},
 		&domain.OrderItem{},
+		&domain.OrderAllocation{},
+		&domain.OrderDiscount{},
+		&domain.CouponRedemption{},
+		&domain.Shipment{},
+		&domain.ShipmentItem{},
+		&domain.ShipmentEvent{},
 		&domain.Payment{},
+		&domain.InvoiceSequence{},
+		&domain.Invoice{},
+		&domain.LoginAttempt{},
+		&domain.UserToken{},
+		&domain.Job{},
+		&domain.WebhookSubscription{},
+		&domain.WebhookDelivery{},
+		&domain.WebhookDeliveryAttempt{},
+		&domain.AuditLog{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Emails are unique regardless of case; lookups and the admin order search
+	// match them case-insensitively. Accounts that differ only in the case of
+	// their email must be merged before this index can be created.
+	indexes := []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower_unique ON users (LOWER(email))`,
+		`DROP INDEX IF EXISTS idx_users_email_lower`,
+	}
+	for _, stmt := range indexes {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create indexes: %w", err)
+		}
+	}
+
+	if err := migrateDefaultWarehouse(db); err != nil {
+		return fmt.Errorf("failed to migrate stock to the default warehouse: %w", err)
+	}
+	if err := migrateOpeningBalances(db); err != nil {
+		return fmt.Errorf("failed to record opening stock balances: %w", err)
+	}
+
+	// Orders placed before the pricing breakdown existed paid their subtotal,
+	// untaxed and without shipping
+	backfills := []string{
+		`UPDATE orders SET subtotal = total_amount WHERE subtotal = 0 AND discount_amount = 0`,
+		`UPDATE order_items SET total = price * quantity - discount_amount WHERE total = 0`,
+	}
+	for _, stmt := range backfills {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to backfill order pricing: %w", err)
+		}
+	}
+
+	// Invoices issued before the customer was kept on them are billed to
+	// the customer as they are now
+	err = db.Exec(`
+		UPDATE invoices SET bill_to_name = u.full_name, bill_to_email = u.email
+		FROM orders o JOIN users u ON u.id = o.user_id
+		WHERE o.id = invoices.order_id AND invoices.bill_to_email = ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill invoice customers: %w", err)
+	}
+
 	log.Println("Auto migration completed successfully")
 	return nil
 }
+
+// migrateDefaultWarehouse creates the default warehouse the first time
+// migrations run with warehouse support, and moves all existing stock and
+// its ledger into it
+func migrateDefaultWarehouse(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.Warehouse{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		warehouse := domain.Warehouse{
+			Code:      domain.DefaultWarehouseCode,
+			Name:      "Default warehouse",
+			IsDefault: true,
+		}
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+
+		statements := []string{
+			`UPDATE inventory_movements SET warehouse_id = @id WHERE warehouse_id IS NULL`,
+			`INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at)
+				SELECT @id, id, NULL, stock, NOW() FROM products WHERE stock <> 0`,
+			`INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at)
+				SELECT @id, product_id, id, stock, NOW() FROM product_variants WHERE stock <> 0`,
+		}
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt, sql.Named("id", warehouse.ID)).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("Moved existing stock to warehouse %s", warehouse.Code)
+		return nil
+	})
+}
+
+// migrateOpeningBalances gives stock that existed before the inventory
+// ledger a movement to account for it, so reconciliation doesn't report it
+// as drift. It only touches warehouse levels without any movement, so it
+// runs once per level; it must run after the stock was moved into the
+// default warehouse.
+func migrateOpeningBalances(db *gorm.DB) error {
+	result := db.Exec(`
+		INSERT INTO inventory_movements (product_id, variant_id, warehouse_id, type, quantity, stock_after, reason, created_at)
+		SELECT s.product_id, s.variant_id, s.warehouse_id, @type, s.quantity, s.quantity, @reason, NOW()
+		FROM stock_levels s
+		WHERE s.quantity <> 0 AND NOT EXISTS (
+			SELECT 1 FROM inventory_movements m
+			WHERE m.warehouse_id = s.warehouse_id
+				AND m.product_id = s.product_id
+				AND m.variant_id IS NOT DISTINCT FROM s.variant_id
+		)`,
+		sql.Named("type", domain.MovementAdjustment),
+		sql.Named("reason", "opening balance"),
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("Recorded opening balances for %d stock levels", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer creates a Mailer that delivers through an SMTP server. Leave
+// username empty for servers without authentication, such as local catch-all
+// SMTP sinks used in development.
+func NewSMTPMailer(host, port, username, password, from string) gateway.Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, email gateway.Email) error {
+	// net/smtp has no context support; run it so callers can stop waiting
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, buildMessage(m.from, email))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// buildMessage renders a minimal RFC 5322 plain text message
+func buildMessage(from string, email gateway.Email) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + sanitizeHeader(from) + "\r\n")
+	b.WriteString("To: " + sanitizeHeader(email.To) + "\r\n")
+	b.WriteString("Subject: " + sanitizeHeader(email.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sanitizeHeader strips line breaks to prevent header injection
+func sanitizeHeader(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
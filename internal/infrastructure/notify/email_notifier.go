@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+type emailNotifier struct {
+	mailer gateway.Mailer
+	to     string
+}
+
+// NewEmailNotifier creates a Notifier that emails alerts to a fixed
+// recipient through mailer. With the file mailer this only writes .eml
+// files, which is enough for development.
+func NewEmailNotifier(mailer gateway.Mailer, to string) gateway.Notifier {
+	return &emailNotifier{mailer: mailer, to: to}
+}
+
+func (n *emailNotifier) NotifyLowStock(ctx context.Context, alert gateway.LowStockAlert) error {
+	body := fmt.Sprintf("%s (SKU %s) is low on stock.\n\nProduct ID: %d\nStock: %d\nReorder threshold: %d\nTriggered at: %s\n",
+		alert.Name, alert.SKU, alert.ProductID, alert.Stock, alert.Threshold, alert.TriggeredAt.Format("2006-01-02 15:04:05 MST"))
+	if alert.VariantID != nil {
+		body += fmt.Sprintf("Variant ID: %d\n", *alert.VariantID)
+	}
+
+	return n.mailer.Send(ctx, gateway.Email{
+		To:      n.to,
+		Subject: fmt.Sprintf("Low stock: %s", alert.Name),
+		Body:    body,
+	})
+}
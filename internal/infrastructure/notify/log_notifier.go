@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+type logNotifier struct {
+	logger *slog.Logger
+}
+
+// NewLogNotifier creates a Notifier that writes alerts to the application
+// log as warnings
+func NewLogNotifier(logger *slog.Logger) gateway.Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) NotifyLowStock(ctx context.Context, alert gateway.LowStockAlert) error {
+	args := []any{
+		"product_id", alert.ProductID,
+		"sku", alert.SKU,
+		"name", alert.Name,
+		"stock", alert.Stock,
+		"threshold", alert.Threshold,
+	}
+	if alert.VariantID != nil {
+		args = append(args, "variant_id", *alert.VariantID)
+	}
+	n.logger.WarnContext(ctx, "low stock", args...)
+	return nil
+}
@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+type logPublisher struct {
+	logger *slog.Logger
+}
+
+// NewLogPublisher creates an EventPublisher that writes events to the
+// application log
+func NewLogPublisher(logger *slog.Logger) gateway.EventPublisher {
+	return &logPublisher{logger: logger}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event gateway.Event) error {
+	p.logger.InfoContext(ctx, "event", "type", event.Type, "occurred_at", event.OccurredAt, "data", event.Data)
+	return nil
+}
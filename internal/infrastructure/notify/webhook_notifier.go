@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a Notifier that POSTs each alert as JSON to url
+func NewWebhookNotifier(url string, timeout time.Duration) gateway.Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+type webhookPayload struct {
+	Event string                `json:"event"`
+	Alert gateway.LowStockAlert `json:"alert"`
+}
+
+func (n *webhookNotifier) NotifyLowStock(ctx context.Context, alert gateway.LowStockAlert) error {
+	body, err := json.Marshal(webhookPayload{Event: "inventory.low_stock", Alert: alert})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call alert webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+// maxWebhookResponse is how much of a subscriber's response body is kept
+const maxWebhookResponse = 4 << 10
+
+type webhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender creates a WebhookSender that gives subscribers timeout to
+// respond. Redirects are not followed.
+func NewWebhookSender(timeout time.Duration) gateway.WebhookSender {
+	return &webhookSender{client: &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (s *webhookSender) Send(ctx context.Context, r gateway.WebhookRequest) (*gateway.WebhookResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range r.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	return &gateway.WebhookResponse{StatusCode: resp.StatusCode, Body: string(body)}, nil
+}
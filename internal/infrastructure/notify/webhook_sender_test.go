@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+func TestWebhookSenderReturnsResponse(t *testing.T) {
+	var gotHeader http.Header
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "try again later")
+	}))
+	defer srv.Close()
+
+	header := http.Header{}
+	header.Set("X-Webhook-Event", "order.paid")
+	resp, err := NewWebhookSender(time.Second).Send(context.Background(), gateway.WebhookRequest{
+		URL:    srv.URL,
+		Header: header,
+		Body:   []byte(`{"id":"evt_1"}`),
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Body != "try again later" {
+		t.Errorf("got %d %q, want 503 with the subscriber's body", resp.StatusCode, resp.Body)
+	}
+	if gotBody != `{"id":"evt_1"}` {
+		t.Errorf("subscriber received %q", gotBody)
+	}
+	if gotHeader.Get("X-Webhook-Event") != "order.paid" || gotHeader.Get("Content-Type") != "application/json" {
+		t.Errorf("subscriber received headers %v", gotHeader)
+	}
+}
+
+func TestWebhookSenderCutsLongResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 2*maxWebhookResponse))
+	}))
+	defer srv.Close()
+
+	resp, err := NewWebhookSender(time.Second).Send(context.Background(), gateway.WebhookRequest{URL: srv.URL, Body: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(resp.Body) != maxWebhookResponse {
+		t.Errorf("kept %d bytes of the body, want %d", len(resp.Body), maxWebhookResponse)
+	}
+}
+
+func TestWebhookSenderDoesNotFollowRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			t.Error("sender followed the redirect")
+		}
+		http.Redirect(w, r, "/moved", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	resp, err := NewWebhookSender(time.Second).Send(context.Background(), gateway.WebhookRequest{URL: srv.URL, Body: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("status = %d, want 302", resp.StatusCode)
+	}
+}
+
+func TestWebhookSenderTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	_, err := NewWebhookSender(50*time.Millisecond).Send(context.Background(), gateway.WebhookRequest{URL: srv.URL, Body: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("Send succeeded without a response")
+	}
+}
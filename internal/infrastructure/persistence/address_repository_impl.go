@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type addressRepository struct {
+	db *gorm.DB
+}
+
+// NewAddressRepository creates a new instance of AddressRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewAddressRepository(db *gorm.DB) repository.AddressRepository {
+	return &addressRepository{db: db}
+}
+
+func (r *addressRepository) Create(ctx context.Context, address *domain.Address) error {
+	return r.db.WithContext(ctx).Create(address).Error
+}
+
+func (r *addressRepository) FindByID(ctx context.Context, userID, id uint) (*domain.Address, error) {
+	var address domain.Address
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&address, id).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *addressRepository) FindByUserID(ctx context.Context, userID uint) ([]domain.Address, error) {
+	var addresses []domain.Address
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("is_default DESC, id").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *addressRepository) FindDefault(ctx context.Context, userID uint) (*domain.Address, error) {
+	var address domain.Address
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND is_default", userID).Take(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *addressRepository) Update(ctx context.Context, address *domain.Address) error {
+	return r.db.WithContext(ctx).Save(address).Error
+}
+
+func (r *addressRepository) ClearDefault(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&domain.Address{}).
+		Where("user_id = ? AND is_default", userID).
+		Update("is_default", false).Error
+}
+
+func (r *addressRepository) Delete(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.Address{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *addressRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.Address{}).Error
+}
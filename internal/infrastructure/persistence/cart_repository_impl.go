@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type cartRepository struct {
+	db *gorm.DB
+}
+
+// NewCartRepository creates a new instance of CartRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewCartRepository(db *gorm.DB) repository.CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) Create(ctx context.Context, cart *domain.Cart) error {
+	return r.db.WithContext(ctx).Omit("Items").Create(cart).Error
+}
+
+func (r *cartRepository) FindByID(ctx context.Context, id uint) (*domain.Cart, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r *cartRepository) FindByUserID(ctx context.Context, userID uint) (*domain.Cart, error) {
+	return r.find(ctx, "user_id = ?", userID)
+}
+
+func (r *cartRepository) FindByGuestTokenHash(ctx context.Context, hash string) (*domain.Cart, error) {
+	return r.find(ctx, "guest_token_hash = ? AND user_id IS NULL", hash)
+}
+
+func (r *cartRepository) find(ctx context.Context, query string, args ...any) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where(query, args...).
+		Take(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *cartRepository) Touch(ctx context.Context, cartID uint, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.Cart{}).
+		Where("id = ?", cartID).
+		Update("expires_at", expiresAt).Error
+}
+
+func (r *cartRepository) SaveItem(ctx context.Context, item *domain.CartItem) error {
+	return r.db.WithContext(ctx).Save(item).Error
+}
+
+func (r *cartRepository) DeleteItem(ctx context.Context, cartID, itemID uint) error {
+	return r.db.WithContext(ctx).
+		Where("cart_id = ?", cartID).
+		Delete(&domain.CartItem{}, itemID).Error
+}
+
+func (r *cartRepository) Clear(ctx context.Context, cartID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Where("cart_id = ?", cartID).Delete(&domain.CartItem{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *cartRepository) Delete(ctx context.Context, cartID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cart_id = ?", cartID).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Cart{}, cartID).Error
+	})
+}
+
+func (r *cartRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		carts := tx.Model(&domain.Cart{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("cart_id IN (?)", carts).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&domain.Cart{}).Error
+	})
+}
+
+func (r *cartRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&domain.Cart{}).Select("id").Where("expires_at <= ?", now)
+		if err := tx.Where("cart_id IN (?)", expired).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("expires_at <= ?", now).Delete(&domain.Cart{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
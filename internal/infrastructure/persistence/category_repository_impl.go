@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository creates a new instance of CategoryRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewCategoryRepository(db *gorm.DB) repository.CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	return r.db.WithContext(ctx).Omit("Parent", "Children").Create(category).Error
+}
+
+func (r *categoryRepository) FindByID(ctx context.Context, id uint) (*domain.Category, error) {
+	var category domain.Category
+	err := r.db.WithContext(ctx).
+		Preload("Parent").
+		Preload("Children", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		First(&category, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// FindAll is read-only and served by a read replica when available
+func (r *categoryRepository) FindAll(ctx context.Context) ([]domain.Category, error) {
+	var categories []domain.Category
+	err := readReplica(r.db.WithContext(ctx)).Order("name").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepository) FindSubtreeIDs(ctx context.Context, id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION ALL
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree`, id).
+		Scan(&ids).Error
+	return ids, err
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	return updateVersioned(r.db.WithContext(ctx), category, &category.Version, clause.Associations)
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &domain.Category{}, id, version)
+}
+
+func (r *categoryRepository) IsInUse(ctx context.Context, id uint) (bool, error) {
+	var children, products int64
+	if err := r.db.WithContext(ctx).Model(&domain.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+		return false, err
+	}
+	// Soft-deleted products still reference the category
+	if err := r.db.WithContext(ctx).Unscoped().Model(&domain.Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+		return false, err
+	}
+	return children > 0 || products > 0, nil
+}
@@ -0,0 +1,109 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository creates a new instance of CouponRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewCouponRepository(db *gorm.DB) repository.CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) Create(ctx context.Context, coupon *domain.Coupon) error {
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+func (r *couponRepository) FindByID(ctx context.Context, id uint) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+	if err := r.db.WithContext(ctx).First(&coupon, id).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) FindAll(ctx context.Context) ([]domain.Coupon, error) {
+	var coupons []domain.Coupon
+	err := readReplica(r.db.WithContext(ctx)).Order("id").Find(&coupons).Error
+	return coupons, err
+}
+
+func (r *couponRepository) Update(ctx context.Context, coupon *domain.Coupon) error {
+	return updateVersioned(r.db.WithContext(ctx), coupon, &coupon.Version, clause.Associations, "RedemptionCount")
+}
+
+func (r *couponRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &domain.Coupon{}, id, version)
+}
+
+func (r *couponRepository) LockByCodes(ctx context.Context, codes []string) ([]domain.Coupon, error) {
+	var coupons []domain.Coupon
+	// Locking in ID order keeps concurrent orders from deadlocking
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code IN ?", codes).
+		Order("id").
+		Find(&coupons).Error
+	return coupons, err
+}
+
+func (r *couponRepository) CountRedemptions(ctx context.Context, couponID, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *couponRepository) HasRedemptions(ctx context.Context, couponID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.CouponRedemption{}).
+		Where("coupon_id = ?", couponID).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *couponRepository) Redeem(ctx context.Context, redemption *domain.CouponRedemption) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The limit is checked in the same statement that counts the
+		// redemption, so it holds even without the row lock
+		result := tx.Model(&domain.Coupon{}).
+			Where("id = ? AND (max_redemptions = 0 OR redemption_count < max_redemptions)", redemption.CouponID).
+			Update("redemption_count", gorm.Expr("redemption_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrCouponExhausted
+		}
+		return tx.Create(redemption).Error
+	})
+}
+
+func (r *couponRepository) ReleaseRedemptions(ctx context.Context, orderID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemptions []domain.CouponRedemption
+		if err := tx.Where("order_id = ?", orderID).Find(&redemptions).Error; err != nil {
+			return err
+		}
+		for _, redemption := range redemptions {
+			err := tx.Model(&domain.Coupon{}).
+				Where("id = ? AND redemption_count > 0", redemption.CouponID).
+				Update("redemption_count", gorm.Expr("redemption_count - 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("order_id = ?", orderID).Delete(&domain.CouponRedemption{}).Error
+	})
+}
@@ -0,0 +1,248 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+// NewInventoryRepository creates a new instance of InventoryRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewInventoryRepository(db *gorm.DB) repository.InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+func (r *inventoryRepository) Apply(ctx context.Context, movement *domain.InventoryMovement) error {
+	return r.record(ctx, movement, func(total int) int { return movement.Quantity })
+}
+
+func (r *inventoryRepository) SetLevel(ctx context.Context, movement *domain.InventoryMovement, level int) error {
+	return r.record(ctx, movement, func(total int) int { return level - total })
+}
+
+func (r *inventoryRepository) Transfer(ctx context.Context, out, in *domain.InventoryMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := &inventoryRepository{db: tx}
+		if err := repo.Apply(ctx, out); err != nil {
+			return err
+		}
+		return repo.Apply(ctx, in)
+	})
+}
+
+// record locks the product or variant row, lets quantity pick the change from
+// the current total stock, and writes the warehouse level, the total and the
+// movement in one transaction (a savepoint when already inside one)
+func (r *inventoryRepository) record(ctx context.Context, movement *domain.InventoryMovement, quantity func(total int) int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		total, err := lockItem(tx, movement.ProductID, movement.VariantID)
+		if err != nil {
+			return err
+		}
+
+		if movement.WarehouseID == nil {
+			var warehouse domain.Warehouse
+			if err := tx.Where("is_default").Take(&warehouse).Error; err != nil {
+				return err
+			}
+			movement.WarehouseID = &warehouse.ID
+		}
+
+		movement.Quantity = quantity(total)
+		if movement.Quantity == 0 {
+			return nil
+		}
+		if err := movement.Validate(); err != nil {
+			return err
+		}
+
+		// The item row lock serializes all writers, so a missing level can be
+		// created without racing
+		level := domain.StockLevel{WarehouseID: *movement.WarehouseID, ProductID: movement.ProductID, VariantID: movement.VariantID}
+		err = tx.Scopes(stockItem(movement.ProductID, movement.VariantID)).
+			Where("warehouse_id = ?", level.WarehouseID).
+			Take(&level).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		movement.StockAfter = level.Quantity + movement.Quantity
+		if movement.StockAfter < 0 {
+			return domain.ErrInsufficientStock
+		}
+
+		level.Quantity = movement.StockAfter
+		if err := tx.Save(&level).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(itemModel(movement.VariantID)).
+			Where("id = ?", itemID(movement.ProductID, movement.VariantID)).
+			Update("stock", total+movement.Quantity).Error; err != nil {
+			return err
+		}
+		return tx.Create(movement).Error
+	})
+}
+
+func (r *inventoryRepository) Append(ctx context.Context, movement *domain.InventoryMovement) error {
+	if err := movement.Validate(); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(movement).Error
+}
+
+func (r *inventoryRepository) LockLevels(ctx context.Context, productID uint, variantID *uint) ([]domain.StockLevel, error) {
+	db := r.db.WithContext(ctx)
+	if _, err := lockItem(db, productID, variantID); err != nil {
+		return nil, err
+	}
+
+	var levels []domain.StockLevel
+	err := db.Scopes(stockItem(productID, variantID)).
+		Joins("JOIN warehouses ON warehouses.id = stock_levels.warehouse_id").
+		Order("warehouses.priority, warehouses.id").
+		Find(&levels).Error
+	return levels, err
+}
+
+// lockItem locks the product or variant row FOR UPDATE and returns its total
+// stock
+func lockItem(tx *gorm.DB, productID uint, variantID *uint) (int, error) {
+	var stock int
+	err := tx.Model(itemModel(variantID)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", itemID(productID, variantID)).
+		Select("stock").
+		Take(&stock).Error
+	return stock, err
+}
+
+// itemModel is the model whose Stock column holds the total stock: the
+// variant if there is one, else the product
+func itemModel(variantID *uint) any {
+	if variantID != nil {
+		return &domain.ProductVariant{}
+	}
+	return &domain.Product{}
+}
+
+func itemID(productID uint, variantID *uint) uint {
+	if variantID != nil {
+		return *variantID
+	}
+	return productID
+}
+
+// stockItem selects the stock levels of a product's own stock, or of one
+// of its variants
+func stockItem(productID uint, variantID *uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("stock_levels.product_id = ?", productID)
+		if variantID != nil {
+			return db.Where("stock_levels.variant_id = ?", *variantID)
+		}
+		return db.Where("stock_levels.variant_id IS NULL")
+	}
+}
+
+func (r *inventoryRepository) FindMovements(ctx context.Context, filter repository.MovementFilter, limit, offset int) ([]domain.InventoryMovement, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.InventoryMovement{}).Where("product_id = ?", filter.ProductID)
+	if filter.VariantID != nil {
+		query = query.Where("variant_id = ?", *filter.VariantID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movements []domain.InventoryMovement
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&movements).Error
+	return movements, total, err
+}
+
+// FindDrift compares every warehouse level with its ledger balance, and the
+// stock columns of live products and variants with the sum of their levels.
+// Stock of products with variants lives on the variants, so both are checked
+// independently.
+func (r *inventoryRepository) FindDrift(ctx context.Context) ([]repository.StockDrift, error) {
+	var drift []repository.StockDrift
+	err := r.db.WithContext(ctx).Raw(`
+		WITH ledger AS (
+			SELECT warehouse_id, product_id, variant_id, SUM(quantity) AS quantity
+			FROM inventory_movements
+			GROUP BY warehouse_id, product_id, variant_id
+		)
+		SELECT COALESCE(s.product_id, l.product_id) AS product_id,
+			COALESCE(s.variant_id, l.variant_id) AS variant_id,
+			COALESCE(s.warehouse_id, l.warehouse_id) AS warehouse_id,
+			COALESCE(s.quantity, 0) AS column_stock,
+			COALESCE(l.quantity, 0) AS expected_stock
+		FROM stock_levels s
+		FULL JOIN ledger l ON l.warehouse_id = s.warehouse_id
+			AND l.product_id = s.product_id
+			AND l.variant_id IS NOT DISTINCT FROM s.variant_id
+		WHERE COALESCE(s.quantity, 0) <> COALESCE(l.quantity, 0)
+		UNION ALL
+		SELECT p.id, NULL::bigint, NULL::bigint, p.stock, COALESCE(SUM(s.quantity), 0)
+		FROM products p
+		LEFT JOIN stock_levels s ON s.product_id = p.id AND s.variant_id IS NULL
+		WHERE p.deleted_at IS NULL
+		GROUP BY p.id, p.stock
+		HAVING p.stock <> COALESCE(SUM(s.quantity), 0)
+		UNION ALL
+		SELECT v.product_id, v.id, NULL::bigint, v.stock, COALESCE(SUM(s.quantity), 0)
+		FROM product_variants v
+		LEFT JOIN stock_levels s ON s.variant_id = v.id
+		WHERE v.deleted_at IS NULL
+		GROUP BY v.product_id, v.id, v.stock
+		HAVING v.stock <> COALESCE(SUM(s.quantity), 0)
+		ORDER BY product_id, variant_id NULLS FIRST, warehouse_id NULLS LAST`).
+		Scan(&drift).Error
+	return drift, err
+}
+
+func (r *inventoryRepository) OverwriteStock(ctx context.Context, drift repository.StockDrift) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockItem(tx, drift.ProductID, drift.VariantID); err != nil {
+			return err
+		}
+
+		if drift.WarehouseID != nil {
+			level := domain.StockLevel{WarehouseID: *drift.WarehouseID, ProductID: drift.ProductID, VariantID: drift.VariantID}
+			err := tx.Scopes(stockItem(drift.ProductID, drift.VariantID)).
+				Where("warehouse_id = ?", level.WarehouseID).
+				Take(&level).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			level.Quantity = drift.ExpectedStock
+			if err := tx.Save(&level).Error; err != nil {
+				return err
+			}
+		}
+
+		var total int
+		err := tx.Model(&domain.StockLevel{}).
+			Scopes(stockItem(drift.ProductID, drift.VariantID)).
+			Select("COALESCE(SUM(quantity), 0)").
+			Scan(&total).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(itemModel(drift.VariantID)).
+			Where("id = ?", itemID(drift.ProductID, drift.VariantID)).
+			Update("stock", total).Error
+	})
+}
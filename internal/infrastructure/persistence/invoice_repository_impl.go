@@ -0,0 +1,41 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceRepository creates a new instance of InvoiceRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewInvoiceRepository(db *gorm.DB) repository.InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) NextSequence(ctx context.Context, year int) (int, error) {
+	var sequence int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO invoice_sequences (year, last_number) VALUES (?, 1)
+		ON CONFLICT (year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`, year).
+		Scan(&sequence).Error
+	return sequence, err
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	return r.db.WithContext(ctx).Create(invoice).Error
+}
+
+func (r *invoiceRepository) FindByOrderID(ctx context.Context, orderID uint) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Take(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
@@ -0,0 +1,165 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new instance of JobRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewJobRepository(db *gorm.DB) repository.JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Create(ctx context.Context, job *domain.Job) (bool, error) {
+	// The only unique constraint a new job can hit is its unique key
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *jobRepository) FindByID(ctx context.Context, id uint) (*domain.Job, error) {
+	var job domain.Job
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) FindUnfinishedByUniqueKey(ctx context.Context, key string) (*domain.Job, error) {
+	var job domain.Job
+	err := r.db.WithContext(ctx).
+		Where("unique_key = ? AND finished_at IS NULL", key).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) FindAll(ctx context.Context, filter repository.JobFilter, limit, offset int) ([]domain.Job, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Job{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []domain.Job
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+	return jobs, total, err
+}
+
+func (r *jobRepository) LockByID(ctx context.Context, id uint) (*domain.Job, error) {
+	var job domain.Job
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) Claim(ctx context.Context, types []string, worker string, now time.Time, limit int) ([]domain.Job, error) {
+	db := r.db.WithContext(ctx)
+	due := db.Model(&domain.Job{}).
+		Select("id").
+		Where("status = ? AND run_at <= ? AND type IN ?", domain.JobStatusPending, now, types).
+		Order("run_at, id").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var jobs []domain.Job
+	err := db.Model(&jobs).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Updates(map[string]any{
+			"status":     domain.JobStatusRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"locked_at":  now,
+			"locked_by":  worker,
+			"updated_at": now,
+		}).Error
+	return jobs, err
+}
+
+func (r *jobRepository) Finish(ctx context.Context, job *domain.Job) (bool, error) {
+	// Every claim counts an attempt, so the attempt identifies the claim
+	result := r.db.WithContext(ctx).Model(&domain.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, domain.JobStatusRunning, job.Attempts).
+		Updates(jobState(job))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *jobRepository) Update(ctx context.Context, job *domain.Job) error {
+	state := jobState(job)
+	state["attempts"] = job.Attempts
+	return r.db.WithContext(ctx).Model(job).Updates(state).Error
+}
+
+func (r *jobRepository) ReleaseStale(ctx context.Context, lockedBefore time.Time, now time.Time) (int64, error) {
+	var released int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale := func() *gorm.DB {
+			return tx.Model(&domain.Job{}).Where("status = ? AND locked_at < ?", domain.JobStatusRunning, lockedBefore)
+		}
+		release := func(status domain.JobStatus, finishedAt *time.Time) map[string]any {
+			return map[string]any{
+				"status":      status,
+				"finished_at": finishedAt,
+				"run_at":      now,
+				"locked_at":   nil,
+				"locked_by":   "",
+				"last_error":  "worker stopped before finishing the job",
+				"updated_at":  now,
+			}
+		}
+
+		// Out of attempts first, so the rest can go back to the queue
+		result := stale().Where("attempts >= max_attempts").Updates(release(domain.JobStatusDead, &now))
+		if result.Error != nil {
+			return result.Error
+		}
+		released = result.RowsAffected
+
+		result = stale().Updates(release(domain.JobStatusPending, nil))
+		released += result.RowsAffected
+		return result.Error
+	})
+	return released, err
+}
+
+// jobState holds the columns that change as a job moves through the queue;
+// a map so that cleared fields are written too
+func jobState(job *domain.Job) map[string]any {
+	return map[string]any{
+		"status":      job.Status,
+		"run_at":      job.RunAt,
+		"locked_at":   job.LockedAt,
+		"locked_by":   job.LockedBy,
+		"last_error":  job.LastError,
+		"finished_at": job.FinishedAt,
+	}
+}
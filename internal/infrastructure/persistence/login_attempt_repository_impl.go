@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new instance of LoginAttemptRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewLoginAttemptRepository(db *gorm.DB) repository.LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Create(ctx context.Context, attempt *domain.LoginAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+// LockEmail takes a transaction-level advisory lock on a key derived from the
+// email, namespaced so it doesn't collide with other advisory locks
+func (r *loginAttemptRepository) LockEmail(ctx context.Context, email string) error {
+	h := fnv.New64a()
+	h.Write([]byte("login_attempts:" + email))
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", int64(h.Sum64())).Error
+}
+
+func (r *loginAttemptRepository) FindByEmailSince(ctx context.Context, email string, since time.Time) ([]domain.LoginAttempt, error) {
+	var attempts []domain.LoginAttempt
+	err := r.db.WithContext(ctx).
+		Where("email = ? AND created_at >= ?", email, since).
+		Order("created_at DESC, id DESC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *loginAttemptRepository) FindRecentByEmail(ctx context.Context, email string, limit int) ([]domain.LoginAttempt, error) {
+	var attempts []domain.LoginAttempt
+	err := r.db.WithContext(ctx).
+		Where("email = ?", email).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *loginAttemptRepository) FindByUser(ctx context.Context, userID uint, email string) ([]domain.LoginAttempt, error) {
+	var attempts []domain.LoginAttempt
+	err := r.db.WithContext(ctx).
+		Scopes(userAttempts(userID, email)).
+		Order("created_at DESC, id DESC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *loginAttemptRepository) DeleteByUser(ctx context.Context, userID uint, email string) error {
+	return r.db.WithContext(ctx).Scopes(userAttempts(userID, email)).Delete(&domain.LoginAttempt{}).Error
+}
+
+// userAttempts selects the attempts made as the user, and the attempts for
+// their email without a user, such as locked-out ones
+func userAttempts(userID uint, email string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? OR (user_id IS NULL AND email = ?)", userID, email)
+	}
+}
@@ -2,10 +2,13 @@ package persistence
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/example/clean-arch-template/internal/domain"
 	"github.com/example/clean-arch-template/internal/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type orderRepository struct {
@@ -27,8 +30,11 @@ func (r *orderRepository) FindByID(ctx context.Context, id uint) (*domain.Order,
 	var order domain.Order
 	err := r.db.WithContext(ctx).
 		Preload("Items").
-		Preload("Items.Product").
-		Preload("User").
+		Preload("Items.Allocations").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Variant", unscoped).
+		Preload("Discounts").
+		Preload("User", unscoped).
 		First(&order, id).Error
 	if err != nil {
 		return nil, err
@@ -36,17 +42,180 @@ func (r *orderRepository) FindByID(ctx context.Context, id uint) (*domain.Order,
 	return &order, nil
 }
 
+// FindByUserID is read-only and served by a read replica when available
 func (r *orderRepository) FindByUserID(ctx context.Context, userID uint) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.WithContext(ctx).
+	err := readReplica(r.db.WithContext(ctx)).
 		Preload("Items").
-		Preload("Items.Product").
+		Preload("Items.Allocations").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Variant", unscoped).
+		Preload("Discounts").
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Find(&orders).Error
 	return orders, err
 }
 
+// unscoped lets order history resolve products, variants and users that have since
+// been soft-deleted
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
 func (r *orderRepository) Update(ctx context.Context, order *domain.Order) error {
 	return r.db.WithContext(ctx).Save(order).Error
 }
+
+func (r *orderRepository) LockByID(ctx context.Context, id uint) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items").
+		Preload("Items.Allocations").
+		First(&order, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) UpdateStatus(ctx context.Context, id uint, status domain.OrderStatus) error {
+	return r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+func (r *orderRepository) FindExpiredPendingIDs(ctx context.Context, cutoff time.Time, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("status = ? AND created_at < ? AND id > ?", domain.OrderStatusPending, cutoff, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM shipments WHERE shipments.order_id = orders.id)").
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Search is read-only and served by a read replica when available. Items and
+// users are loaded with one query each, whatever the page size.
+func (r *orderRepository) Search(ctx context.Context, filter repository.OrderFilter, limit, offset int) ([]domain.Order, int64, error) {
+	query := r.search(ctx, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []domain.Order
+	err := query.
+		Preload("Items").
+		Preload("User", unscoped).
+		Order(orderSort(filter)).
+		Limit(limit).
+		Offset(offset).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+// SearchInBatches is read-only and served by a read replica when available.
+// Batches continue after the sort key of the previous one rather than at an
+// offset, so orders placed meanwhile don't shift or repeat rows.
+func (r *orderRepository) SearchInBatches(ctx context.Context, filter repository.OrderFilter, batchSize int, fn func(orders []domain.Order) error) error {
+	column, direction := orderSortColumn(filter)
+	after := ">"
+	if direction == "DESC" {
+		after = "<"
+	}
+
+	var last *domain.Order
+	for {
+		query := r.search(ctx, filter)
+		if last != nil {
+			switch column {
+			case "id":
+				query = query.Where("id "+after+" ?", last.ID)
+			case "total_amount":
+				query = query.Where("(total_amount, id) "+after+" (?, ?)", last.TotalAmount, last.ID)
+			default:
+				query = query.Where("(created_at, id) "+after+" (?, ?)", last.CreatedAt, last.ID)
+			}
+		}
+
+		var orders []domain.Order
+		err := query.
+			Preload("Items").
+			Preload("User", unscoped).
+			Order(orderSort(filter)).
+			Limit(batchSize).
+			Find(&orders).Error
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+		if err := fn(orders); err != nil {
+			return err
+		}
+		if len(orders) < batchSize {
+			return nil
+		}
+		last = &orders[len(orders)-1]
+	}
+}
+
+// search selects the orders matching filter. User emails and products are
+// matched in subqueries, so every order appears once.
+func (r *orderRepository) search(ctx context.Context, filter repository.OrderFilter) *gorm.DB {
+	query := readReplica(r.db.WithContext(ctx)).Model(&domain.Order{})
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserEmail != "" {
+		query = query.Where("user_id IN (SELECT id FROM users WHERE LOWER(email) = LOWER(?))", filter.UserEmail)
+	}
+	if filter.ProductID != nil {
+		query = query.Where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.product_id = ?)", *filter.ProductID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.MinTotal != nil {
+		query = query.Where("total_amount >= ?", *filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		query = query.Where("total_amount <= ?", *filter.MaxTotal)
+	}
+	return query
+}
+
+// orderSortColumn returns the column and direction of the filter's sort.
+// Only known columns make it into the query.
+func orderSortColumn(filter repository.OrderFilter) (column, direction string) {
+	switch filter.SortBy {
+	case repository.OrderSortTotalAmount, repository.OrderSortID:
+		column = string(filter.SortBy)
+	default:
+		column = string(repository.OrderSortCreatedAt)
+	}
+	direction = "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+	return column, direction
+}
+
+func orderSort(filter repository.OrderFilter) string {
+	column, direction := orderSortColumn(filter)
+	if column == "id" {
+		return "id " + direction
+	}
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
@@ -45,6 +45,18 @@ func (r *paymentRepository) FindByOrderID(ctx context.Context, orderID uint) (*d
 	return &payment, nil
 }
 
+func (r *paymentRepository) FindByOrderIDs(ctx context.Context, orderIDs []uint) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	if len(orderIDs) == 0 {
+		return payments, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("order_id IN ?", orderIDs).
+		Order("created_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
 func (r *paymentRepository) Update(ctx context.Context, payment *domain.Payment) error {
 	return r.db.WithContext(ctx).Save(payment).Error
 }
@@ -0,0 +1,106 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type taxRateRepository struct {
+	db *gorm.DB
+}
+
+// NewTaxRateRepository creates a new instance of TaxRateRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewTaxRateRepository(db *gorm.DB) repository.TaxRateRepository {
+	return &taxRateRepository{db: db}
+}
+
+func (r *taxRateRepository) Create(ctx context.Context, rate *domain.TaxRate) error {
+	return r.db.WithContext(ctx).Create(rate).Error
+}
+
+func (r *taxRateRepository) FindByID(ctx context.Context, id uint) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+	if err := r.db.WithContext(ctx).First(&rate, id).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *taxRateRepository) FindAll(ctx context.Context) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	err := readReplica(r.db.WithContext(ctx)).Order("region, category_id NULLS FIRST").Find(&rates).Error
+	return rates, err
+}
+
+func (r *taxRateRepository) FindForRegion(ctx context.Context, region string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	err := r.db.WithContext(ctx).Where("region IN ?", regionCandidates(region)).Find(&rates).Error
+	return rates, err
+}
+
+func (r *taxRateRepository) Update(ctx context.Context, rate *domain.TaxRate) error {
+	return updateVersioned(r.db.WithContext(ctx), rate, &rate.Version)
+}
+
+func (r *taxRateRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &domain.TaxRate{}, id, version)
+}
+
+type shippingRateRepository struct {
+	db *gorm.DB
+}
+
+// NewShippingRateRepository creates a new instance of ShippingRateRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewShippingRateRepository(db *gorm.DB) repository.ShippingRateRepository {
+	return &shippingRateRepository{db: db}
+}
+
+func (r *shippingRateRepository) Create(ctx context.Context, rate *domain.ShippingRate) error {
+	return r.db.WithContext(ctx).Create(rate).Error
+}
+
+func (r *shippingRateRepository) FindByID(ctx context.Context, id uint) (*domain.ShippingRate, error) {
+	var rate domain.ShippingRate
+	if err := r.db.WithContext(ctx).First(&rate, id).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *shippingRateRepository) FindAll(ctx context.Context) ([]domain.ShippingRate, error) {
+	var rates []domain.ShippingRate
+	err := readReplica(r.db.WithContext(ctx)).Order("region").Find(&rates).Error
+	return rates, err
+}
+
+func (r *shippingRateRepository) FindForRegion(ctx context.Context, region string) ([]domain.ShippingRate, error) {
+	var rates []domain.ShippingRate
+	err := r.db.WithContext(ctx).Where("region IN ?", regionCandidates(region)).Find(&rates).Error
+	return rates, err
+}
+
+func (r *shippingRateRepository) Update(ctx context.Context, rate *domain.ShippingRate) error {
+	return updateVersioned(r.db.WithContext(ctx), rate, &rate.Version)
+}
+
+func (r *shippingRateRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &domain.ShippingRate{}, id, version)
+}
+
+// regionCandidates returns the regions whose rates may apply to region
+func regionCandidates(region string) []string {
+	candidates := []string{""}
+	if region == "" {
+		return candidates
+	}
+	if country, _, ok := strings.Cut(region, "-"); ok {
+		candidates = append(candidates, country)
+	}
+	return append(candidates, region)
+}
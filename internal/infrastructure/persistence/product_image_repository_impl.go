@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+// NewProductImageRepository creates a new instance of ProductImageRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewProductImageRepository(db *gorm.DB) repository.ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+func (r *productImageRepository) Create(ctx context.Context, image *domain.ProductImage) error {
+	return r.db.WithContext(ctx).Create(image).Error
+}
+
+func (r *productImageRepository) FindByID(ctx context.Context, id uint) (*domain.ProductImage, error) {
+	var image domain.ProductImage
+	err := r.db.WithContext(ctx).First(&image, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *productImageRepository) FindByProductID(ctx context.Context, productID uint) ([]domain.ProductImage, error) {
+	var images []domain.ProductImage
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("position, id").
+		Find(&images).Error
+	return images, err
+}
+
+func (r *productImageRepository) Update(ctx context.Context, image *domain.ProductImage) error {
+	return r.db.WithContext(ctx).Save(image).Error
+}
+
+func (r *productImageRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.ProductImage{}, id).Error
+}
+
+func (r *productImageRepository) Reorder(ctx context.Context, productID uint, imageIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for position, id := range imageIDs {
+			err := tx.Model(&domain.ProductImage{}).
+				Where("id = ? AND product_id = ?", id, productID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *productImageRepository) NextPosition(ctx context.Context, productID uint) (int, error) {
+	var position int
+	err := r.db.WithContext(ctx).
+		Model(&domain.ProductImage{}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Scan(&position).Error
+	return position, err
+}
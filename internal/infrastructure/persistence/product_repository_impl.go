@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"context"
+	"errors"
 
 	"github.com/example/clean-arch-template/internal/domain"
 	"github.com/example/clean-arch-template/internal/repository"
@@ -19,50 +20,156 @@ func NewProductRepository(db *gorm.DB) repository.ProductRepository {
 	return &productRepository{db: db}
 }
 
+// Create inserts the product row only; tags are linked with ReplaceTags and
+// variants are managed through ProductVariantRepository. The product starts
+// without stock, which is added through the inventory ledger.
 func (r *productRepository) Create(ctx context.Context, product *domain.Product) error {
-	return r.db.WithContext(ctx).Create(product).Error
+	product.Stock = 0
+	return r.db.WithContext(ctx).Omit(clause.Associations, "Stock").Create(product).Error
 }
 
 func (r *productRepository) FindByID(ctx context.Context, id uint) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.WithContext(ctx).First(&product, id).Error
+	err := withCatalog(r.db.WithContext(ctx)).First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
+// FindAll is read-only and served by a read replica when available
 func (r *productRepository) FindAll(ctx context.Context) ([]domain.Product, error) {
 	var products []domain.Product
-	err := r.db.WithContext(ctx).Find(&products).Error
+	err := withCatalog(readReplica(r.db.WithContext(ctx))).Find(&products).Error
 	return products, err
 }
 
+// FindByFilter is read-only and served by a read replica when available
+func (r *productRepository) FindByFilter(ctx context.Context, filter repository.ProductFilter) ([]domain.Product, error) {
+	var products []domain.Product
+	query := withCatalog(readReplica(r.db.WithContext(ctx)))
+
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ?", filter.CategoryIDs)
+	}
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", r.db.Table("product_tags").
+			Select("product_tags.product_id").
+			Joins("JOIN tags ON tags.id = product_tags.tag_id").
+			Where("tags.name = ?", filter.Tag))
+	}
+
+	err := query.Order("id").Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) FindBySKUs(ctx context.Context, skus []string) ([]domain.Product, error) {
+	var products []domain.Product
+	if len(skus) == 0 {
+		return products, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Where("sku IN ?", skus).
+		Find(&products).Error
+	return products, err
+}
+
+// FindInBatches is read-only and served by a read replica when available
+func (r *productRepository) FindInBatches(ctx context.Context, batchSize int, fn func(products []domain.Product) error) error {
+	var products []domain.Product
+	return readReplica(r.db.WithContext(ctx)).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		FindInBatches(&products, batchSize, func(_ *gorm.DB, _ int) error {
+			return fn(products)
+		}).Error
+}
+
+// Update saves the product row only, so stale associations loaded with the
+// product (e.g. variant stock) are never written back. Stock is left alone;
+// it only changes through the inventory ledger.
 func (r *productRepository) Update(ctx context.Context, product *domain.Product) error {
-	return r.db.WithContext(ctx).Save(product).Error
+	return updateVersioned(r.db.WithContext(ctx), product, &product.Version, clause.Associations, "Stock")
 }
 
-// UpdateStock updates product stock with pessimistic locking to prevent race conditions
-func (r *productRepository) UpdateStock(ctx context.Context, productID uint, quantity int) error {
+func (r *productRepository) UpdateFields(ctx context.Context, id, version uint, fields map[string]any) error {
+	return updateVersionedFields(r.db.WithContext(ctx), &domain.Product{}, id, version, fields)
+}
+
+func (r *productRepository) ReplaceTags(ctx context.Context, product *domain.Product, tags []domain.Tag) error {
+	if err := r.db.WithContext(ctx).Model(product).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+	product.Tags = tags
+	return nil
+}
+
+// withCatalog preloads the catalog data returned with product reads
+func withCatalog(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Category").
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		Preload("StockLevels", func(db *gorm.DB) *gorm.DB { return db.Order("variant_id NULLS FIRST, warehouse_id") })
+}
+
+func (r *productRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &domain.Product{}, id, version)
+}
+
+func (r *productRepository) FindDeletedByID(ctx context.Context, id uint) (*domain.Product, error) {
+	var product domain.Product
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *productRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Product{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+// Purge removes the product together with its tag links, variants, stock
+// levels and image records. Stored image files are left to the caller.
+func (r *productRepository) Purge(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var product domain.Product
-
-		// Use FOR UPDATE lock to prevent concurrent updates
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			First(&product, productID).Error; err != nil {
+		err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&product, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
 			return err
 		}
 
-		// Reduce stock
-		if err := product.ReduceStock(quantity); err != nil {
+		if err := tx.Exec("DELETE FROM product_tags WHERE product_id = ?", id).Error; err != nil {
 			return err
 		}
-
-		// Save updated stock
-		return tx.Save(&product).Error
+		if err := tx.Where("product_id = ?", id).Delete(&domain.ProductImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", id).Delete(&domain.StockLevel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("product_id = ?", id).Delete(&domain.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&product).Error
 	})
 }
 
-func (r *productRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&domain.Product{}, id).Error
+func (r *productRepository) HasOrderItems(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.OrderItem{}).
+		Where("product_id = ?", id).
+		Count(&count).Error
+	return count > 0, err
 }
@@ -0,0 +1,53 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type productVariantRepository struct {
+	db *gorm.DB
+}
+
+// NewProductVariantRepository creates a new instance of ProductVariantRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewProductVariantRepository(db *gorm.DB) repository.ProductVariantRepository {
+	return &productVariantRepository{db: db}
+}
+
+// Create inserts the variant without stock, which is added through the
+// inventory ledger
+func (r *productVariantRepository) Create(ctx context.Context, variant *domain.ProductVariant) error {
+	variant.Stock = 0
+	return r.db.WithContext(ctx).Omit("Stock").Create(variant).Error
+}
+
+func (r *productVariantRepository) FindByID(ctx context.Context, id uint) (*domain.ProductVariant, error) {
+	var variant domain.ProductVariant
+	err := r.db.WithContext(ctx).First(&variant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *productVariantRepository) FindByProductID(ctx context.Context, productID uint) ([]domain.ProductVariant, error) {
+	var variants []domain.ProductVariant
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("id").
+		Find(&variants).Error
+	return variants, err
+}
+
+// Update leaves stock alone; it only changes through the inventory ledger
+func (r *productVariantRepository) Update(ctx context.Context, variant *domain.ProductVariant) error {
+	return updateVersioned(r.db.WithContext(ctx), variant, &variant.Version, "Stock")
+}
+
+func (r *productVariantRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &domain.ProductVariant{}, id, version)
+}
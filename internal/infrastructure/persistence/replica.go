@@ -0,0 +1,14 @@
+package persistence
+
+import (
+	"github.com/example/clean-arch-template/internal/infrastructure/database"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// readReplica routes a read-only query to the read replicas when they are
+// configured. Inside a transaction, or without replicas, the query runs on
+// the connection it was given, so callers never lose read-your-writes.
+func readReplica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(database.ReplicaResolver), dbresolver.Read)
+}
@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type shipmentRepository struct {
+	db *gorm.DB
+}
+
+// NewShipmentRepository creates a new instance of ShipmentRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewShipmentRepository(db *gorm.DB) repository.ShipmentRepository {
+	return &shipmentRepository{db: db}
+}
+
+func (r *shipmentRepository) Create(ctx context.Context, shipment *domain.Shipment) error {
+	return r.db.WithContext(ctx).Create(shipment).Error
+}
+
+func (r *shipmentRepository) FindByID(ctx context.Context, id uint) (*domain.Shipment, error) {
+	var shipment domain.Shipment
+	err := r.withDetails(r.db.WithContext(ctx)).First(&shipment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (r *shipmentRepository) FindByOrderID(ctx context.Context, orderID uint) ([]domain.Shipment, error) {
+	var shipments []domain.Shipment
+	err := r.withDetails(r.db.WithContext(ctx)).
+		Where("order_id = ?", orderID).
+		Order("id").
+		Find(&shipments).Error
+	return shipments, err
+}
+
+func (r *shipmentRepository) withDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") })
+}
+
+func (r *shipmentRepository) UpdateStatus(ctx context.Context, shipment *domain.Shipment, event *domain.ShipmentEvent) error {
+	err := r.db.WithContext(ctx).Model(&domain.Shipment{}).
+		Where("id = ?", shipment.ID).
+		Updates(map[string]any{
+			"carrier":         shipment.Carrier,
+			"tracking_number": shipment.TrackingNumber,
+			"status":          shipment.Status,
+			"shipped_at":      shipment.ShippedAt,
+			"delivered_at":    shipment.DeliveredAt,
+		}).Error
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(event).Error
+}
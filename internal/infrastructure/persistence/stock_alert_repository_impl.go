@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type stockAlertRepository struct {
+	db *gorm.DB
+}
+
+// NewStockAlertRepository creates a new instance of StockAlertRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewStockAlertRepository(db *gorm.DB) repository.StockAlertRepository {
+	return &stockAlertRepository{db: db}
+}
+
+// Open relies on the partial unique indexes over open alerts
+func (r *stockAlertRepository) Open(ctx context.Context, alert *domain.StockAlert) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *stockAlertRepository) Resolve(ctx context.Context, productID uint, variantID *uint) error {
+	query := r.db.WithContext(ctx).Model(&domain.StockAlert{}).
+		Where("product_id = ? AND resolved_at IS NULL", productID)
+	if variantID != nil {
+		query = query.Where("variant_id = ?", *variantID)
+	} else {
+		query = query.Where("variant_id IS NULL")
+	}
+	return query.Update("resolved_at", time.Now()).Error
+}
+
+// FindLowStock is read-only and served by a read replica when available.
+// Products sold through variants are reported per variant.
+func (r *stockAlertRepository) FindLowStock(ctx context.Context) ([]repository.LowStockItem, error) {
+	var items []repository.LowStockItem
+	err := readReplica(r.db.WithContext(ctx)).Raw(`
+		SELECT p.id AS product_id, NULL::bigint AS variant_id, p.sku, p.name, p.stock,
+			p.reorder_threshold AS threshold, a.created_at AS alerted_at
+		FROM products p
+		LEFT JOIN stock_alerts a ON a.product_id = p.id AND a.variant_id IS NULL AND a.resolved_at IS NULL
+		WHERE p.deleted_at IS NULL
+			AND p.reorder_threshold > 0 AND p.stock <= p.reorder_threshold
+			AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+		UNION ALL
+		SELECT v.product_id, v.id, v.sku, p.name, v.stock, p.reorder_threshold, a.created_at
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id AND p.deleted_at IS NULL
+		LEFT JOIN stock_alerts a ON a.variant_id = v.id AND a.resolved_at IS NULL
+		WHERE v.deleted_at IS NULL
+			AND p.reorder_threshold > 0 AND v.stock <= p.reorder_threshold
+		ORDER BY stock, product_id, variant_id NULLS FIRST`).
+		Scan(&items).Error
+	return items, err
+}
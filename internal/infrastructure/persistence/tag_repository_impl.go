@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new instance of TagRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewTagRepository(db *gorm.DB) repository.TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) FindOrCreate(ctx context.Context, names []string) ([]domain.Tag, error) {
+	var tags []domain.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	newTags := make([]domain.Tag, 0, len(names))
+	for _, name := range names {
+		newTags = append(newTags, domain.Tag{Name: name})
+	}
+
+	// Insert missing tags; concurrent inserts of the same name are ignored
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&newTags).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+// FindAll is read-only and served by a read replica when available
+func (r *tagRepository) FindAll(ctx context.Context) ([]domain.Tag, error) {
+	var tags []domain.Tag
+	err := readReplica(r.db.WithContext(ctx)).Order("name").Find(&tags).Error
+	return tags, err
+}
@@ -33,7 +33,7 @@ func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, e
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("LOWER(email) = ?", domain.NormalizeEmail(email)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -47,3 +47,45 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&domain.User{}, id).Error
 }
+
+func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.User{}).
+		Where("LOWER(email) = ?", domain.NormalizeEmail(email)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userRepository) FindDeletedByID(ctx context.Context, id uint) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Model(&domain.User{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+func (r *userRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Delete(&domain.User{}, id).Error
+}
+
+func (r *userRepository) HasOrders(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.Order{}).
+		Where("user_id = ?", id).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"github.com/example/clean-arch-template/internal/domain"
+	"gorm.io/gorm"
+)
+
+// updateVersioned saves all columns of model except omit, but only if its
+// row still has the version model was read with, which it then increments.
+// A row that changed or disappeared meanwhile is left alone and
+// domain.ErrVersionConflict returned.
+func updateVersioned(db *gorm.DB, model any, version *uint, omit ...string) error {
+	read := *version
+	*version = read + 1
+
+	result := db.Model(model).
+		Where("version = ?", read).
+		Select("*").Omit(append(omit, "CreatedAt")...).
+		Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = domain.ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = read
+	}
+	return result.Error
+}
+
+// updateVersionedFields writes fields to the row of model with id if it
+// still has version, which it then increments; otherwise it returns
+// domain.ErrVersionConflict
+func updateVersionedFields(db *gorm.DB, model any, id, version uint, fields map[string]any) error {
+	columns := make(map[string]any, len(fields)+1)
+	for column, value := range fields {
+		columns[column] = value
+	}
+	columns["version"] = gorm.Expr("version + 1")
+
+	result := db.Model(model).Where("id = ? AND version = ?", id, version).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+// deleteVersioned soft-deletes the row of model with id if it still has
+// version, or returns domain.ErrVersionConflict
+func deleteVersioned(db *gorm.DB, model any, id, version uint) error {
+	result := db.Where("version = ?", version).Delete(model, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
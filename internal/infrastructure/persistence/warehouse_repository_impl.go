@@ -0,0 +1,80 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type warehouseRepository struct {
+	db *gorm.DB
+}
+
+// NewWarehouseRepository creates a new instance of WarehouseRepository
+// Accepts *gorm.DB which can be either a regular connection or a transaction
+func NewWarehouseRepository(db *gorm.DB) repository.WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+func (r *warehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefault(tx, warehouse); err != nil {
+			return err
+		}
+		return tx.Create(warehouse).Error
+	})
+}
+
+func (r *warehouseRepository) FindByID(ctx context.Context, id uint) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	if err := r.db.WithContext(ctx).First(&warehouse, id).Error; err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r *warehouseRepository) FindAll(ctx context.Context) ([]domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	err := r.db.WithContext(ctx).Order("priority, id").Find(&warehouses).Error
+	return warehouses, err
+}
+
+func (r *warehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefault(tx, warehouse); err != nil {
+			return err
+		}
+		return updateVersioned(tx, warehouse, &warehouse.Version)
+	})
+}
+
+// clearDefault unsets the current default warehouse when warehouse takes
+// over that role, which makes a new version of it
+func clearDefault(tx *gorm.DB, warehouse *domain.Warehouse) error {
+	if !warehouse.IsDefault {
+		return nil
+	}
+	return tx.Model(&domain.Warehouse{}).
+		Where("is_default AND id <> ?", warehouse.ID).
+		Updates(map[string]any{"is_default": false, "version": gorm.Expr("version + 1")}).Error
+}
+
+func (r *warehouseRepository) Delete(ctx context.Context, id, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("warehouse_id = ?", id).Delete(&domain.StockLevel{}).Error; err != nil {
+			return err
+		}
+		return deleteVersioned(tx, &domain.Warehouse{}, id, version)
+	})
+}
+
+func (r *warehouseRepository) HasStock(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.StockLevel{}).
+		Where("warehouse_id = ? AND quantity <> 0", id).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+// fakeS3 is a local stand-in for an S3-compatible store. It keeps objects in
+// memory, serves the path-style requests s3Storage makes and doesn't check
+// signatures.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+}
+
+type fakeObject struct {
+	data         []byte
+	contentType  string
+	cacheControl string
+}
+
+func newFakeS3(buckets ...string) *fakeS3 {
+	f := &fakeS3{buckets: make(map[string]map[string]fakeObject)}
+	for _, b := range buckets {
+		f.buckets[b] = make(map[string]fakeObject)
+	}
+	return f
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objects, ok := f.buckets[bucket]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" {
+		if r.Method != http.MethodHead {
+			writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err == nil && strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			data, err = decodeAWSChunked(data)
+		}
+		if err != nil {
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		objects[key] = fakeObject{
+			data:         data,
+			contentType:  r.Header.Get("Content-Type"),
+			cacheControl: r.Header.Get("Cache-Control"),
+		}
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(data))+`"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := objects[key]
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(obj.data))+`"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+	}
+}
+
+// decodeAWSChunked strips the chunk framing the client adds when it signs a
+// streaming upload over plain HTTP
+func decodeAWSChunked(body []byte) ([]byte, error) {
+	var out []byte
+	r := bufio.NewReader(bytes.NewReader(body))
+	for {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out, nil
+		}
+		chunk := make([]byte, size+2) // data followed by CRLF
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, err
+		}
+		out = append(out, chunk[:size]...)
+	}
+}
+
+func newTestS3Storage(t *testing.T, fake *fakeS3, bucket string) (gateway.BlobStorage, error) {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	return NewS3Storage(context.Background(), S3Config{
+		Endpoint:  strings.TrimPrefix(srv.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    bucket,
+		AccessKey: "test",
+		SecretKey: "test-secret",
+		PublicURL: "https://cdn.example.com/media/",
+	})
+}
+
+func TestS3StorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeS3("media")
+	store, err := newTestS3Storage(t, fake, "media")
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	key := "products/1/abc.png"
+	data := []byte("not really a png")
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	stored := fake.buckets["media"][key]
+	if !bytes.Equal(stored.data, data) {
+		t.Errorf("stored %q, want %q", stored.data, data)
+	}
+	if stored.contentType != "image/png" {
+		t.Errorf("stored content type = %q, want image/png", stored.contentType)
+	}
+	if !strings.Contains(stored.cacheControl, "immutable") {
+		t.Errorf("stored cache control = %q, want an immutable policy", stored.cacheControl)
+	}
+
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("read object: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get returned %q, want %q", got, data)
+	}
+
+	if url := store.URL(key); url != "https://cdn.example.com/media/"+key {
+		t.Errorf("URL = %q", url)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, gateway.ErrBlobNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrBlobNotFound", err)
+	}
+}
+
+func TestNewS3StorageRequiresBucket(t *testing.T) {
+	if _, err := newTestS3Storage(t, newFakeS3("other"), "media"); err == nil {
+		t.Fatal("NewS3Storage succeeded without the bucket")
+	}
+}
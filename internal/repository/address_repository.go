@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// AddressRepository defines the interface for address book persistence
+type AddressRepository interface {
+	Create(ctx context.Context, address *domain.Address) error
+	// FindByID returns the address only if it belongs to the user
+	FindByID(ctx context.Context, userID, id uint) (*domain.Address, error)
+	FindByUserID(ctx context.Context, userID uint) ([]domain.Address, error)
+	FindDefault(ctx context.Context, userID uint) (*domain.Address, error)
+	Update(ctx context.Context, address *domain.Address) error
+	// ClearDefault unmarks the user's default address, if any
+	ClearDefault(ctx context.Context, userID uint) error
+	Delete(ctx context.Context, userID, id uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
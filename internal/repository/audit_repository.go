@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// AuditFilter selects audit log entries; zero values match everything. From
+// is inclusive, To exclusive.
+type AuditFilter struct {
+	EntityType string
+	EntityID   uint
+	ActorID    *uint
+	From       time.Time
+	To         time.Time
+}
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	Create(ctx context.Context, log *domain.AuditLog) error
+	// FindAll returns a page of entries, newest first, with the total matching
+	FindAll(ctx context.Context, filter AuditFilter, limit, offset int) ([]domain.AuditLog, int64, error)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// CartRepository defines the interface for cart persistence
+type CartRepository interface {
+	Create(ctx context.Context, cart *domain.Cart) error
+	FindByID(ctx context.Context, id uint) (*domain.Cart, error)
+	FindByUserID(ctx context.Context, userID uint) (*domain.Cart, error)
+	FindByGuestTokenHash(ctx context.Context, hash string) (*domain.Cart, error)
+	// Touch extends the cart's life after activity
+	Touch(ctx context.Context, cartID uint, expiresAt time.Time) error
+	// SaveItem creates or updates a cart line
+	SaveItem(ctx context.Context, item *domain.CartItem) error
+	DeleteItem(ctx context.Context, cartID, itemID uint) error
+	// Clear removes all lines of the cart and returns how many there were
+	Clear(ctx context.Context, cartID uint) (int64, error)
+	// Delete removes the cart and its lines
+	Delete(ctx context.Context, cartID uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	// DeleteExpired removes carts that expired before now and returns how
+	// many there were
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// CategoryRepository defines the interface for category data persistence
+type CategoryRepository interface {
+	Create(ctx context.Context, category *domain.Category) error
+	FindByID(ctx context.Context, id uint) (*domain.Category, error)
+	FindAll(ctx context.Context) ([]domain.Category, error)
+	// FindSubtreeIDs returns the ID of the category and all its descendants
+	FindSubtreeIDs(ctx context.Context, id uint) ([]uint, error)
+	// Update only applies to the version the category was read with and
+	// increments it; otherwise it returns domain.ErrVersionConflict
+	Update(ctx context.Context, category *domain.Category) error
+	// Delete deletes the category if it is still at version, or returns
+	// domain.ErrVersionConflict
+	Delete(ctx context.Context, id, version uint) error
+	// IsInUse reports whether the category has children or products
+	IsInUse(ctx context.Context, id uint) (bool, error)
+}
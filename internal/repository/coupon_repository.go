@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// CouponRepository defines the interface for coupon persistence
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *domain.Coupon) error
+	FindByID(ctx context.Context, id uint) (*domain.Coupon, error)
+	FindAll(ctx context.Context) ([]domain.Coupon, error)
+	// Update never writes RedemptionCount, which only Redeem and
+	// ReleaseRedemptions change. It only applies to the version the coupon
+	// was read with and increments it; otherwise it returns
+	// domain.ErrVersionConflict.
+	Update(ctx context.Context, coupon *domain.Coupon) error
+	// Delete deletes the coupon if it is still at version, or returns
+	// domain.ErrVersionConflict
+	Delete(ctx context.Context, id, version uint) error
+	// LockByCodes returns the coupons with the given codes, locked until the
+	// transaction ends so their redemptions can be counted safely
+	LockByCodes(ctx context.Context, codes []string) ([]domain.Coupon, error)
+	// CountRedemptions returns how often the user redeemed the coupon
+	CountRedemptions(ctx context.Context, couponID, userID uint) (int64, error)
+	// HasRedemptions reports whether the coupon was ever redeemed
+	HasRedemptions(ctx context.Context, couponID uint) (bool, error)
+	// Redeem records a redemption and counts it against the coupon's global
+	// limit; it returns domain.ErrCouponExhausted if the limit is reached
+	Redeem(ctx context.Context, redemption *domain.CouponRedemption) error
+	// ReleaseRedemptions removes the redemptions of an order and gives them
+	// back to the coupons' limits
+	ReleaseRedemptions(ctx context.Context, orderID uint) error
+}
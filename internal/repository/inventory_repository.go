@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// StockDrift is a stock level that disagrees with what it is derived from.
+// With a WarehouseID it is the stock in that warehouse, expected to equal the
+// sum of its inventory movements; without one it is the Stock column of the
+// product or variant, expected to equal the sum over all warehouses.
+type StockDrift struct {
+	ProductID     uint  `json:"product_id"`
+	VariantID     *uint `json:"variant_id"`
+	WarehouseID   *uint `json:"warehouse_id"`
+	ColumnStock   int   `json:"column_stock"`
+	ExpectedStock int   `json:"expected_stock"`
+}
+
+// MovementFilter selects the inventory movements of a product
+type MovementFilter struct {
+	ProductID   uint
+	VariantID   *uint
+	WarehouseID *uint
+}
+
+// InventoryRepository defines the interface for the inventory ledger. It is
+// the only way stock changes. Movements without a WarehouseID are booked on
+// the default warehouse.
+type InventoryRepository interface {
+	// Apply locks the product or variant row, changes its stock in the
+	// movement's warehouse by movement.Quantity and appends the movement.
+	// It fails with domain.ErrInsufficientStock if the warehouse's stock
+	// would become negative.
+	Apply(ctx context.Context, movement *domain.InventoryMovement) error
+	// SetLevel is like Apply with the quantity chosen so that the total
+	// stock over all warehouses ends at level. Nothing is recorded if it
+	// is already at level.
+	SetLevel(ctx context.Context, movement *domain.InventoryMovement, level int) error
+	// Transfer applies both movements of a transfer atomically
+	Transfer(ctx context.Context, out, in *domain.InventoryMovement) error
+	// Append records a movement without touching any stock, to explain
+	// stock that changed outside the ledger
+	Append(ctx context.Context, movement *domain.InventoryMovement) error
+	// LockLevels locks the product or variant row like Apply and returns
+	// its stock per warehouse in allocation order. Use it inside a
+	// transaction so the levels can't change until it ends.
+	LockLevels(ctx context.Context, productID uint, variantID *uint) ([]domain.StockLevel, error)
+	// FindMovements returns movements newest first, and their total count
+	FindMovements(ctx context.Context, filter MovementFilter, limit, offset int) ([]domain.InventoryMovement, int64, error)
+	FindDrift(ctx context.Context) ([]StockDrift, error)
+	// OverwriteStock sets the drifted stock to its expected value. The
+	// product or variant total is recomputed from the current warehouse
+	// levels in the same transaction, also when a level was repaired, so it
+	// never disagrees with the levels afterwards.
+	OverwriteStock(ctx context.Context, drift StockDrift) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// InvoiceRepository defines the interface for invoice persistence
+type InvoiceRepository interface {
+	// NextSequence reserves the next invoice number of year. The year stays
+	// locked until the transaction ends, so a rollback leaves no gap.
+	NextSequence(ctx context.Context, year int) (int, error)
+	Create(ctx context.Context, invoice *domain.Invoice) error
+	FindByOrderID(ctx context.Context, orderID uint) (*domain.Invoice, error)
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// JobFilter selects jobs of the queue; zero values match everything
+type JobFilter struct {
+	Status domain.JobStatus
+	Type   string
+}
+
+// JobRepository defines the interface for the job queue
+type JobRepository interface {
+	// Create enqueues the job. It returns false, without an error, when an
+	// unfinished job with the same unique key exists.
+	Create(ctx context.Context, job *domain.Job) (bool, error)
+	FindByID(ctx context.Context, id uint) (*domain.Job, error)
+	// FindUnfinishedByUniqueKey returns the job holding a unique key
+	FindUnfinishedByUniqueKey(ctx context.Context, key string) (*domain.Job, error)
+	// FindAll returns a page of jobs, newest first, with the total matching
+	FindAll(ctx context.Context, filter JobFilter, limit, offset int) ([]domain.Job, int64, error)
+	// LockByID returns the job locked against workers and other changes
+	// until the transaction ends
+	LockByID(ctx context.Context, id uint) (*domain.Job, error)
+	// Claim marks up to limit pending jobs of the given types that are due
+	// as running by worker and counts an attempt for each. Jobs claimed by
+	// other workers are skipped rather than waited for.
+	Claim(ctx context.Context, types []string, worker string, now time.Time, limit int) ([]domain.Job, error)
+	// Finish saves the outcome of a claimed attempt. It returns false when
+	// the job was claimed again meanwhile, e.g. after its worker was
+	// presumed dead; the outcome is then dropped.
+	Finish(ctx context.Context, job *domain.Job) (bool, error)
+	// Update saves the job's status, schedule and attempts
+	Update(ctx context.Context, job *domain.Job) error
+	// ReleaseStale gives jobs running since before lockedBefore back to the
+	// queue, or lets them die if they are out of attempts
+	ReleaseStale(ctx context.Context, lockedBefore time.Time, now time.Time) (int64, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// LoginAttemptRepository defines the interface for login attempt persistence
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *domain.LoginAttempt) error
+	// LockEmail serializes login attempts for the email until the transaction
+	// ends, so concurrent attempts see each other's outcomes
+	LockEmail(ctx context.Context, email string) error
+	// FindByEmailSince returns attempts for the email made at or after since, newest first
+	FindByEmailSince(ctx context.Context, email string, since time.Time) ([]domain.LoginAttempt, error)
+	// FindRecentByEmail returns the latest attempts for the email, newest first;
+	// a negative limit returns all of them
+	FindRecentByEmail(ctx context.Context, email string, limit int) ([]domain.LoginAttempt, error)
+	// FindByUser returns the attempts of a user, newest first: those made as
+	// the user, and those for the user's email that no account claimed. An
+	// earlier owner of the email keeps theirs.
+	FindByUser(ctx context.Context, userID uint, email string) ([]domain.LoginAttempt, error)
+	// DeleteByUser deletes the attempts FindByUser returns
+	DeleteByUser(ctx context.Context, userID uint, email string) error
+}
@@ -2,14 +2,56 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/example/clean-arch-template/internal/domain"
 )
 
+// OrderSortField is a column order searches can be sorted by
+type OrderSortField string
+
+const (
+	OrderSortCreatedAt   OrderSortField = "created_at"
+	OrderSortTotalAmount OrderSortField = "total_amount"
+	OrderSortID          OrderSortField = "id"
+)
+
+// OrderFilter selects orders for admin searches; zero values match
+// everything. From is inclusive, To exclusive; MinTotal and MaxTotal are
+// both inclusive. UserEmail matches case-insensitively, ProductID orders
+// with an item of that product. Results are sorted by SortBy, creation time
+// when empty, descending unless Ascending is set, with ties broken by ID.
+type OrderFilter struct {
+	IDs       []uint
+	Status    domain.OrderStatus
+	UserEmail string
+	ProductID *uint
+	From      time.Time
+	To        time.Time
+	MinTotal  *float64
+	MaxTotal  *float64
+	SortBy    OrderSortField
+	Ascending bool
+}
+
 // OrderRepository defines the interface for order data persistence
 type OrderRepository interface {
 	Create(ctx context.Context, order *domain.Order) error
 	FindByID(ctx context.Context, id uint) (*domain.Order, error)
 	FindByUserID(ctx context.Context, userID uint) ([]domain.Order, error)
 	Update(ctx context.Context, order *domain.Order) error
+	// LockByID returns the order with its items and locks it until the
+	// transaction ends
+	LockByID(ctx context.Context, id uint) (*domain.Order, error)
+	UpdateStatus(ctx context.Context, id uint, status domain.OrderStatus) error
+	// FindExpiredPendingIDs returns, in ID order after afterID, up to limit
+	// pending orders created before cutoff that have not shipped
+	FindExpiredPendingIDs(ctx context.Context, cutoff time.Time, afterID uint, limit int) ([]uint, error)
+	// Search returns a page of the orders matching filter, with their items
+	// and users, and the total matching
+	Search(ctx context.Context, filter OrderFilter, limit, offset int) ([]domain.Order, int64, error)
+	// SearchInBatches calls fn with successive batches of all orders matching
+	// filter, in its order and loaded like Search, without loading all of
+	// them at once
+	SearchInBatches(ctx context.Context, filter OrderFilter, batchSize int, fn func(orders []domain.Order) error) error
 }
@@ -11,5 +11,6 @@ type PaymentRepository interface {
 	Create(ctx context.Context, payment *domain.Payment) error
 	FindByID(ctx context.Context, id uint) (*domain.Payment, error)
 	FindByOrderID(ctx context.Context, orderID uint) (*domain.Payment, error)
+	FindByOrderIDs(ctx context.Context, orderIDs []uint) ([]domain.Payment, error)
 	Update(ctx context.Context, payment *domain.Payment) error
 }
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// TaxRateRepository defines the interface for tax rate persistence
+type TaxRateRepository interface {
+	Create(ctx context.Context, rate *domain.TaxRate) error
+	FindByID(ctx context.Context, id uint) (*domain.TaxRate, error)
+	FindAll(ctx context.Context) ([]domain.TaxRate, error)
+	// FindForRegion returns the rates that may apply to region: its own,
+	// its country's and those without a region
+	FindForRegion(ctx context.Context, region string) ([]domain.TaxRate, error)
+	// Update only applies to the version the rate was read with and
+	// increments it; otherwise it returns domain.ErrVersionConflict
+	Update(ctx context.Context, rate *domain.TaxRate) error
+	// Delete deletes the rate if it is still at version, or returns
+	// domain.ErrVersionConflict
+	Delete(ctx context.Context, id, version uint) error
+}
+
+// ShippingRateRepository defines the interface for shipping rate persistence
+type ShippingRateRepository interface {
+	Create(ctx context.Context, rate *domain.ShippingRate) error
+	FindByID(ctx context.Context, id uint) (*domain.ShippingRate, error)
+	FindAll(ctx context.Context) ([]domain.ShippingRate, error)
+	// FindForRegion returns the rates that may apply to region: its own,
+	// its country's and the one without a region
+	FindForRegion(ctx context.Context, region string) ([]domain.ShippingRate, error)
+	// Update only applies to the version the rate was read with and
+	// increments it; otherwise it returns domain.ErrVersionConflict
+	Update(ctx context.Context, rate *domain.ShippingRate) error
+	// Delete deletes the rate if it is still at version, or returns
+	// domain.ErrVersionConflict
+	Delete(ctx context.Context, id, version uint) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// ProductImageRepository defines the interface for product image persistence
+type ProductImageRepository interface {
+	Create(ctx context.Context, image *domain.ProductImage) error
+	FindByID(ctx context.Context, id uint) (*domain.ProductImage, error)
+	// FindByProductID returns the images in display order
+	FindByProductID(ctx context.Context, productID uint) ([]domain.ProductImage, error)
+	Update(ctx context.Context, image *domain.ProductImage) error
+	Delete(ctx context.Context, id uint) error
+	// Reorder sets the position of each image to its index in imageIDs
+	Reorder(ctx context.Context, productID uint, imageIDs []uint) error
+	NextPosition(ctx context.Context, productID uint) (int, error)
+}
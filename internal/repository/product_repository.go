@@ -6,12 +6,42 @@ import (
 	"github.com/example/clean-arch-template/internal/domain"
 )
 
+// ProductFilter narrows down product listings; zero values match everything
+type ProductFilter struct {
+	CategoryIDs []uint
+	Tag         string
+}
+
 // ProductRepository defines the interface for product data persistence
 type ProductRepository interface {
 	Create(ctx context.Context, product *domain.Product) error
 	FindByID(ctx context.Context, id uint) (*domain.Product, error)
 	FindAll(ctx context.Context) ([]domain.Product, error)
+	FindByFilter(ctx context.Context, filter ProductFilter) ([]domain.Product, error)
+	// FindBySKUs returns the live products with any of the given SKUs and
+	// their tags
+	FindBySKUs(ctx context.Context, skus []string) ([]domain.Product, error)
+	// FindInBatches calls fn with successive batches of all live products in
+	// ID order, with their tags loaded, without loading the whole catalog
+	FindInBatches(ctx context.Context, batchSize int, fn func(products []domain.Product) error) error
+	// ReplaceTags sets the product's tags to exactly the given ones
+	ReplaceTags(ctx context.Context, product *domain.Product, tags []domain.Tag) error
+	// Create and Update never write Stock; use InventoryRepository. Update
+	// only applies to the version the product was read with and increments
+	// it; otherwise it returns domain.ErrVersionConflict.
 	Update(ctx context.Context, product *domain.Product) error
-	UpdateStock(ctx context.Context, productID uint, quantity int) error
-	Delete(ctx context.Context, id uint) error
+	// UpdateFields writes only the given columns, keyed by column name, and
+	// increments the version, if the product is still at version; otherwise
+	// it returns domain.ErrVersionConflict
+	UpdateFields(ctx context.Context, id, version uint, fields map[string]any) error
+	// Delete soft-deletes the product if it is still at version, or returns
+	// domain.ErrVersionConflict; it disappears from FindByID and FindAll
+	Delete(ctx context.Context, id, version uint) error
+	// FindDeletedByID returns a product only if it is soft-deleted
+	FindDeletedByID(ctx context.Context, id uint) (*domain.Product, error)
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a soft-deleted product
+	Purge(ctx context.Context, id uint) error
+	// HasOrderItems reports whether the product appears in any order
+	HasOrderItems(ctx context.Context, id uint) (bool, error)
 }
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// ProductVariantRepository defines the interface for product variant persistence
+type ProductVariantRepository interface {
+	Create(ctx context.Context, variant *domain.ProductVariant) error
+	FindByID(ctx context.Context, id uint) (*domain.ProductVariant, error)
+	FindByProductID(ctx context.Context, productID uint) ([]domain.ProductVariant, error)
+	// Create and Update never write Stock; use InventoryRepository. Update
+	// only applies to the version the variant was read with and increments
+	// it; otherwise it returns domain.ErrVersionConflict.
+	Update(ctx context.Context, variant *domain.ProductVariant) error
+	// Delete soft-deletes the variant if it is still at version, or returns
+	// domain.ErrVersionConflict; order history keeps resolving it
+	Delete(ctx context.Context, id, version uint) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// ShipmentRepository defines the interface for shipment persistence
+type ShipmentRepository interface {
+	// Create saves the shipment with its items and status history
+	Create(ctx context.Context, shipment *domain.Shipment) error
+	FindByID(ctx context.Context, id uint) (*domain.Shipment, error)
+	FindByOrderID(ctx context.Context, orderID uint) ([]domain.Shipment, error)
+	// UpdateStatus saves the shipment's carrier, status and dates and records the
+	// event in its history
+	UpdateStatus(ctx context.Context, shipment *domain.Shipment, event *domain.ShipmentEvent) error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// LowStockItem is a product or variant whose stock is at or below its
+// reorder threshold
+type LowStockItem struct {
+	ProductID uint       `json:"product_id"`
+	VariantID *uint      `json:"variant_id"`
+	SKU       string     `json:"sku"`
+	Name      string     `json:"name"`
+	Stock     int        `json:"stock"`
+	Threshold int        `json:"threshold"`
+	AlertedAt *time.Time `json:"alerted_at"` // When the open alert fired, if any
+}
+
+// StockAlertRepository defines the interface for low-stock alert persistence
+type StockAlertRepository interface {
+	// Open records the alert unless the item already has an open one, and
+	// reports whether it did. Concurrent callers can't both open an alert.
+	Open(ctx context.Context, alert *domain.StockAlert) (bool, error)
+	// Resolve closes the open alert of the item, if any
+	Resolve(ctx context.Context, productID uint, variantID *uint) error
+	FindLowStock(ctx context.Context) ([]LowStockItem, error)
+}
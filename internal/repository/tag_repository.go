@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// TagRepository defines the interface for tag data persistence
+type TagRepository interface {
+	// FindOrCreate returns the tags with the given names, creating missing ones
+	FindOrCreate(ctx context.Context, names []string) ([]domain.Tag, error)
+	FindAll(ctx context.Context) ([]domain.Tag, error)
+}
@@ -10,7 +10,19 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	FindByID(ctx context.Context, id uint) (*domain.User, error)
+	// FindByEmail matches the email case-insensitively
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
+	// Delete soft-deletes the user; it disappears from the Find methods
 	Delete(ctx context.Context, id uint) error
+	// EmailExists checks the email case-insensitively against all users,
+	// including deleted ones
+	EmailExists(ctx context.Context, email string) (bool, error)
+	// FindDeletedByID returns a user only if it is soft-deleted
+	FindDeletedByID(ctx context.Context, id uint) (*domain.User, error)
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a soft-deleted user
+	Purge(ctx context.Context, id uint) error
+	// HasOrders reports whether the user has placed any order
+	HasOrders(ctx context.Context, id uint) (bool, error)
 }
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// UserTokenRepository defines the interface for user token persistence
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *domain.UserToken) error
+	FindByHash(ctx context.Context, purpose domain.UserTokenPurpose, tokenHash string) (*domain.UserToken, error)
+	// MarkUsed consumes the token; it returns false if it was already used
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	// InvalidateForUser consumes all unused tokens of the user for a purpose
+	InvalidateForUser(ctx context.Context, userID uint, purpose domain.UserTokenPurpose) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// WarehouseRepository defines the interface for warehouse persistence
+type WarehouseRepository interface {
+	Create(ctx context.Context, warehouse *domain.Warehouse) error
+	FindByID(ctx context.Context, id uint) (*domain.Warehouse, error)
+	// FindAll returns warehouses in allocation order
+	FindAll(ctx context.Context) ([]domain.Warehouse, error)
+	// Create and Update make the warehouse the only default one when
+	// IsDefault is set. Update only applies to the version the warehouse was
+	// read with and increments it; otherwise it returns
+	// domain.ErrVersionConflict.
+	Update(ctx context.Context, warehouse *domain.Warehouse) error
+	// Delete removes the warehouse together with its (empty) stock levels if
+	// it is still at version, or returns domain.ErrVersionConflict
+	Delete(ctx context.Context, id, version uint) error
+	// HasStock reports whether any product is stocked in the warehouse
+	HasStock(ctx context.Context, id uint) (bool, error)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// WebhookRepository defines the interface for webhook subscription
+// persistence
+type WebhookRepository interface {
+	Create(ctx context.Context, subscription *domain.WebhookSubscription) error
+	FindByID(ctx context.Context, id uint) (*domain.WebhookSubscription, error)
+	FindAll(ctx context.Context) ([]domain.WebhookSubscription, error)
+	FindActive(ctx context.Context) ([]domain.WebhookSubscription, error)
+	// Update only applies to the version the subscription was read with and
+	// increments it; otherwise it returns domain.ErrVersionConflict
+	Update(ctx context.Context, subscription *domain.WebhookSubscription) error
+	// Delete removes the subscription with its deliveries if it is still at
+	// version, or returns domain.ErrVersionConflict
+	Delete(ctx context.Context, id, version uint) error
+}
+
+// WebhookDeliveryFilter selects the deliveries of a subscription
+type WebhookDeliveryFilter struct {
+	SubscriptionID uint
+	Status         domain.WebhookDeliveryStatus
+	EventType      string
+}
+
+// WebhookDeliveryRepository defines the interface for the webhook delivery
+// log
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// FindByID returns the delivery with its attempts
+	FindByID(ctx context.Context, id uint) (*domain.WebhookDelivery, error)
+	// FindAll returns a page of deliveries without their attempts, newest
+	// first, with the total matching
+	FindAll(ctx context.Context, filter WebhookDeliveryFilter, limit, offset int) ([]domain.WebhookDelivery, int64, error)
+	// UpdateStatus saves the delivery's status and delivery time
+	UpdateStatus(ctx context.Context, delivery *domain.WebhookDelivery) error
+	CreateAttempt(ctx context.Context, attempt *domain.WebhookDeliveryAttempt) error
+}
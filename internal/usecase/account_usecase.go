@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AccountExport is the data archive a user can download about themselves
+type AccountExport struct {
+	ExportedAt    time.Time             `json:"exported_at"`
+	Profile       *domain.User          `json:"profile"`
+	Addresses     []domain.Address      `json:"addresses"`
+	Orders        []domain.Order        `json:"orders"`
+	Payments      []domain.Payment      `json:"payments"`
+	LoginAttempts []domain.LoginAttempt `json:"login_attempts"`
+}
+
+// AccountUseCase handles operations spanning a user's whole account. Like
+// OrderUseCase it receives the DB instance for transaction management.
+type AccountUseCase struct {
+	db *gorm.DB
+}
+
+func NewAccountUseCase(db *gorm.DB) *AccountUseCase {
+	return &AccountUseCase{
+		db: db,
+	}
+}
+
+// DeleteAccount anonymizes and soft-deletes the user after confirming the
+// password. Orders and payments are kept for accounting; personal data,
+// tokens, sign-in history, the address book and the cart are removed in the same transaction.
+func (uc *AccountUseCase) DeleteAccount(ctx context.Context, userID uint, password string) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		userRepo := persistence.NewUserRepository(tx)
+		userTokenRepo := persistence.NewUserTokenRepository(tx)
+		loginAttemptRepo := persistence.NewLoginAttemptRepository(tx)
+		cartRepo := persistence.NewCartRepository(tx)
+		addressRepo := persistence.NewAddressRepository(tx)
+
+		user, err := userRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("user not found")
+			}
+			return err
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return ErrIncorrectPassword
+		}
+
+		if err := userTokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to delete user tokens: %w", err)
+		}
+		if err := loginAttemptRepo.DeleteByUser(ctx, user.ID, domain.NormalizeEmail(user.Email)); err != nil {
+			return fmt.Errorf("failed to delete login history: %w", err)
+		}
+		if err := cartRepo.DeleteByUserID(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to delete cart: %w", err)
+		}
+		if err := addressRepo.DeleteByUserID(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to delete addresses: %w", err)
+		}
+
+		// The audit entry starts from the user with personal data already
+		// removed, so the audit log doesn't keep what the deletion erases
+		verifiedAt := user.VerifiedAt
+		user.Anonymize(time.Now())
+		before := *user
+		before.VerifiedAt, before.AnonymizedAt = verifiedAt, nil
+
+		if err := userRepo.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+		if err := userRepo.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+
+		deleted, err := userRepo.FindDeletedByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionDelete, domain.AuditEntityUser, user.ID, &before, deleted)
+	})
+}
+
+// ExportData collects everything stored about a user
+func (uc *AccountUseCase) ExportData(ctx context.Context, userID uint) (*AccountExport, error) {
+	export := &AccountExport{ExportedAt: time.Now()}
+
+	// Read in one transaction so the archive is a consistent snapshot
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		userRepo := persistence.NewUserRepository(tx)
+		orderRepo := persistence.NewOrderRepository(tx)
+		paymentRepo := persistence.NewPaymentRepository(tx)
+		loginAttemptRepo := persistence.NewLoginAttemptRepository(tx)
+		addressRepo := persistence.NewAddressRepository(tx)
+
+		user, err := userRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("user not found")
+			}
+			return err
+		}
+		export.Profile = user
+
+		if export.Addresses, err = addressRepo.FindByUserID(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to load addresses: %w", err)
+		}
+
+		if export.Orders, err = orderRepo.FindByUserID(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to load orders: %w", err)
+		}
+
+		orderIDs := make([]uint, 0, len(export.Orders))
+		for _, order := range export.Orders {
+			orderIDs = append(orderIDs, order.ID)
+		}
+		if export.Payments, err = paymentRepo.FindByOrderIDs(ctx, orderIDs); err != nil {
+			return fmt.Errorf("failed to load payments: %w", err)
+		}
+
+		export.LoginAttempts, err = loginAttemptRepo.FindByUser(ctx, user.ID, domain.NormalizeEmail(user.Email))
+		if err != nil {
+			return fmt.Errorf("failed to load login history: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
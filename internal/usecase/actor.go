@@ -0,0 +1,30 @@
+package usecase
+
+import "context"
+
+type actorKey struct{}
+
+// ActorContextKey is the context key under which the Actor of a request is
+// stored. Delivery code sets it (e.g. as a fiber local) so that use cases
+// can attribute changes without every method taking an actor argument.
+var ActorContextKey = actorKey{}
+
+// Actor identifies who performs an operation
+type Actor struct {
+	UserID *uint
+	IP     string
+	// RequestID correlates the changes of one request, e.g. in the audit log
+	RequestID string
+}
+
+// WithActor returns a copy of ctx carrying the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, ActorContextKey, actor)
+}
+
+// ActorFromContext returns the actor of ctx; the zero Actor stands for the
+// system itself (background jobs, maintenance commands)
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(ActorContextKey).(Actor)
+	return actor
+}
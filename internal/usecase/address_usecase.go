@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+// ErrAddressNotFound is returned for addresses missing from the user's
+// address book
+var ErrAddressNotFound = errors.New("address not found")
+
+// AddressInput holds the editable fields of an address book entry
+type AddressInput struct {
+	Label     string
+	Address   domain.PostalAddress
+	IsDefault bool
+}
+
+type AddressUseCase struct {
+	db *gorm.DB
+}
+
+func NewAddressUseCase(db *gorm.DB) *AddressUseCase {
+	return &AddressUseCase{
+		db: db,
+	}
+}
+
+// ListAddresses retrieves the user's address book, default address first
+func (uc *AddressUseCase) ListAddresses(ctx context.Context, userID uint) ([]domain.Address, error) {
+	return persistence.NewAddressRepository(uc.db).FindByUserID(ctx, userID)
+}
+
+// CreateAddress adds an address to the user's address book. The first
+// address becomes the default.
+func (uc *AddressUseCase) CreateAddress(ctx context.Context, userID uint, input AddressInput) (*domain.Address, error) {
+	address := &domain.Address{UserID: userID}
+	applyAddressInput(address, input)
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		addressRepo := persistence.NewAddressRepository(tx)
+
+		if !address.IsDefault {
+			_, err := addressRepo.FindDefault(ctx, userID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				address.IsDefault = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if address.IsDefault {
+			if err := addressRepo.ClearDefault(ctx, userID); err != nil {
+				return err
+			}
+		}
+
+		return addressRepo.Create(ctx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// UpdateAddress updates an address of the user's address book. Orders
+// placed with it keep the address they were placed with.
+func (uc *AddressUseCase) UpdateAddress(ctx context.Context, userID, id uint, input AddressInput) (*domain.Address, error) {
+	var address *domain.Address
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		addressRepo := persistence.NewAddressRepository(tx)
+
+		var err error
+		address, err = addressRepo.FindByID(ctx, userID, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+
+		// The default only moves to another address, so a user who has
+		// addresses always has a default
+		wasDefault := address.IsDefault
+		applyAddressInput(address, input)
+		address.IsDefault = address.IsDefault || wasDefault
+		if err := address.Validate(); err != nil {
+			return err
+		}
+
+		if address.IsDefault && !wasDefault {
+			if err := addressRepo.ClearDefault(ctx, userID); err != nil {
+				return err
+			}
+		}
+
+		return addressRepo.Update(ctx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// DeleteAddress removes an address from the user's address book. When it
+// was the default, the oldest remaining address becomes the default.
+func (uc *AddressUseCase) DeleteAddress(ctx context.Context, userID, id uint) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		addressRepo := persistence.NewAddressRepository(tx)
+
+		address, err := addressRepo.FindByID(ctx, userID, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+
+		if err := addressRepo.Delete(ctx, userID, id); err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		remaining, err := addressRepo.FindByUserID(ctx, userID)
+		if err != nil || len(remaining) == 0 {
+			return err
+		}
+		// None of them is the default, so they are listed oldest first
+		oldest := &remaining[0]
+		oldest.IsDefault = true
+		return addressRepo.Update(ctx, oldest)
+	})
+}
+
+func applyAddressInput(address *domain.Address, input AddressInput) {
+	address.Label = strings.TrimSpace(input.Label)
+	address.PostalAddress = normalizePostalAddress(input.Address)
+	address.IsDefault = input.IsDefault
+}
+
+func normalizePostalAddress(address domain.PostalAddress) domain.PostalAddress {
+	return domain.PostalAddress{
+		RecipientName: strings.TrimSpace(address.RecipientName),
+		Line1:         strings.TrimSpace(address.Line1),
+		Line2:         strings.TrimSpace(address.Line2),
+		City:          strings.TrimSpace(address.City),
+		PostalCode:    strings.TrimSpace(address.PostalCode),
+		Region:        domain.NormalizeRegion(address.Region),
+		Phone:         strings.TrimSpace(address.Phone),
+	}
+}
+
+// orderAddress returns the address an order ships to: the given address
+// of the user's address book, or else their default address. nil means
+// the user has no address book.
+func orderAddress(ctx context.Context, tx *gorm.DB, userID uint, addressID *uint) (*domain.Address, error) {
+	addressRepo := persistence.NewAddressRepository(tx)
+	if addressID != nil {
+		address, err := addressRepo.FindByID(ctx, userID, *addressID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return address, err
+	}
+
+	address, err := addressRepo.FindDefault(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return address, err
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// AuditPage is a page of audit log entries, newest first
+type AuditPage struct {
+	Entries []domain.AuditLog `json:"entries"`
+	Total   int64             `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// AuditUseCase lets admins query the audit log
+type AuditUseCase struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditUseCase(auditRepo repository.AuditRepository) *AuditUseCase {
+	return &AuditUseCase{
+		auditRepo: auditRepo,
+	}
+}
+
+// ListAuditLogs retrieves a page of audit log entries, optionally filtered
+// by entity, actor and time range
+func (uc *AuditUseCase) ListAuditLogs(ctx context.Context, filter repository.AuditFilter, limit, offset int) (*AuditPage, error) {
+	if filter.EntityID != 0 && filter.EntityType == "" {
+		return nil, errors.New("entity ID requires an entity type")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errors.New("time range must end after it starts")
+	}
+
+	limit = max(1, min(limit, 500))
+	offset = max(0, offset)
+
+	entries, total, err := uc.auditRepo.FindAll(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditPage{Entries: entries, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// recordAudit logs a change of an entity in tx, attributed to the actor of
+// ctx, so that the entry commits or rolls back with the change. before and
+// after are the entity's states around the change, nil where it doesn't
+// exist. Updates that changed nothing aren't logged.
+func recordAudit(ctx context.Context, tx *gorm.DB, action domain.AuditAction, entityType string, entityID uint, before, after any) error {
+	changes, err := domain.DiffAudit(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s %d for the audit log: %w", entityType, entityID, err)
+	}
+	if action == domain.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	actor := ActorFromContext(ctx)
+	entry := &domain.AuditLog{
+		ActorID:    actor.UserID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  actor.RequestID,
+		IP:         actor.IP,
+	}
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	if err := persistence.NewAuditRepository(tx).Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
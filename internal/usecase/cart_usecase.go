@@ -0,0 +1,493 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/pkg/token"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCartEmpty is returned when checking out a cart without items
+	ErrCartEmpty = errors.New("cart is empty")
+	// ErrCartItemNotFound is returned for lines that are not in the cart
+	ErrCartItemNotFound = errors.New("cart item not found")
+	// ErrCartChanged is returned when the cart is modified while it is being
+	// checked out; no order is created
+	ErrCartChanged = errors.New("cart changed during checkout, please review it and try again")
+)
+
+// Cart line issues that block checkout
+const (
+	CartIssueUnavailable       = "unavailable"
+	CartIssueVariantRequired   = "variant_required"
+	CartIssueInsufficientStock = "insufficient_stock"
+)
+
+// CheckoutInput holds the order details a checkout adds to the cart
+type CheckoutInput struct {
+	PaymentMethod string
+	CouponCodes   []string
+	AddressID     *uint
+	Region        string
+}
+
+// CartOwner identifies whose cart an operation is on: a signed-in user, or
+// a guest presenting the token of their cart
+type CartOwner struct {
+	UserID     *uint
+	GuestToken string
+}
+
+// CartPolicy controls the lifetime of carts
+type CartPolicy struct {
+	// InactivityTTL is how long a cart lives after its last change
+	InactivityTTL time.Duration
+}
+
+// CartLine is a cart item priced and checked against the current catalog
+type CartLine struct {
+	ID        uint    `json:"id"`
+	ProductID uint    `json:"product_id"`
+	VariantID *uint   `json:"variant_id"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	LineTotal float64 `json:"line_total"`
+	Available int     `json:"available"`
+	// Issue is set when the line can't be ordered as it is
+	Issue string `json:"issue,omitempty"`
+}
+
+// CartView is a cart with live prices and stock. Valid reports whether it
+// can be checked out as it is.
+type CartView struct {
+	ID        uint       `json:"id"`
+	Items     []CartLine `json:"items"`
+	Subtotal  float64    `json:"subtotal"`
+	Valid     bool       `json:"valid"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// GuestToken is only returned when a guest cart is created; guests send
+	// it back to access their cart
+	GuestToken string `json:"guest_token,omitempty"`
+}
+
+// CartUseCase manages shopping carts. Like OrderUseCase it receives the DB
+// instance, since checkout and merging span several tables.
+type CartUseCase struct {
+	db     *gorm.DB
+	orders *OrderUseCase
+	policy CartPolicy
+}
+
+func NewCartUseCase(db *gorm.DB, orders *OrderUseCase, policy CartPolicy) *CartUseCase {
+	return &CartUseCase{
+		db:     db,
+		orders: orders,
+		policy: policy,
+	}
+}
+
+// GetCart returns the owner's cart; owners without a cart get an empty one
+func (uc *CartUseCase) GetCart(ctx context.Context, owner CartOwner) (*CartView, error) {
+	cart, err := uc.findCart(ctx, persistence.NewCartRepository(uc.db), owner)
+	if err != nil {
+		return nil, err
+	}
+	return uc.view(ctx, cart)
+}
+
+// AddItem adds quantity of a product or variant to the owner's cart,
+// creating the cart if needed. Lines for the same item are merged; the
+// merged quantity must be in stock.
+func (uc *CartUseCase) AddItem(ctx context.Context, owner CartOwner, productID uint, variantID *uint, quantity int) (*CartView, error) {
+	item := &domain.CartItem{ProductID: productID, VariantID: variantID, Quantity: quantity}
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	cartRepo := persistence.NewCartRepository(uc.db)
+	cart, err := uc.findCart(ctx, cartRepo, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart != nil {
+		if existing := cart.FindItem(productID, variantID); existing != nil {
+			existing.Quantity += quantity
+			item = existing
+		}
+	}
+	if err := uc.checkItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	var guestToken string
+	if cart == nil {
+		cart, guestToken, err = uc.createCart(ctx, cartRepo, owner)
+		if err != nil {
+			return nil, err
+		}
+	}
+	item.CartID = cart.ID
+	if err := cartRepo.SaveItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	view, err := uc.touch(ctx, cartRepo, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	view.GuestToken = guestToken
+	return view, nil
+}
+
+// UpdateItem sets the quantity of a cart line; a quantity of 0 removes it
+func (uc *CartUseCase) UpdateItem(ctx context.Context, owner CartOwner, itemID uint, quantity int) (*CartView, error) {
+	if quantity == 0 {
+		return uc.RemoveItem(ctx, owner, itemID)
+	}
+
+	cartRepo := persistence.NewCartRepository(uc.db)
+	item, err := uc.findItem(ctx, cartRepo, owner, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Quantity = quantity
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.checkItem(ctx, item); err != nil {
+		return nil, err
+	}
+	if err := cartRepo.SaveItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return uc.touch(ctx, cartRepo, item.CartID)
+}
+
+// RemoveItem removes a line from the owner's cart
+func (uc *CartUseCase) RemoveItem(ctx context.Context, owner CartOwner, itemID uint) (*CartView, error) {
+	cartRepo := persistence.NewCartRepository(uc.db)
+	item, err := uc.findItem(ctx, cartRepo, owner, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cartRepo.DeleteItem(ctx, item.CartID, item.ID); err != nil {
+		return nil, err
+	}
+
+	return uc.touch(ctx, cartRepo, item.CartID)
+}
+
+// ClearCart deletes the owner's cart
+func (uc *CartUseCase) ClearCart(ctx context.Context, owner CartOwner) error {
+	cartRepo := persistence.NewCartRepository(uc.db)
+	cart, err := uc.findCart(ctx, cartRepo, owner)
+	if err != nil || cart == nil {
+		return err
+	}
+	return cartRepo.Delete(ctx, cart.ID)
+}
+
+// MergeGuestCart moves the items of a guest cart into the user's cart, for
+// when a guest signs in. Quantities of items in both carts are added up;
+// stock is checked again at checkout. The guest cart is deleted.
+func (uc *CartUseCase) MergeGuestCart(ctx context.Context, userID uint, guestToken string) (*CartView, error) {
+	owner := CartOwner{UserID: &userID}
+
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		cartRepo := persistence.NewCartRepository(tx)
+
+		guestCart, err := uc.findCart(ctx, cartRepo, CartOwner{GuestToken: guestToken})
+		if err != nil || guestCart == nil {
+			return err
+		}
+
+		cart, err := uc.findCart(ctx, cartRepo, owner)
+		if err != nil {
+			return err
+		}
+		if cart == nil {
+			if cart, _, err = uc.createCart(ctx, cartRepo, owner); err != nil {
+				return err
+			}
+		}
+
+		for _, guestItem := range guestCart.Items {
+			item := cart.FindItem(guestItem.ProductID, guestItem.VariantID)
+			if item == nil {
+				cart.Items = append(cart.Items, domain.CartItem{
+					CartID:    cart.ID,
+					ProductID: guestItem.ProductID,
+					VariantID: guestItem.VariantID,
+				})
+				item = &cart.Items[len(cart.Items)-1]
+			}
+			item.Quantity += guestItem.Quantity
+			if err := cartRepo.SaveItem(ctx, item); err != nil {
+				return err
+			}
+		}
+
+		if err := cartRepo.Delete(ctx, guestCart.ID); err != nil {
+			return err
+		}
+		return cartRepo.Touch(ctx, cart.ID, time.Now().Add(uc.policy.InactivityTTL))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(ctx, owner)
+}
+
+// Checkout turns the user's cart into an order, at current prices, and
+// empties the cart in the same transaction
+func (uc *CartUseCase) Checkout(ctx context.Context, userID uint, input CheckoutInput) (*domain.Order, error) {
+	cart, err := uc.findCart(ctx, persistence.NewCartRepository(uc.db), CartOwner{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil || len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	view, err := uc.view(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if !view.Valid {
+		for _, line := range view.Items {
+			if line.Issue != "" {
+				return nil, fmt.Errorf("cart item %d can't be ordered: %s", line.ID, line.Issue)
+			}
+		}
+	}
+
+	req := CreateOrderRequest{
+		PaymentMethod: input.PaymentMethod,
+		CouponCodes:   input.CouponCodes,
+		AddressID:     input.AddressID,
+		Region:        input.Region,
+	}
+	for _, item := range cart.Items {
+		req.Items = append(req.Items, CreateOrderItemRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	return uc.orders.placeOrder(ctx, userID, req, func(tx *gorm.DB, order *domain.Order) error {
+		// The lines must be exactly the ones ordered; this also stops a
+		// concurrent checkout of the same cart
+		cleared, err := persistence.NewCartRepository(tx).Clear(ctx, cart.ID)
+		if err != nil {
+			return fmt.Errorf("failed to clear cart: %w", err)
+		}
+		if cleared != int64(len(cart.Items)) {
+			return ErrCartChanged
+		}
+		return nil
+	})
+}
+
+// PurgeExpired deletes carts that have been inactive for too long
+func (uc *CartUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	return persistence.NewCartRepository(uc.db).DeleteExpired(ctx, time.Now())
+}
+
+// findCart returns the owner's cart, or nil if there is none. Expired carts
+// are deleted on access and count as none.
+func (uc *CartUseCase) findCart(ctx context.Context, cartRepo repository.CartRepository, owner CartOwner) (*domain.Cart, error) {
+	var cart *domain.Cart
+	var err error
+	switch {
+	case owner.UserID != nil:
+		cart, err = cartRepo.FindByUserID(ctx, *owner.UserID)
+	case owner.GuestToken != "":
+		cart, err = cartRepo.FindByGuestTokenHash(ctx, token.HashOpaque(owner.GuestToken))
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if cart.IsExpired(time.Now()) {
+		if err := cartRepo.Delete(ctx, cart.ID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return cart, nil
+}
+
+// createCart creates an empty cart for owner. Guests get a new cart token,
+// returned raw; a token that matches no cart is never reused.
+func (uc *CartUseCase) createCart(ctx context.Context, cartRepo repository.CartRepository, owner CartOwner) (*domain.Cart, string, error) {
+	cart := &domain.Cart{
+		UserID:    owner.UserID,
+		ExpiresAt: time.Now().Add(uc.policy.InactivityTTL),
+	}
+
+	var guestToken string
+	if owner.UserID == nil {
+		raw, hash, err := token.NewOpaque()
+		if err != nil {
+			return nil, "", err
+		}
+		guestToken = raw
+		cart.GuestTokenHash = hash
+	}
+
+	if err := cartRepo.Create(ctx, cart); err != nil {
+		return nil, "", fmt.Errorf("failed to create cart: %w", err)
+	}
+	return cart, guestToken, nil
+}
+
+func (uc *CartUseCase) findItem(ctx context.Context, cartRepo repository.CartRepository, owner CartOwner, itemID uint) (*domain.CartItem, error) {
+	cart, err := uc.findCart(ctx, cartRepo, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart != nil {
+		for i := range cart.Items {
+			if cart.Items[i].ID == itemID {
+				return &cart.Items[i], nil
+			}
+		}
+	}
+	return nil, ErrCartItemNotFound
+}
+
+// checkItem verifies that the line can be ordered as it is
+func (uc *CartUseCase) checkItem(ctx context.Context, item *domain.CartItem) error {
+	product, err := persistence.NewProductRepository(uc.db).FindByID(ctx, item.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("product with ID %d not found", item.ProductID)
+		}
+		return err
+	}
+
+	line := priceLine(product, item)
+	switch line.Issue {
+	case CartIssueUnavailable:
+		return fmt.Errorf("variant with ID %d not found for product %s", *item.VariantID, product.Name)
+	case CartIssueVariantRequired:
+		return fmt.Errorf("product %s requires a variant", product.Name)
+	case CartIssueInsufficientStock:
+		return fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)",
+			line.Name, line.Available, item.Quantity)
+	}
+	return nil
+}
+
+// touch extends the cart's life after a change and returns the cart
+func (uc *CartUseCase) touch(ctx context.Context, cartRepo repository.CartRepository, cartID uint) (*CartView, error) {
+	if err := cartRepo.Touch(ctx, cartID, time.Now().Add(uc.policy.InactivityTTL)); err != nil {
+		return nil, err
+	}
+	cart, err := cartRepo.FindByID(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.view(ctx, cart)
+}
+
+// view prices a cart and checks it against the current catalog
+func (uc *CartUseCase) view(ctx context.Context, cart *domain.Cart) (*CartView, error) {
+	view := &CartView{Items: []CartLine{}}
+	if cart == nil {
+		return view, nil
+	}
+	view.ID = cart.ID
+	view.ExpiresAt = &cart.ExpiresAt
+	view.Valid = len(cart.Items) > 0
+
+	productRepo := persistence.NewProductRepository(uc.db)
+	products := make(map[uint]*domain.Product)
+	for i := range cart.Items {
+		item := &cart.Items[i]
+
+		product, ok := products[item.ProductID]
+		if !ok {
+			var err error
+			product, err = productRepo.FindByID(ctx, item.ProductID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			products[item.ProductID] = product
+		}
+
+		line := priceLine(product, item)
+		if line.Issue != "" {
+			view.Valid = false
+		} else {
+			view.Subtotal += line.LineTotal
+		}
+		view.Items = append(view.Items, line)
+	}
+	return view, nil
+}
+
+// priceLine prices a cart item at the current price of its product or
+// variant; product is nil for products that no longer exist
+func priceLine(product *domain.Product, item *domain.CartItem) CartLine {
+	line := CartLine{
+		ID:        item.ID,
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Quantity:  item.Quantity,
+	}
+	if product == nil {
+		line.Issue = CartIssueUnavailable
+		return line
+	}
+	line.Name = product.Name
+	line.SKU = product.SKU
+	line.UnitPrice = product.Price
+	line.Available = product.Stock
+
+	switch {
+	case item.VariantID != nil:
+		line.Issue = CartIssueUnavailable
+		for _, variant := range product.Variants {
+			if variant.ID == *item.VariantID {
+				line.Name = product.Name + " (" + variant.Label() + ")"
+				line.SKU = variant.SKU
+				line.UnitPrice = variant.Price
+				line.Available = variant.Stock
+				line.Issue = ""
+				break
+			}
+		}
+	case product.HasVariants():
+		line.Issue = CartIssueVariantRequired
+	}
+
+	if line.Issue == "" && line.Available < item.Quantity {
+		line.Issue = CartIssueInsufficientStock
+	}
+	if line.Issue == CartIssueUnavailable {
+		line.Available = 0
+	}
+	line.LineTotal = line.UnitPrice * float64(line.Quantity)
+	return line
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+type CategoryUseCase struct {
+	categoryRepo repository.CategoryRepository
+}
+
+func NewCategoryUseCase(categoryRepo repository.CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// CreateCategory creates a new category, optionally below a parent
+func (uc *CategoryUseCase) CreateCategory(ctx context.Context, name, slug, description string, parentID *uint) (*domain.Category, error) {
+	category := &domain.Category{
+		ParentID:    parentID,
+		Name:        name,
+		Slug:        slug,
+		Description: description,
+	}
+
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+	if parentID != nil {
+		if _, err := uc.GetCategory(ctx, *parentID); err != nil {
+			return nil, errors.New("parent category not found")
+		}
+	}
+
+	if err := uc.categoryRepo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetCategory retrieves a category with its parent and direct children
+func (uc *CategoryUseCase) GetCategory(ctx context.Context, id uint) (*domain.Category, error) {
+	category, err := uc.categoryRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// ListCategoryTree retrieves all categories nested under their parents
+func (uc *CategoryUseCase) ListCategoryTree(ctx context.Context) ([]domain.Category, error) {
+	categories, err := uc.categoryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.BuildCategoryTree(categories), nil
+}
+
+// UpdateCategory updates a category. A category can't be moved below itself
+// or one of its descendants. version, if set, must be the category's current
+// version.
+func (uc *CategoryUseCase) UpdateCategory(ctx context.Context, id uint, version *uint, name, slug, description string, parentID *uint) (*domain.Category, error) {
+	category, err := uc.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(category.Version, version); err != nil {
+		return nil, err
+	}
+
+	category.Name = name
+	category.Slug = slug
+	category.Description = description
+	category.ParentID = parentID
+	category.Parent = nil
+	category.Children = nil
+
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if _, err := uc.GetCategory(ctx, *parentID); err != nil {
+			return nil, errors.New("parent category not found")
+		}
+		subtree, err := uc.categoryRepo.FindSubtreeIDs(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(subtree, *parentID) {
+			return nil, errors.New("category cannot be moved below itself or its subcategories")
+		}
+	}
+
+	if err := uc.categoryRepo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCategory(ctx, id)
+}
+
+// DeleteCategory deletes a category without subcategories or products.
+// version, if set, must be the category's current version.
+func (uc *CategoryUseCase) DeleteCategory(ctx context.Context, id uint, version *uint) error {
+	category, err := uc.GetCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(category.Version, version); err != nil {
+		return err
+	}
+
+	inUse, err := uc.categoryRepo.IsInUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return errors.New("category has subcategories or products and cannot be deleted")
+	}
+
+	return uc.categoryRepo.Delete(ctx, id, category.Version)
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// CouponInput holds the editable fields of a coupon
+type CouponInput struct {
+	Code                  string
+	Description           string
+	Type                  domain.CouponType
+	Value                 float64
+	FreeProductID         *uint
+	FreeVariantID         *uint
+	FreeQuantity          int
+	MinOrderAmount        float64
+	StartsAt              *time.Time
+	EndsAt                *time.Time
+	MaxRedemptions        int
+	MaxRedemptionsPerUser int
+	Stackable             bool
+	Active                bool
+}
+
+type CouponUseCase struct {
+	couponRepo  repository.CouponRepository
+	productRepo repository.ProductRepository
+}
+
+func NewCouponUseCase(couponRepo repository.CouponRepository, productRepo repository.ProductRepository) *CouponUseCase {
+	return &CouponUseCase{
+		couponRepo:  couponRepo,
+		productRepo: productRepo,
+	}
+}
+
+// CreateCoupon creates a new coupon
+func (uc *CouponUseCase) CreateCoupon(ctx context.Context, input CouponInput) (*domain.Coupon, error) {
+	coupon := &domain.Coupon{}
+	applyCouponInput(coupon, input)
+
+	if err := uc.validate(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	if err := uc.couponRepo.Create(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// GetCoupon retrieves a coupon by ID
+func (uc *CouponUseCase) GetCoupon(ctx context.Context, id uint) (*domain.Coupon, error) {
+	coupon, err := uc.couponRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("coupon not found")
+		}
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// ListCoupons retrieves all coupons
+func (uc *CouponUseCase) ListCoupons(ctx context.Context) ([]domain.Coupon, error) {
+	return uc.couponRepo.FindAll(ctx)
+}
+
+// UpdateCoupon updates a coupon. Its redemptions so far keep counting
+// against the new limits. version, if set, must be the coupon's current
+// version.
+func (uc *CouponUseCase) UpdateCoupon(ctx context.Context, id uint, version *uint, input CouponInput) (*domain.Coupon, error) {
+	coupon, err := uc.GetCoupon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(coupon.Version, version); err != nil {
+		return nil, err
+	}
+
+	applyCouponInput(coupon, input)
+	if err := uc.validate(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	if err := uc.couponRepo.Update(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCoupon(ctx, id)
+}
+
+// DeleteCoupon deletes a coupon that was never redeemed; redeemed coupons
+// are part of order history and can only be deactivated. version, if set,
+// must be the coupon's current version.
+func (uc *CouponUseCase) DeleteCoupon(ctx context.Context, id uint, version *uint) error {
+	coupon, err := uc.GetCoupon(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(coupon.Version, version); err != nil {
+		return err
+	}
+
+	redeemed, err := uc.couponRepo.HasRedemptions(ctx, id)
+	if err != nil {
+		return err
+	}
+	if redeemed {
+		return errors.New("coupon has been redeemed; deactivate it instead")
+	}
+
+	return uc.couponRepo.Delete(ctx, id, coupon.Version)
+}
+
+func (uc *CouponUseCase) validate(ctx context.Context, coupon *domain.Coupon) error {
+	if err := coupon.Validate(); err != nil {
+		return err
+	}
+	if coupon.Type != domain.CouponFreeItem {
+		return nil
+	}
+
+	product, err := uc.productRepo.FindByID(ctx, *coupon.FreeProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("product with ID %d not found", *coupon.FreeProductID)
+		}
+		return err
+	}
+	if coupon.FreeVariantID == nil {
+		return nil
+	}
+	for _, variant := range product.Variants {
+		if variant.ID == *coupon.FreeVariantID {
+			return nil
+		}
+	}
+	return fmt.Errorf("variant with ID %d not found for product %s", *coupon.FreeVariantID, product.Name)
+}
+
+func applyCouponInput(coupon *domain.Coupon, input CouponInput) {
+	coupon.Code = domain.NormalizeCouponCode(input.Code)
+	coupon.Description = strings.TrimSpace(input.Description)
+	coupon.Type = input.Type
+	coupon.Value = input.Value
+	coupon.MinOrderAmount = input.MinOrderAmount
+	coupon.StartsAt = input.StartsAt
+	coupon.EndsAt = input.EndsAt
+	coupon.MaxRedemptions = input.MaxRedemptions
+	coupon.MaxRedemptionsPerUser = input.MaxRedemptionsPerUser
+	coupon.Stackable = input.Stackable
+	coupon.Active = input.Active
+
+	// Free item settings only apply to free item coupons
+	coupon.FreeProductID, coupon.FreeVariantID, coupon.FreeQuantity = nil, nil, 0
+	if input.Type == domain.CouponFreeItem {
+		coupon.FreeProductID = input.FreeProductID
+		coupon.FreeVariantID = input.FreeVariantID
+		coupon.FreeQuantity = input.FreeQuantity
+	}
+}
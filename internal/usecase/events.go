@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/gateway"
+	"gorm.io/gorm"
+)
+
+// newEvent describes a change made now
+func newEvent(eventType string, data any) gateway.Event {
+	return gateway.Event{Type: eventType, OccurredAt: time.Now(), Data: data}
+}
+
+// queueWebhooks queues events for webhook subscribers in tx, so that they
+// are delivered if and only if the change they describe commits
+func queueWebhooks(ctx context.Context, tx *gorm.DB, webhooks *WebhookUseCase, events []gateway.Event) error {
+	for _, event := range events {
+		if err := webhooks.Publish(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishEvents publishes committed changes; failures are only logged since
+// the changes stand either way
+func publishEvents(ctx context.Context, publisher gateway.EventPublisher, events []gateway.Event) {
+	for _, event := range events {
+		if err := publisher.Publish(ctx, event); err != nil {
+			slog.Error("failed to publish event", "type", event.Type, "error", err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ReconcileRepair chooses how Reconcile resolves drift between the ledger
+// and the stock columns
+type ReconcileRepair string
+
+const (
+	// ReconcileReportOnly only reports drift
+	ReconcileReportOnly ReconcileRepair = ""
+	// ReconcileRepairLedger trusts the columns and appends adjustments that
+	// explain the difference, e.g. for stock that predates the ledger
+	ReconcileRepairLedger ReconcileRepair = "ledger"
+	// ReconcileRepairColumn trusts the ledger and overwrites the columns
+	ReconcileRepairColumn ReconcileRepair = "column"
+)
+
+// StockHistory is a page of inventory movements, newest first
+type StockHistory struct {
+	Movements []domain.InventoryMovement `json:"movements"`
+	Total     int64                      `json:"total"`
+	Limit     int                        `json:"limit"`
+	Offset    int                        `json:"offset"`
+}
+
+// TransferInput moves stock of a product, or of one of its variants,
+// between warehouses
+type TransferInput struct {
+	ProductID       uint
+	VariantID       *uint
+	FromWarehouseID uint
+	ToWarehouseID   uint
+	Quantity        int
+	Reason          string
+}
+
+type InventoryUseCase struct {
+	productRepo   repository.ProductRepository
+	variantRepo   repository.ProductVariantRepository
+	warehouseRepo repository.WarehouseRepository
+	inventoryRepo repository.InventoryRepository
+	stockAlerts   *StockAlertUseCase
+}
+
+func NewInventoryUseCase(
+	productRepo repository.ProductRepository,
+	variantRepo repository.ProductVariantRepository,
+	warehouseRepo repository.WarehouseRepository,
+	inventoryRepo repository.InventoryRepository,
+	stockAlerts *StockAlertUseCase,
+) *InventoryUseCase {
+	return &InventoryUseCase{
+		productRepo:   productRepo,
+		variantRepo:   variantRepo,
+		warehouseRepo: warehouseRepo,
+		inventoryRepo: inventoryRepo,
+		stockAlerts:   stockAlerts,
+	}
+}
+
+// AdjustStock records a manual stock correction (e.g. after a stock take)
+// of a product, or of one of its variants, in a warehouse (by default the
+// default warehouse)
+func (uc *InventoryUseCase) AdjustStock(ctx context.Context, productID uint, variantID, warehouseID *uint, quantity int, reason string) (*domain.InventoryMovement, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("a reason is required for stock adjustments")
+	}
+	if err := uc.checkItem(ctx, productID, variantID); err != nil {
+		return nil, err
+	}
+	if warehouseID != nil {
+		if err := uc.checkWarehouse(ctx, *warehouseID); err != nil {
+			return nil, err
+		}
+	}
+
+	movement := newMovement(ctx, domain.MovementAdjustment, productID, variantID, reason)
+	movement.WarehouseID = warehouseID
+	movement.Quantity = quantity
+	if err := uc.inventoryRepo.Apply(ctx, movement); err != nil {
+		return nil, err
+	}
+
+	uc.stockAlerts.CheckProducts(ctx, productID)
+	return movement, nil
+}
+
+// TransferStock moves stock between two warehouses, recording a movement
+// out of the source and one into the destination
+func (uc *InventoryUseCase) TransferStock(ctx context.Context, input TransferInput) ([]domain.InventoryMovement, error) {
+	if input.Quantity <= 0 {
+		return nil, errors.New("transfer quantity must be greater than 0")
+	}
+	if input.FromWarehouseID == input.ToWarehouseID {
+		return nil, errors.New("source and destination warehouse must differ")
+	}
+	if err := uc.checkItem(ctx, input.ProductID, input.VariantID); err != nil {
+		return nil, err
+	}
+	for _, id := range []uint{input.FromWarehouseID, input.ToWarehouseID} {
+		if err := uc.checkWarehouse(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		reason = fmt.Sprintf("transfer from warehouse #%d to #%d", input.FromWarehouseID, input.ToWarehouseID)
+	}
+
+	out := newMovement(ctx, domain.MovementTransfer, input.ProductID, input.VariantID, reason)
+	out.WarehouseID = &input.FromWarehouseID
+	out.Quantity = -input.Quantity
+	in := newMovement(ctx, domain.MovementTransfer, input.ProductID, input.VariantID, reason)
+	in.WarehouseID = &input.ToWarehouseID
+	in.Quantity = input.Quantity
+
+	if err := uc.inventoryRepo.Transfer(ctx, out, in); err != nil {
+		return nil, err
+	}
+
+	return []domain.InventoryMovement{*out, *in}, nil
+}
+
+// GetStockHistory retrieves the inventory movements of a product, optionally
+// limited to one variant and/or warehouse
+func (uc *InventoryUseCase) GetStockHistory(ctx context.Context, filter repository.MovementFilter, limit, offset int) (*StockHistory, error) {
+	if err := uc.checkItem(ctx, filter.ProductID, filter.VariantID); err != nil {
+		return nil, err
+	}
+
+	limit = max(1, min(limit, 500))
+	offset = max(0, offset)
+
+	movements, total, err := uc.inventoryRepo.FindMovements(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StockHistory{Movements: movements, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// Reconcile finds warehouse stock that differs from the sum of its
+// movements, and product and variant totals that differ from the sum over
+// their warehouses, and optionally repairs them. Totals are always repaired
+// from the warehouse levels, including the totals of levels repaired from the
+// ledger. The drift found is returned either way.
+func (uc *InventoryUseCase) Reconcile(ctx context.Context, repair ReconcileRepair) ([]repository.StockDrift, error) {
+	drift, err := uc.inventoryRepo.FindDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var repairedTotals []uint
+	for _, d := range drift {
+		switch {
+		case repair == ReconcileReportOnly:
+		case d.WarehouseID == nil:
+			err = uc.inventoryRepo.OverwriteStock(ctx, d)
+			repairedTotals = append(repairedTotals, d.ProductID)
+		case repair == ReconcileRepairColumn:
+			err = uc.inventoryRepo.OverwriteStock(ctx, d)
+			repairedTotals = append(repairedTotals, d.ProductID)
+		case repair == ReconcileRepairLedger:
+			movement := newMovement(ctx, domain.MovementAdjustment, d.ProductID, d.VariantID,
+				fmt.Sprintf("reconciliation: stock changed outside the ledger (ledger %d, column %d)", d.ExpectedStock, d.ColumnStock))
+			movement.WarehouseID = d.WarehouseID
+			movement.Quantity = d.ColumnStock - d.ExpectedStock
+			movement.StockAfter = d.ColumnStock
+			err = uc.inventoryRepo.Append(ctx, movement)
+		}
+		if err != nil {
+			return drift, fmt.Errorf("failed to repair product %d: %w", d.ProductID, err)
+		}
+	}
+
+	uc.stockAlerts.CheckProducts(ctx, repairedTotals...)
+	return drift, nil
+}
+
+func (uc *InventoryUseCase) checkItem(ctx context.Context, productID uint, variantID *uint) error {
+	if _, err := uc.productRepo.FindByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("product not found")
+		}
+		return err
+	}
+	if variantID == nil {
+		return nil
+	}
+
+	variant, err := uc.variantRepo.FindByID(ctx, *variantID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err != nil || variant.ProductID != productID {
+		return errors.New("variant not found")
+	}
+	return nil
+}
+
+func (uc *InventoryUseCase) checkWarehouse(ctx context.Context, id uint) error {
+	if _, err := uc.warehouseRepo.FindByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("warehouse with ID %d not found", id)
+		}
+		return err
+	}
+	return nil
+}
+
+// newMovement starts a ledger entry attributed to the actor of ctx
+func newMovement(ctx context.Context, movementType domain.MovementType, productID uint, variantID *uint, reason string) *domain.InventoryMovement {
+	return &domain.InventoryMovement{
+		ProductID: productID,
+		VariantID: variantID,
+		Type:      movementType,
+		ActorID:   ActorFromContext(ctx).UserID,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/pkg/pdf"
+)
+
+// Invoice layout in points
+const (
+	invoiceMargin     = 50.0
+	invoiceFontSize   = 10.0
+	invoiceLineHeight = 14.0
+	// invoiceFooter is the space kept free at the bottom of every page
+	invoiceFooter = 80.0
+)
+
+// invoiceColumns are the right edges of the item table's amount columns;
+// the item name fills the space left of them
+var invoiceColumns = []struct {
+	title string
+	right float64
+}{
+	{"Qty", 260},
+	{"Unit price", 335},
+	{"Discount", 405},
+	{"Tax", 465},
+	{"Total", 545.28},
+}
+
+// renderInvoice writes the invoice of order as PDF. order must come with its
+// items and discounts.
+func renderInvoice(w io.Writer, issuer InvoiceIssuer, invoice *domain.Invoice, order *domain.Order) error {
+	doc := pdf.New(pdf.A4)
+	doc.SetTitle("Invoice " + invoice.Number)
+	r := &invoiceRenderer{doc: doc, currency: issuer.Currency}
+	r.newPage()
+
+	// Seller and invoice details
+	r.page.Text(invoiceMargin, r.y, pdf.HelveticaBold, 18, issuer.Name)
+	r.page.TextRight(r.right(), r.y, pdf.HelveticaBold, 18, "INVOICE")
+	r.y += 24
+	details := []string{
+		"Invoice number: " + invoice.Number,
+		"Date: " + invoice.IssuedAt.Format("2006-01-02"),
+		fmt.Sprintf("Order: #%d", order.ID),
+	}
+	// The address is printed one comma-separated part per line
+	var seller []string
+	for _, line := range strings.Split(issuer.Address, ",") {
+		if line = strings.TrimSpace(line); line != "" {
+			seller = append(seller, line)
+		}
+	}
+	if issuer.TaxID != "" {
+		seller = append(seller, "Tax ID: "+issuer.TaxID)
+	}
+	for i := 0; i < max(len(details), len(seller)); i++ {
+		if i < len(seller) {
+			r.page.Text(invoiceMargin, r.y, pdf.Helvetica, invoiceFontSize, seller[i])
+		}
+		if i < len(details) {
+			r.page.TextRight(r.right(), r.y, pdf.Helvetica, invoiceFontSize, details[i])
+		}
+		r.y += invoiceLineHeight
+	}
+	r.y += invoiceLineHeight
+
+	// Customer and delivery address
+	var billTo []string
+	for _, line := range []string{invoice.BillToName, invoice.BillToEmail} {
+		if line != "" {
+			billTo = append(billTo, line)
+		}
+	}
+	shipTo := postalAddressLines(order.ShippingAddress)
+	half := invoiceMargin + (r.right()-invoiceMargin)/2
+	r.page.Text(invoiceMargin, r.y, pdf.HelveticaBold, invoiceFontSize, "Bill to")
+	if len(shipTo) > 0 {
+		r.page.Text(half, r.y, pdf.HelveticaBold, invoiceFontSize, "Ship to")
+	}
+	r.y += invoiceLineHeight
+	for i := 0; i < max(len(billTo), len(shipTo)); i++ {
+		if i < len(billTo) {
+			r.page.Text(invoiceMargin, r.y, pdf.Helvetica, invoiceFontSize, billTo[i])
+		}
+		if i < len(shipTo) {
+			r.page.Text(half, r.y, pdf.Helvetica, invoiceFontSize, shipTo[i])
+		}
+		r.y += invoiceLineHeight
+	}
+	r.y += invoiceLineHeight
+
+	// Items
+	r.tableHeader()
+	for _, item := range order.Items {
+		r.ensureSpace(invoiceLineHeight, true)
+		name := item.ProductName
+		if item.SKU != "" {
+			name += " (" + item.SKU + ")"
+		}
+		r.page.Text(invoiceMargin+4, r.y, pdf.Helvetica, invoiceFontSize, fitText(name, invoiceColumns[0].right-40-invoiceMargin))
+		tax := fmt.Sprintf("%g%%", item.TaxRate)
+		if item.TaxInclusive {
+			tax += " incl."
+		}
+		cells := []string{
+			fmt.Sprintf("%d", item.Quantity),
+			r.amount(item.Price),
+			r.amount(-item.DiscountAmount),
+			tax,
+			r.amount(item.Total),
+		}
+		for i, cell := range cells {
+			r.page.TextRight(invoiceColumns[i].right, r.y, pdf.Helvetica, invoiceFontSize, cell)
+		}
+		r.y += invoiceLineHeight
+	}
+	r.page.Line(invoiceMargin, r.y-8, r.right(), r.y-8, 0.5)
+	r.y += invoiceLineHeight
+
+	// Totals
+	totals := [][2]string{{"Subtotal", r.amount(order.Subtotal)}}
+	for _, discount := range order.Discounts {
+		totals = append(totals, [2]string{"Discount " + discount.Code, r.amount(-discount.Amount)})
+	}
+	totals = append(totals,
+		[2]string{"Tax", r.amount(order.TaxAmount)},
+		[2]string{"Shipping", r.amount(order.ShippingAmount)},
+	)
+	r.ensureSpace(float64(len(totals)+3)*invoiceLineHeight, false)
+	for _, total := range totals {
+		r.page.Text(360, r.y, pdf.Helvetica, invoiceFontSize, total[0])
+		r.page.TextRight(r.right(), r.y, pdf.Helvetica, invoiceFontSize, total[1])
+		r.y += invoiceLineHeight
+	}
+	r.y += 4
+	r.page.Text(360, r.y, pdf.HelveticaBold, 12, "Total")
+	r.page.TextRight(r.right(), r.y, pdf.HelveticaBold, 12, r.amount(order.TotalAmount))
+	r.y += invoiceLineHeight
+
+	if hasInclusiveTax(order) {
+		r.y += invoiceLineHeight
+		r.page.Text(invoiceMargin, r.y, pdf.Helvetica, 8, "Tax marked \"incl.\" is included in the unit price and counted in the tax total.")
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+type invoiceRenderer struct {
+	doc      *pdf.Document
+	page     *pdf.Page
+	y        float64
+	currency string
+}
+
+func (r *invoiceRenderer) newPage() {
+	r.page = r.doc.AddPage()
+	r.y = invoiceMargin + 18
+}
+
+// right is the right margin of the page
+func (r *invoiceRenderer) right() float64 {
+	return r.doc.Size().Width - invoiceMargin
+}
+
+// ensureSpace starts a new page when height doesn't fit on the current one,
+// repeating the table header if header is set
+func (r *invoiceRenderer) ensureSpace(height float64, header bool) {
+	if r.y+height <= r.doc.Size().Height-invoiceFooter {
+		return
+	}
+	r.newPage()
+	if header {
+		r.tableHeader()
+	}
+}
+
+func (r *invoiceRenderer) tableHeader() {
+	r.page.FillRect(invoiceMargin, r.y-invoiceFontSize-3, r.right()-invoiceMargin, invoiceLineHeight+2, 0.9)
+	r.page.Text(invoiceMargin+4, r.y, pdf.HelveticaBold, invoiceFontSize, "Item")
+	for _, column := range invoiceColumns {
+		r.page.TextRight(column.right, r.y, pdf.HelveticaBold, invoiceFontSize, column.title)
+	}
+	r.y += invoiceLineHeight + 4
+}
+
+func (r *invoiceRenderer) amount(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	if s == "-0.00" {
+		s = "0.00"
+	}
+	if r.currency == "" {
+		return s
+	}
+	return r.currency + " " + s
+}
+
+// fitText shortens text with an ellipsis to fit width at the table's font
+func fitText(text string, width float64) string {
+	if pdf.TextWidth(pdf.Helvetica, invoiceFontSize, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(pdf.Helvetica, invoiceFontSize, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func postalAddressLines(a domain.PostalAddress) []string {
+	if a.IsZero() {
+		return nil
+	}
+	lines := []string{a.RecipientName, a.Line1}
+	if a.Line2 != "" {
+		lines = append(lines, a.Line2)
+	}
+	lines = append(lines, strings.TrimSpace(a.PostalCode+" "+a.City), a.Region)
+	if a.Phone != "" {
+		lines = append(lines, a.Phone)
+	}
+	return lines
+}
+
+func hasInclusiveTax(order *domain.Order) bool {
+	for _, item := range order.Items {
+		if item.TaxInclusive && item.TaxAmount > 0 {
+			return true
+		}
+	}
+	return false
+}
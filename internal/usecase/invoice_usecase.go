@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+// ErrInvoiceNotFound is returned for orders that have no invoice yet
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// jobPublishInvoice renders and stores the PDF of a newly issued invoice
+const jobPublishInvoice = "invoice.publish"
+
+type publishInvoicePayload struct {
+	OrderID uint `json:"order_id"`
+}
+
+// InvoiceIssuer holds the seller details printed on invoices
+type InvoiceIssuer struct {
+	Name    string
+	Address string
+	TaxID   string
+	// Currency is printed with every amount, e.g. "USD"
+	Currency string
+}
+
+// InvoiceUseCase issues invoices for paid orders and stores them as PDF.
+// Storage must not be publicly readable.
+type InvoiceUseCase struct {
+	db      *gorm.DB
+	storage gateway.BlobStorage
+	issuer  InvoiceIssuer
+	jobs    *JobQueue
+}
+
+// NewInvoiceUseCase creates the use case and registers its jobs with jobs
+func NewInvoiceUseCase(db *gorm.DB, storage gateway.BlobStorage, issuer InvoiceIssuer, jobs *JobQueue) *InvoiceUseCase {
+	uc := &InvoiceUseCase{
+		db:      db,
+		storage: storage,
+		issuer:  issuer,
+		jobs:    jobs,
+	}
+	HandleJob(jobs, jobPublishInvoice, uc.handlePublish)
+	return uc
+}
+
+// issue numbers the invoice of an order inside the transaction that marks
+// the order paid, so numbers are only used by committed invoices, and
+// queues its PDF in the same transaction. The customer's name and email
+// are kept on the invoice as they are now. Orders keep the invoice they
+// already have.
+func (uc *InvoiceUseCase) issue(ctx context.Context, tx *gorm.DB, order *domain.Order, now time.Time) (*domain.Invoice, error) {
+	invoiceRepo := persistence.NewInvoiceRepository(tx)
+
+	invoice, err := invoiceRepo.FindByOrderID(ctx, order.ID)
+	if err == nil {
+		return invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	customer, err := persistence.NewUserRepository(tx).FindByID(ctx, order.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load customer: %w", err)
+	}
+
+	issuedAt := now.UTC()
+	sequence, err := invoiceRepo.NextSequence(ctx, issuedAt.Year())
+	if err != nil {
+		return nil, fmt.Errorf("failed to number invoice: %w", err)
+	}
+
+	number := domain.InvoiceNumber(issuedAt.Year(), sequence)
+	invoice = &domain.Invoice{
+		OrderID:     order.ID,
+		Number:      number,
+		Year:        issuedAt.Year(),
+		Sequence:    sequence,
+		IssuedAt:    issuedAt,
+		BillToName:  customer.FullName,
+		BillToEmail: customer.Email,
+		StorageKey:  fmt.Sprintf("invoices/%d/%s.pdf", issuedAt.Year(), number),
+	}
+	if err := invoiceRepo.Create(ctx, invoice); err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	payload := publishInvoicePayload{OrderID: order.ID}
+	if _, err := uc.jobs.enqueue(ctx, tx, jobPublishInvoice, payload, JobOptions{}); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// handlePublish renders and stores an issued invoice. Until it succeeds the
+// PDF is rendered on download instead.
+func (uc *InvoiceUseCase) handlePublish(ctx context.Context, payload publishInvoicePayload) error {
+	invoice, err := persistence.NewInvoiceRepository(uc.db).FindByOrderID(ctx, payload.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %w", ErrJobPermanent, ErrInvoiceNotFound)
+		}
+		return err
+	}
+	_, err = uc.publish(ctx, invoice)
+	return err
+}
+
+func (uc *InvoiceUseCase) publish(ctx context.Context, invoice *domain.Invoice) ([]byte, error) {
+	order, err := persistence.NewOrderRepository(uc.db).FindByID(ctx, invoice.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderInvoice(&buf, uc.issuer, invoice, order); err != nil {
+		return nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+	data := buf.Bytes()
+
+	if err := uc.storage.Put(ctx, invoice.StorageKey, bytes.NewReader(data), int64(len(data)), "application/pdf"); err != nil {
+		return nil, fmt.Errorf("failed to store invoice: %w", err)
+	}
+	return data, nil
+}
+
+// GetInvoice returns the invoice of an order with its PDF. With userID set,
+// the order must belong to that user.
+func (uc *InvoiceUseCase) GetInvoice(ctx context.Context, orderID uint, userID *uint) (*domain.Invoice, []byte, error) {
+	order, err := persistence.NewOrderRepository(uc.db).FindByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrOrderNotFound
+		}
+		return nil, nil, err
+	}
+	if userID != nil && order.UserID != *userID {
+		return nil, nil, ErrOrderNotFound
+	}
+
+	invoice, err := persistence.NewInvoiceRepository(uc.db).FindByOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvoiceNotFound
+		}
+		return nil, nil, err
+	}
+
+	r, err := uc.storage.Get(ctx, invoice.StorageKey)
+	if errors.Is(err, gateway.ErrBlobNotFound) {
+		// Storing failed when the invoice was issued
+		data, err := uc.publish(ctx, invoice)
+		if err != nil {
+			return nil, nil, err
+		}
+		return invoice, data, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read invoice: %w", err)
+	}
+	return invoice, data, nil
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrJobPermanent marks a failure that retrying won't fix; handlers wrap
+	// it to send their job straight to dead
+	ErrJobPermanent = errors.New("permanent job failure")
+	// ErrUnknownJobType is returned when enqueuing a job no handler is
+	// registered for
+	ErrUnknownJobType = errors.New("unknown job type")
+)
+
+// JobPolicy controls how the job queue runs jobs
+type JobPolicy struct {
+	// Workers is how many jobs run at the same time
+	Workers      int
+	PollInterval time.Duration
+	// MaxAttempts applies to jobs enqueued without their own limit
+	MaxAttempts int
+	// BackoffBase is the delay before the first retry; it doubles with
+	// every further attempt up to BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Timeout bounds one attempt. Jobs running for twice as long are
+	// presumed lost with their worker.
+	Timeout time.Duration
+}
+
+// JobOptions controls when and how often a job runs. The zero value runs it
+// as soon as possible with the queue's default attempts.
+type JobOptions struct {
+	// RunAt schedules the job; Delay is added to it, or to now without it
+	RunAt time.Time
+	Delay time.Duration
+	// UniqueKey, if set, enqueues the job only if no unfinished job holds
+	// the same key
+	UniqueKey   string
+	MaxAttempts int
+}
+
+type jobHandler func(ctx context.Context, payload json.RawMessage) error
+
+type jobContextKey struct{}
+
+// runningJob returns the job whose handler ctx was passed to, e.g. to tell
+// whether this is its last attempt
+func runningJob(ctx context.Context) *domain.Job {
+	job, _ := ctx.Value(jobContextKey{}).(*domain.Job)
+	return job
+}
+
+// JobQueue runs asynchronous work stored in Postgres. Jobs are claimed with
+// SELECT ... FOR UPDATE SKIP LOCKED, so any number of instances can work the
+// same queue; failed jobs retry with exponential backoff until they are dead.
+type JobQueue struct {
+	db       *gorm.DB
+	policy   JobPolicy
+	handlers map[string]jobHandler
+	worker   string
+	wg       sync.WaitGroup
+}
+
+func NewJobQueue(db *gorm.DB, policy JobPolicy) *JobQueue {
+	host, _ := os.Hostname()
+	return &JobQueue{
+		db:       db,
+		policy:   policy,
+		handlers: make(map[string]jobHandler),
+		worker:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// HandleJob registers handle for jobs of jobType, decoding their payload
+// into T. Handlers must be registered before Start. Jobs may run more than
+// once, e.g. when their worker dies midway, so handlers should be
+// idempotent.
+func HandleJob[T any](q *JobQueue, jobType string, handle func(ctx context.Context, payload T) error) {
+	q.handlers[jobType] = func(ctx context.Context, data json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", ErrJobPermanent, err)
+		}
+		return handle(ctx, payload)
+	}
+}
+
+// Enqueue adds a job with payload encoded as JSON. With a unique key that an
+// unfinished job holds already, that job is returned instead.
+func (q *JobQueue) Enqueue(ctx context.Context, jobType string, payload any, opts JobOptions) (*domain.Job, error) {
+	return q.enqueue(ctx, q.db, jobType, payload, opts)
+}
+
+// enqueue adds the job in tx, so that it only exists if tx commits
+func (q *JobQueue) enqueue(ctx context.Context, tx *gorm.DB, jobType string, payload any, opts JobOptions) (*domain.Job, error) {
+	if _, ok := q.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	job := &domain.Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      domain.JobStatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       runAt.Add(opts.Delay),
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = q.policy.MaxAttempts
+	}
+	if opts.UniqueKey != "" {
+		key := opts.UniqueKey
+		job.UniqueKey = &key
+	}
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	jobRepo := persistence.NewJobRepository(tx)
+	created, err := jobRepo.Create(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	if created {
+		return job, nil
+	}
+	return jobRepo.FindUnfinishedByUniqueKey(ctx, opts.UniqueKey)
+}
+
+// Start runs the workers until ctx is done. It returns immediately; without
+// registered handlers no worker starts.
+func (q *JobQueue) Start(ctx context.Context) {
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	if len(types) == 0 {
+		return
+	}
+	sort.Strings(types)
+
+	for i := 0; i < q.policy.Workers; i++ {
+		q.wg.Add(1)
+		go func(worker string) {
+			defer q.wg.Done()
+			q.work(ctx, worker, types)
+		}(fmt.Sprintf("%s/%d", q.worker, i+1))
+	}
+}
+
+// Wait blocks until the workers stopped after their context is done. Jobs
+// in progress finish first, within the policy's timeout.
+func (q *JobQueue) Wait() {
+	q.wg.Wait()
+}
+
+// ReleaseStale gives jobs whose worker stopped responding back to the queue
+func (q *JobQueue) ReleaseStale(ctx context.Context) (int64, error) {
+	now := time.Now()
+	return persistence.NewJobRepository(q.db).ReleaseStale(ctx, now.Add(-2*q.policy.Timeout), now)
+}
+
+// work runs due jobs one after another, polling while the queue is idle
+func (q *JobQueue) work(ctx context.Context, worker string, types []string) {
+	jobRepo := persistence.NewJobRepository(q.db)
+	for ctx.Err() == nil {
+		jobs, err := jobRepo.Claim(ctx, types, worker, time.Now(), 1)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("failed to claim job", "worker", worker, "error", err)
+		}
+		if len(jobs) > 0 {
+			q.run(ctx, &jobs[0])
+			continue
+		}
+
+		timer := time.NewTimer(q.policy.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// run runs one claimed attempt of job and records its outcome. The attempt
+// isn't cut short when ctx is done, only by the policy's timeout.
+func (q *JobQueue) run(ctx context.Context, job *domain.Job) {
+	ctx = context.WithoutCancel(ctx)
+	runCtx, cancel := context.WithTimeout(ctx, q.policy.Timeout)
+	defer cancel()
+	runCtx = context.WithValue(runCtx, jobContextKey{}, job)
+
+	start := time.Now()
+	err := q.call(runCtx, job)
+	now := time.Now()
+	logger := slog.With("job_id", job.ID, "type", job.Type, "attempt", job.Attempts, "duration", now.Sub(start))
+
+	if err == nil {
+		job.Complete(now)
+		logger.Debug("job completed")
+	} else {
+		job.Fail(err, errors.Is(err, ErrJobPermanent), now.Add(q.backoff(job.Attempts)), now)
+		if job.Status == domain.JobStatusDead {
+			logger.Error("job failed for good", "error", err)
+		} else {
+			logger.Warn("job failed, will retry", "run_at", job.RunAt, "error", err)
+		}
+	}
+
+	ok, err := persistence.NewJobRepository(q.db).Finish(ctx, job)
+	if err != nil {
+		logger.Error("failed to save job outcome", "error", err)
+	} else if !ok {
+		logger.Warn("job was released while it ran; outcome dropped")
+	}
+}
+
+// call runs the job's handler, turning a panic into an error
+func (q *JobQueue) call(ctx context.Context, job *domain.Job) (err error) {
+	handle, ok := q.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handle(ctx, job.Payload)
+}
+
+// backoff is the delay before the retry that follows attempt
+func (q *JobQueue) backoff(attempt int) time.Duration {
+	delay := q.policy.BackoffBase
+	for i := 1; i < attempt && delay < q.policy.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, q.policy.BackoffMax)
+}
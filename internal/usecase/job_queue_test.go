@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database with the tables of models. It
+// stands in for Postgres, without row locks: SKIP LOCKED is dropped from
+// claims, so these tests cover what a claim picks, not how claims race.
+func newTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Every connection would get its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+type testJobPayload struct {
+	N int `json:"n"`
+}
+
+// newTestQueue returns a queue with a "test" job type whose handler returns
+// the errors of fail in turn, then succeeds
+func newTestQueue(t *testing.T, fail ...error) (*JobQueue, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t, &domain.Job{})
+	q := NewJobQueue(db, testJobPolicy)
+	var calls atomic.Int32
+	HandleJob(q, "test", func(ctx context.Context, payload testJobPayload) error {
+		if n := int(calls.Add(1)); n <= len(fail) {
+			return fail[n-1]
+		}
+		return nil
+	})
+	return q, db
+}
+
+// claim claims the first job of jobType due at now and runs it like a
+// worker would, returning it as saved
+func claim(t *testing.T, q *JobQueue, jobType string, now time.Time) *domain.Job {
+	t.Helper()
+	ctx := context.Background()
+	jobRepo := persistence.NewJobRepository(q.db)
+
+	jobs, err := jobRepo.Claim(ctx, []string{jobType}, "test-worker", now, 1)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("claimed %d jobs, want 1", len(jobs))
+	}
+	if jobs[0].Status != domain.JobStatusRunning || jobs[0].LockedBy != "test-worker" {
+		t.Fatalf("claimed job is %s by %q", jobs[0].Status, jobs[0].LockedBy)
+	}
+
+	q.run(ctx, &jobs[0])
+
+	saved, err := jobRepo.FindByID(ctx, jobs[0].ID)
+	if err != nil {
+		t.Fatalf("find job: %v", err)
+	}
+	return saved
+}
+
+func checkDue(t *testing.T, job *domain.Job, delay time.Duration) {
+	t.Helper()
+	if wait := time.Until(job.RunAt); wait < delay-5*time.Second || wait > delay {
+		t.Errorf("job %d due in %s, want %s", job.ID, wait.Round(time.Second), delay)
+	}
+}
+
+func TestJobQueueRetriesWithBackoffUntilDead(t *testing.T) {
+	boom := errors.New("boom")
+	q, _ := newTestQueue(t, boom, boom, boom, boom)
+	job, err := q.Enqueue(context.Background(), "test", testJobPayload{N: 1}, JobOptions{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if job.MaxAttempts != testJobPolicy.MaxAttempts {
+		t.Fatalf("max attempts = %d, want the policy's %d", job.MaxAttempts, testJobPolicy.MaxAttempts)
+	}
+
+	later := time.Now().Add(time.Hour)
+	for attempt, delay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		job = claim(t, q, "test", later)
+		if job.Status != domain.JobStatusPending || job.Attempts != attempt+1 {
+			t.Fatalf("after attempt %d: %s with %d attempts", attempt+1, job.Status, job.Attempts)
+		}
+		if job.LastError != "boom" || job.LockedAt != nil || job.LockedBy != "" {
+			t.Errorf("after attempt %d: error %q, locked by %q", attempt+1, job.LastError, job.LockedBy)
+		}
+		checkDue(t, job, delay)
+	}
+
+	job = claim(t, q, "test", later)
+	if job.Status != domain.JobStatusDead || job.Attempts != 3 || job.FinishedAt == nil {
+		t.Fatalf("after the last attempt: %s with %d attempts, finished %v", job.Status, job.Attempts, job.FinishedAt)
+	}
+	if jobs, _ := persistence.NewJobRepository(q.db).Claim(context.Background(), []string{"test"}, "w", later, 1); len(jobs) != 0 {
+		t.Error("a dead job was claimed again")
+	}
+}
+
+func TestJobQueuePermanentFailureDiesAtOnce(t *testing.T) {
+	q, _ := newTestQueue(t, fmt.Errorf("%w: no such order", ErrJobPermanent))
+	if _, err := q.Enqueue(context.Background(), "test", testJobPayload{}, JobOptions{}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	job := claim(t, q, "test", time.Now())
+	if job.Status != domain.JobStatusDead || job.Attempts != 1 {
+		t.Errorf("got %s with %d attempts, want dead after 1", job.Status, job.Attempts)
+	}
+}
+
+func TestJobQueueBackoffIsCapped(t *testing.T) {
+	q := NewJobQueue(nil, testJobPolicy)
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, delay := range want {
+		if got := q.backoff(i + 1); got != delay {
+			t.Errorf("backoff after attempt %d = %s, want %s", i+1, got, delay)
+		}
+	}
+}
+
+func TestJobQueueDedupsUnfinishedJobsByUniqueKey(t *testing.T) {
+	q, db := newTestQueue(t)
+	ctx := context.Background()
+	opts := JobOptions{UniqueKey: "order:42"}
+
+	first, err := q.Enqueue(ctx, "test", testJobPayload{N: 1}, opts)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	second, err := q.Enqueue(ctx, "test", testJobPayload{N: 2}, opts)
+	if err != nil {
+		t.Fatalf("Enqueue again: %v", err)
+	}
+	if second.ID != first.ID || string(second.Payload) != `{"n":1}` {
+		t.Errorf("second enqueue returned job %d with %s, want job %d unchanged", second.ID, second.Payload, first.ID)
+	}
+	other, err := q.Enqueue(ctx, "test", testJobPayload{N: 3}, JobOptions{UniqueKey: "order:43"})
+	if err != nil || other.ID == first.ID {
+		t.Fatalf("another key: job %v, err %v", other, err)
+	}
+
+	// Once the job finished, the key is free again
+	if job := claim(t, q, "test", time.Now()); job.ID != first.ID || job.Status != domain.JobStatusCompleted {
+		t.Fatalf("ran job %d (%s), want job %d completed", job.ID, job.Status, first.ID)
+	}
+	third, err := q.Enqueue(ctx, "test", testJobPayload{N: 4}, opts)
+	if err != nil {
+		t.Fatalf("Enqueue after completion: %v", err)
+	}
+	if third.ID == first.ID {
+		t.Error("the finished job's key still blocks new jobs")
+	}
+
+	var count int64
+	db.Model(&domain.Job{}).Where("unique_key = ?", "order:42").Count(&count)
+	if count != 2 {
+		t.Errorf("%d jobs hold the key, want 2", count)
+	}
+}
+
+func TestJobQueueRunsScheduledJobsWhenDue(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+	jobRepo := persistence.NewJobRepository(q.db)
+
+	runAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	late, err := q.Enqueue(ctx, "test", testJobPayload{N: 1}, JobOptions{RunAt: runAt, Delay: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if !late.RunAt.Equal(runAt.Add(30 * time.Minute)) {
+		t.Errorf("run at %s, want %s", late.RunAt, runAt.Add(30*time.Minute))
+	}
+	soon, err := q.Enqueue(ctx, "test", testJobPayload{N: 2}, JobOptions{Delay: time.Hour})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if jobs, err := jobRepo.Claim(ctx, []string{"test"}, "w", time.Now(), 10); err != nil || len(jobs) != 0 {
+		t.Fatalf("claimed %d jobs before they were due (err %v)", len(jobs), err)
+	}
+	if jobs, err := jobRepo.Claim(ctx, []string{"other"}, "w", runAt.Add(time.Hour), 10); err != nil || len(jobs) != 0 {
+		t.Fatalf("claimed %d jobs of a type the worker doesn't handle (err %v)", len(jobs), err)
+	}
+
+	// The job due first is claimed first, whatever order they were added in
+	if job := claim(t, q, "test", runAt.Add(time.Hour)); job.ID != soon.ID {
+		t.Errorf("claimed job %d first, want the earlier job %d", job.ID, soon.ID)
+	}
+	if job := claim(t, q, "test", runAt.Add(time.Hour)); job.ID != late.ID {
+		t.Errorf("claimed job %d, want job %d", job.ID, late.ID)
+	}
+}
+
+func TestJobQueueReleasesStaleJobs(t *testing.T) {
+	q, db := newTestQueue(t)
+	ctx := context.Background()
+	jobRepo := persistence.NewJobRepository(db)
+
+	// Two workers claimed jobs a while ago and died; the third claim is
+	// recent
+	lost := time.Now().Add(-time.Hour)
+	retried, err := q.Enqueue(ctx, "test", testJobPayload{N: 1}, JobOptions{RunAt: lost})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	lastTry, err := q.Enqueue(ctx, "test", testJobPayload{N: 2}, JobOptions{RunAt: lost, MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	fresh, err := q.Enqueue(ctx, "test", testJobPayload{N: 3}, JobOptions{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	stale, err := jobRepo.Claim(ctx, []string{"test"}, "lost-worker", lost, 2)
+	if err != nil || len(stale) != 2 {
+		t.Fatalf("claimed %d jobs (err %v), want 2", len(stale), err)
+	}
+	if _, err := jobRepo.Claim(ctx, []string{"test"}, "live-worker", time.Now(), 1); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+
+	released, err := q.ReleaseStale(ctx)
+	if err != nil {
+		t.Fatalf("ReleaseStale: %v", err)
+	}
+	if released != 2 {
+		t.Errorf("released %d jobs, want 2", released)
+	}
+
+	want := map[uint]domain.JobStatus{
+		retried.ID: domain.JobStatusPending,
+		lastTry.ID: domain.JobStatusDead,
+		fresh.ID:   domain.JobStatusRunning,
+	}
+	for id, status := range want {
+		job, err := jobRepo.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("find job %d: %v", id, err)
+		}
+		if job.Status != status {
+			t.Errorf("job %d is %s, want %s", id, job.Status, status)
+		}
+		if status != domain.JobStatusRunning && (job.LockedAt != nil || job.LastError == "") {
+			t.Errorf("released job %d still locked or without error: %+v", id, job)
+		}
+		if (job.FinishedAt != nil) != (status == domain.JobStatusDead) {
+			t.Errorf("job %d finished at %v", id, job.FinishedAt)
+		}
+	}
+
+	// The released job is claimed again. The lost worker's outcome arrives
+	// late and is dropped rather than overwriting the new claim.
+	reclaimed, err := jobRepo.Claim(ctx, []string{"test"}, "new-worker", time.Now(), 1)
+	if err != nil || len(reclaimed) != 1 || reclaimed[0].ID != retried.ID || reclaimed[0].Attempts != 2 {
+		t.Fatalf("reclaimed %+v (err %v), want job %d at attempt 2", reclaimed, err, retried.ID)
+	}
+	for _, job := range stale {
+		if job.ID != retried.ID {
+			continue
+		}
+		job.Complete(time.Now())
+		if ok, err := jobRepo.Finish(ctx, &job); err != nil || ok {
+			t.Errorf("stale outcome saved: ok %v, err %v", ok, err)
+		}
+	}
+	job, err := jobRepo.FindByID(ctx, retried.ID)
+	if err != nil || job.Status != domain.JobStatusRunning || job.LockedBy != "new-worker" {
+		t.Errorf("after the late outcome the job is %+v (err %v), want still running by new-worker", job, err)
+	}
+}
+
+func TestJobQueueWorkersRunDueJobs(t *testing.T) {
+	db := newTestDB(t, &domain.Job{})
+	policy := testJobPolicy
+	policy.PollInterval = 10 * time.Millisecond
+	q := NewJobQueue(db, policy)
+
+	done := make(chan int, 2)
+	HandleJob(q, "test", func(ctx context.Context, payload testJobPayload) error {
+		if runningJob(ctx) == nil {
+			t.Error("handler context lacks its job")
+		}
+		done <- payload.N
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	defer q.Wait()
+	defer cancel()
+
+	if _, err := q.Enqueue(ctx, "test", testJobPayload{N: 7}, JobOptions{}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := q.Enqueue(ctx, "test", testJobPayload{N: 8}, JobOptions{Delay: time.Hour}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	select {
+	case n := <-done:
+		if n != 7 {
+			t.Errorf("ran job with payload %d, want 7", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the due job didn't run")
+	}
+	select {
+	case n := <-done:
+		t.Errorf("ran the scheduled job %d early", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
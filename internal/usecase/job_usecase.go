@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrJobNotFound is returned for jobs that don't exist
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobDuplicate is returned when retrying a job whose unique key is
+	// held by another unfinished job
+	ErrJobDuplicate = errors.New("another unfinished job has the same unique key")
+)
+
+// JobPage is a page of jobs, newest first
+type JobPage struct {
+	Jobs   []domain.Job `json:"jobs"`
+	Total  int64        `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// JobUseCase lets admins inspect the job queue and retry or discard jobs
+type JobUseCase struct {
+	db *gorm.DB
+}
+
+func NewJobUseCase(db *gorm.DB) *JobUseCase {
+	return &JobUseCase{db: db}
+}
+
+// ListJobs retrieves a page of jobs, optionally filtered by status and type
+func (uc *JobUseCase) ListJobs(ctx context.Context, filter repository.JobFilter, limit, offset int) (*JobPage, error) {
+	limit = max(1, min(limit, 500))
+	offset = max(0, offset)
+
+	jobs, total, err := persistence.NewJobRepository(uc.db).FindAll(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &JobPage{Jobs: jobs, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// GetJob retrieves a job by ID
+func (uc *JobUseCase) GetJob(ctx context.Context, id uint) (*domain.Job, error) {
+	job, err := persistence.NewJobRepository(uc.db).FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// RetryJob queues a dead or discarded job again, to run now with a fresh set
+// of attempts
+func (uc *JobUseCase) RetryJob(ctx context.Context, id uint) (*domain.Job, error) {
+	return uc.change(ctx, id, func(jobRepo repository.JobRepository, job *domain.Job) error {
+		if err := job.Retry(time.Now()); err != nil {
+			return err
+		}
+		if job.UniqueKey == nil {
+			return nil
+		}
+		_, err := jobRepo.FindUnfinishedByUniqueKey(ctx, *job.UniqueKey)
+		if err == nil {
+			return ErrJobDuplicate
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// DiscardJob drops a pending or dead job so it never runs
+func (uc *JobUseCase) DiscardJob(ctx context.Context, id uint) (*domain.Job, error) {
+	return uc.change(ctx, id, func(_ repository.JobRepository, job *domain.Job) error {
+		return job.Discard(time.Now())
+	})
+}
+
+// change applies apply to the locked job and saves it; workers can't claim
+// the job meanwhile
+func (uc *JobUseCase) change(ctx context.Context, id uint, apply func(jobRepo repository.JobRepository, job *domain.Job) error) (*domain.Job, error) {
+	var job *domain.Job
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		jobRepo := persistence.NewJobRepository(tx)
+
+		var err error
+		job, err = jobRepo.LockByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrJobNotFound
+			}
+			return err
+		}
+		if err := apply(jobRepo, job); err != nil {
+			return err
+		}
+		return jobRepo.Update(ctx, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
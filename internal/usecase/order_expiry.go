@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+// expiryBatchSize is how many expired orders are read at a time
+const expiryBatchSize = 100
+
+// ExpirePendingOrders cancels orders that stayed pending, unpaid and
+// unshipped, for longer than ttl. Their stock goes back to the warehouses
+// it was allocated from, their coupon redemptions are released and their
+// payments fail. It returns how many orders were cancelled.
+func (uc *OrderUseCase) ExpirePendingOrders(ctx context.Context, ttl time.Duration) (int, error) {
+	orderRepo := persistence.NewOrderRepository(uc.db)
+	cutoff := time.Now().Add(-ttl)
+
+	var cancelled int
+	var afterID uint
+	for {
+		ids, err := orderRepo.FindExpiredPendingIDs(ctx, cutoff, afterID, expiryBatchSize)
+		if err != nil {
+			return cancelled, err
+		}
+
+		// Orders are cancelled one by one, so a failing order doesn't hold
+		// back the others
+		for _, id := range ids {
+			ok, err := uc.expireOrder(ctx, id, cutoff)
+			if err != nil {
+				slog.Error("failed to expire order", "order_id", id, "error", err)
+				continue
+			}
+			if ok {
+				cancelled++
+			}
+		}
+
+		if len(ids) < expiryBatchSize {
+			return cancelled, nil
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// expireOrder cancels the order if it is still pending and unshipped
+func (uc *OrderUseCase) expireOrder(ctx context.Context, orderID uint, cutoff time.Time) (bool, error) {
+	var order *domain.Order
+	var events []gateway.Event
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		orderRepo := persistence.NewOrderRepository(tx)
+		shipmentRepo := persistence.NewShipmentRepository(tx)
+		inventoryRepo := persistence.NewInventoryRepository(tx)
+		couponRepo := persistence.NewCouponRepository(tx)
+		paymentRepo := persistence.NewPaymentRepository(tx)
+
+		// The order may have been paid or shipped since it was listed
+		var err error
+		order, err = orderRepo.LockByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != domain.OrderStatusPending || !order.CreatedAt.Before(cutoff) {
+			order = nil
+			return nil
+		}
+		shipments, err := shipmentRepo.FindByOrderID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if len(shipments) > 0 {
+			order = nil
+			return nil
+		}
+
+		// Put the stock back where it was taken from; orders placed before
+		// warehouses existed go back to the default warehouse
+		reason := fmt.Sprintf("order #%d expired unpaid", order.ID)
+		for _, item := range order.Items {
+			allocations := item.Allocations
+			if len(allocations) == 0 {
+				allocations = []domain.OrderAllocation{{Quantity: item.Quantity}}
+			}
+			for _, allocation := range allocations {
+				movement := newMovement(ctx, domain.MovementCancellation, item.ProductID, item.VariantID, reason)
+				if allocation.WarehouseID != 0 {
+					movement.WarehouseID = &allocation.WarehouseID
+				}
+				movement.Quantity = allocation.Quantity
+				movement.OrderID = &order.ID
+				if err := inventoryRepo.Apply(ctx, movement); err != nil {
+					return fmt.Errorf("failed to restock %s: %w", item.ProductName, err)
+				}
+			}
+		}
+
+		if err := couponRepo.ReleaseRedemptions(ctx, order.ID); err != nil {
+			return fmt.Errorf("failed to release coupons: %w", err)
+		}
+
+		payment, err := paymentRepo.FindByOrderID(ctx, order.ID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			payment = nil
+		case err != nil:
+			return err
+		case payment.Status == domain.PaymentStatusPending:
+			payment.MarkAsFailed()
+			payment.Order = nil
+			if err := paymentRepo.Update(ctx, payment); err != nil {
+				return fmt.Errorf("failed to update payment: %w", err)
+			}
+		default:
+			payment = nil
+		}
+
+		before := *order
+		order.Status = domain.OrderStatusCancelled
+		if err := orderRepo.UpdateStatus(ctx, order.ID, domain.OrderStatusCancelled); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityOrder, order.ID, &before, order); err != nil {
+			return err
+		}
+
+		events = []gateway.Event{newEvent(gateway.EventOrderCancelled, order)}
+		if payment != nil {
+			events = append(events, newEvent(gateway.EventPaymentFailed, payment))
+		}
+		return queueWebhooks(ctx, tx, uc.webhooks, events)
+	})
+	if err != nil || order == nil {
+		return false, err
+	}
+
+	productIDs := make([]uint, 0, len(order.Items))
+	for _, item := range order.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	uc.stockAlerts.CheckProducts(ctx, productIDs...)
+
+	publishEvents(ctx, uc.events, events)
+	return true, nil
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// orderPricing prices an order in steps: line subtotals, coupon discounts,
+// tax per item and shipping. Every component is stored on the order and its
+// items, so the order can be invoiced again exactly even after the rules
+// that priced it change.
+type orderPricing struct {
+	categoryRepo     repository.CategoryRepository
+	couponRepo       repository.CouponRepository
+	taxRateRepo      repository.TaxRateRepository
+	shippingRateRepo repository.ShippingRateRepository
+}
+
+func newOrderPricing(tx *gorm.DB) *orderPricing {
+	return &orderPricing{
+		categoryRepo:     persistence.NewCategoryRepository(tx),
+		couponRepo:       persistence.NewCouponRepository(tx),
+		taxRateRepo:      persistence.NewTaxRateRepository(tx),
+		shippingRateRepo: persistence.NewShippingRateRepository(tx),
+	}
+}
+
+// price prices the items of order, already priced per unit, and sets the
+// order's totals. productCategories maps the ordered products to their
+// category.
+func (p *orderPricing) price(ctx context.Context, order *domain.Order, couponCodes []string, productCategories map[uint]*uint) error {
+	// Coupons stay locked until the transaction ends, so concurrent orders
+	// can't exceed their usage limits
+	if len(couponCodes) > 0 {
+		discounts, err := applyCoupons(ctx, p.couponRepo, order.UserID, couponCodes, order.Items)
+		if err != nil {
+			return err
+		}
+		order.Discounts = discounts
+	}
+
+	if err := p.applyTax(ctx, order, productCategories); err != nil {
+		return err
+	}
+
+	shippingRates, err := p.shippingRateRepo.FindForRegion(ctx, order.Region)
+	if err != nil {
+		return fmt.Errorf("failed to read shipping rates: %w", err)
+	}
+	var payable float64
+	for _, item := range order.Items {
+		payable += item.Payable()
+	}
+	order.ShippingAmount = domain.MatchShippingRate(shippingRates, order.Region).ShippingFor(payable)
+
+	order.CalculateTotal()
+	return nil
+}
+
+// applyTax taxes every item at the rate for its category in the order's
+// region. Rates for a category also apply to its subcategories.
+func (p *orderPricing) applyTax(ctx context.Context, order *domain.Order, productCategories map[uint]*uint) error {
+	rates, err := p.taxRateRepo.FindForRegion(ctx, order.Region)
+	if err != nil {
+		return fmt.Errorf("failed to read tax rates: %w", err)
+	}
+
+	parents := make(map[uint]*uint)
+	if slices.ContainsFunc(rates, func(rate domain.TaxRate) bool { return rate.CategoryID != nil }) {
+		categories, err := p.categoryRepo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, category := range categories {
+			parents[category.ID] = category.ParentID
+		}
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+
+		// The product's category followed by its ancestors
+		var path []uint
+		for id := productCategories[item.ProductID]; id != nil && len(path) <= len(parents); id = parents[*id] {
+			path = append(path, *id)
+		}
+
+		item.ApplyTax(domain.MatchTaxRate(rates, order.Region, path))
+	}
+	return nil
+}
+
+// applyCoupons checks that the user may redeem the coupons of codes now and
+// discounts the order items with them. The coupons are locked for the rest
+// of the transaction.
+func applyCoupons(ctx context.Context, couponRepo repository.CouponRepository, userID uint, codes []string, items []domain.OrderItem) ([]domain.OrderDiscount, error) {
+	var normalized []string
+	for _, code := range codes {
+		if code = domain.NormalizeCouponCode(code); code != "" && !slices.Contains(normalized, code) {
+			normalized = append(normalized, code)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+
+	coupons, err := couponRepo.LockByCodes(ctx, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coupons: %w", err)
+	}
+
+	now := time.Now()
+	for _, code := range normalized {
+		i := slices.IndexFunc(coupons, func(c domain.Coupon) bool { return c.Code == code })
+		if i < 0 || !coupons[i].IsValidAt(now) {
+			return nil, fmt.Errorf("coupon %s is not valid", code)
+		}
+		coupon := &coupons[i]
+		if coupon.IsExhausted() {
+			return nil, fmt.Errorf("coupon %s: %w", code, domain.ErrCouponExhausted)
+		}
+		if coupon.MaxRedemptionsPerUser > 0 {
+			used, err := couponRepo.CountRedemptions(ctx, coupon.ID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if used >= int64(coupon.MaxRedemptionsPerUser) {
+				return nil, fmt.Errorf("coupon %s was already used the maximum number of times", code)
+			}
+		}
+	}
+
+	return domain.ApplyCoupons(items, coupons)
+}
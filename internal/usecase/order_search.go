@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
+)
+
+// maxOrderLookupIDs bounds the number of orders looked up by ID at once
+const maxOrderLookupIDs = 500
+
+// OrderPage is a page of order search results
+type OrderPage struct {
+	Orders []domain.Order `json:"orders"`
+	Total  int64          `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+var orderExportColumns = []string{
+	"id", "created_at", "status", "user_id", "user_email", "region", "items",
+	"subtotal", "discount_amount", "tax_amount", "shipping_amount", "total_amount",
+}
+
+// ParseOrderSort parses an order sort such as "created_at" or
+// "-total_amount"; a leading "-" sorts descending. An empty sort lists the
+// newest orders first.
+func ParseOrderSort(s string) (field repository.OrderSortField, ascending bool, err error) {
+	if s == "" {
+		return repository.OrderSortCreatedAt, false, nil
+	}
+	name, descending := strings.CutPrefix(s, "-")
+	switch field := repository.OrderSortField(name); field {
+	case repository.OrderSortCreatedAt, repository.OrderSortTotalAmount, repository.OrderSortID:
+		return field, !descending, nil
+	}
+	return "", false, fmt.Errorf("unsupported sort %q, expected created_at, total_amount or id", s)
+}
+
+// ValidateOrderFilter checks an order search filter before it is run
+func ValidateOrderFilter(filter repository.OrderFilter) error {
+	if len(filter.IDs) > maxOrderLookupIDs {
+		return fmt.Errorf("at most %d order IDs can be looked up at once", maxOrderLookupIDs)
+	}
+	switch filter.Status {
+	case "", domain.OrderStatusPending, domain.OrderStatusPaid, domain.OrderStatusCancelled, domain.OrderStatusCompleted:
+	default:
+		return fmt.Errorf("unknown order status %q", filter.Status)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return errors.New("time range must end after it starts")
+	}
+	if filter.MinTotal != nil && filter.MaxTotal != nil && *filter.MinTotal > *filter.MaxTotal {
+		return errors.New("minimum total must not exceed the maximum total")
+	}
+	return nil
+}
+
+// SearchOrders retrieves a page of the orders matching filter, across all
+// users
+func (uc *OrderUseCase) SearchOrders(ctx context.Context, filter repository.OrderFilter, limit, offset int) (*OrderPage, error) {
+	if err := ValidateOrderFilter(filter); err != nil {
+		return nil, err
+	}
+
+	limit = max(1, min(limit, 500))
+	offset = max(0, offset)
+
+	orderRepo := persistence.NewOrderRepository(uc.db)
+	orders, total, err := orderRepo.Search(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &OrderPage{Orders: orders, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// ExportOrders streams the orders matching filter to w as CSV, one row per
+// order, without loading them into memory. Each batch is flushed through w if
+// it can flush, so a failing client stops the export at the next batch.
+func (uc *OrderUseCase) ExportOrders(ctx context.Context, w io.Writer, filter repository.OrderFilter) error {
+	if err := ValidateOrderFilter(filter); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(orderExportColumns); err != nil {
+		return err
+	}
+
+	orderRepo := persistence.NewOrderRepository(uc.db)
+	err := orderRepo.SearchInBatches(ctx, filter, exportBatchSize, func(orders []domain.Order) error {
+		for i := range orders {
+			if err := cw.Write(orderExportRow(&orders[i])); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		return flushBatch(w)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// flushBatch sends a finished batch of an export on to the client if w can
+// flush
+func flushBatch(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func orderExportRow(order *domain.Order) []string {
+	email := ""
+	if order.User != nil {
+		email = order.User.Email
+	}
+	items := 0
+	for _, item := range order.Items {
+		items += item.Quantity
+	}
+	amount := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return []string{
+		strconv.FormatUint(uint64(order.ID), 10),
+		order.CreatedAt.UTC().Format(time.RFC3339),
+		string(order.Status),
+		strconv.FormatUint(uint64(order.UserID), 10),
+		email,
+		order.Region,
+		strconv.Itoa(items),
+		amount(order.Subtotal),
+		amount(order.DiscountAmount),
+		amount(order.TaxAmount),
+		amount(order.ShippingAmount),
+		amount(order.TotalAmount),
+	}
+}
@@ -4,92 +4,187 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
 	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
 	"gorm.io/gorm"
 )
 
-// CreateOrderRequest represents the request to create an order
+// ErrOrderNotFound is returned for orders that don't exist or that the
+// caller may not see
+var ErrOrderNotFound = errors.New("order not found")
+
+// CreateOrderRequest represents the request to create an order. The order
+// is placed for the authenticated user, never for a user named in the body.
 type CreateOrderRequest struct {
-	UserID        uint                     `json:"user_id"`
 	PaymentMethod string                   `json:"payment_method"`
 	Items         []CreateOrderItemRequest `json:"items"`
+	CouponCodes   []string                 `json:"coupon_codes"`
+	// AddressID picks the address of the user's address book the order
+	// ships to; without it the default address is used
+	AddressID *uint `json:"address_id"`
+	// Region the order ships to, e.g. "US-CA", for users without an
+	// address book; it selects tax and shipping rates
+	Region string `json:"region"`
 }
 
-// CreateOrderItemRequest represents an item in the order
+// CreateOrderItemRequest represents an item in the order. VariantID is
+// required for products that are sold through variants.
 type CreateOrderItemRequest struct {
-	ProductID uint `json:"product_id"`
-	Quantity  int  `json:"quantity"`
+	ProductID uint  `json:"product_id"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity"`
 }
 
 type OrderUseCase struct {
-	db *gorm.DB
+	db          *gorm.DB
+	stockAlerts *StockAlertUseCase
+	invoices    *InvoiceUseCase
+	webhooks    *WebhookUseCase
+	events      gateway.EventPublisher
 }
 
-func NewOrderUseCase(db *gorm.DB) *OrderUseCase {
+func NewOrderUseCase(db *gorm.DB, stockAlerts *StockAlertUseCase, invoices *InvoiceUseCase, webhooks *WebhookUseCase, events gateway.EventPublisher) *OrderUseCase {
 	return &OrderUseCase{
-		db: db,
+		db:          db,
+		stockAlerts: stockAlerts,
+		invoices:    invoices,
+		webhooks:    webhooks,
+		events:      events,
 	}
 }
 
-// CreateOrder creates a new order with transaction support
+// CreateOrder creates a new order of userID with transaction support
 // This is the KEY EXAMPLE of multi-table transaction with GORM
-func (uc *OrderUseCase) CreateOrder(ctx context.Context, req CreateOrderRequest) (*domain.Order, error) {
+func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID uint, req CreateOrderRequest) (*domain.Order, error) {
+	return uc.placeOrder(ctx, userID, req, nil)
+}
+
+// placeOrder creates the order of req for userID. beforeCommit, if set, runs in the
+// order's transaction once the order is complete; an error rolls the order
+// back.
+func (uc *OrderUseCase) placeOrder(ctx context.Context, userID uint, req CreateOrderRequest, beforeCommit func(tx *gorm.DB, order *domain.Order) error) (*domain.Order, error) {
 	var createdOrder *domain.Order
+	var events []gateway.Event
 
 	// Start GORM Transaction
 	err := uc.db.Transaction(func(tx *gorm.DB) error {
 		// Create repository instances with transaction
 		orderRepo := persistence.NewOrderRepository(tx)
 		productRepo := persistence.NewProductRepository(tx)
+		variantRepo := persistence.NewProductVariantRepository(tx)
+		inventoryRepo := persistence.NewInventoryRepository(tx)
+		couponRepo := persistence.NewCouponRepository(tx)
 		paymentRepo := persistence.NewPaymentRepository(tx)
+		userRepo := persistence.NewUserRepository(tx)
+
+		// Only users with a verified email may place orders
+		user, err := userRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("user not found")
+			}
+			return err
+		}
+		if !user.IsVerified() {
+			return ErrEmailNotVerified
+		}
 
 		// Step 1: Validate and prepare order items
 		var orderItems []domain.OrderItem
-		var totalAmount float64
+		productCategories := make(map[uint]*uint)
 
 		for _, item := range req.Items {
+			if item.Quantity <= 0 {
+				return fmt.Errorf("quantity for product %d must be greater than 0", item.ProductID)
+			}
+
 			// Fetch product within transaction
 			product, err := productRepo.FindByID(ctx, item.ProductID)
 			if err != nil {
 				return fmt.Errorf("product with ID %d not found", item.ProductID)
 			}
 
+			productCategories[product.ID] = product.CategoryID
+
+			// Products with variants are sold through them
+			if product.HasVariants() || item.VariantID != nil {
+				orderItem, err := variantOrderItem(ctx, variantRepo, product, item)
+				if err != nil {
+					return err
+				}
+				orderItems = append(orderItems, *orderItem)
+				continue
+			}
+
 			// Check stock availability
 			if !product.IsAvailable(item.Quantity) {
 				return fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)",
 					product.Name, product.Stock, item.Quantity)
 			}
 
-			// Calculate price
-			itemTotal := product.Price * float64(item.Quantity)
-			totalAmount += itemTotal
-
 			// Prepare order item
 			orderItems = append(orderItems, domain.OrderItem{
-				ProductID: product.ID,
-				Quantity:  item.Quantity,
-				Price:     product.Price,
+				ProductID:   product.ID,
+				ProductName: product.Name,
+				Quantity:    item.Quantity,
+				Price:       product.Price,
 			})
+		}
 
-			// Step 2: Reduce product stock
-			if err := product.ReduceStock(item.Quantity); err != nil {
-				return err
+		// Step 2: Allocate every item to one or more warehouses. The stock rows
+		// stay locked until the transaction ends, so concurrent orders can't
+		// allocate the same stock.
+		allocated := make(map[stockKey]int)
+		for i := range orderItems {
+			item := &orderItems[i]
+			levels, err := inventoryRepo.LockLevels(ctx, item.ProductID, item.VariantID)
+			if err != nil {
+				return fmt.Errorf("failed to read stock: %w", err)
+			}
+			// Earlier lines of this order may already use some of the stock
+			for j := range levels {
+				levels[j].Quantity -= allocated[newStockKey(item.ProductID, item.VariantID, levels[j].WarehouseID)]
+			}
+
+			item.Allocations, err = domain.AllocateStock(levels, item.Quantity)
+			if err != nil {
+				return fmt.Errorf("insufficient stock for product %s", item.ProductName)
 			}
-			if err := productRepo.Update(ctx, product); err != nil {
-				return fmt.Errorf("failed to update product stock: %w", err)
+			for _, allocation := range item.Allocations {
+				allocated[newStockKey(item.ProductID, item.VariantID, allocation.WarehouseID)] += allocation.Quantity
 			}
 		}
 
-		// Step 3: Create order
+		// Step 3: Price the order: discounts, tax and shipping. The address
+		// is copied so later edits of the address book don't change it.
 		order := &domain.Order{
-			UserID:      req.UserID,
-			TotalAmount: totalAmount,
-			Status:      domain.OrderStatusPending,
-			Items:       orderItems,
+			UserID: userID,
+			Region: domain.NormalizeRegion(req.Region),
+			Status: domain.OrderStatusPending,
+			Items:  orderItems,
+		}
+		address, err := orderAddress(ctx, tx, userID, req.AddressID)
+		if err != nil {
+			return err
+		}
+		if address != nil {
+			order.ShippingAddress = address.PostalAddress
+			order.Region = address.Region
+		}
+		if err := newOrderPricing(tx).price(ctx, order, req.CouponCodes, productCategories); err != nil {
+			return err
+		}
+		// Nothing is left to pay for orders fully covered by coupons and
+		// shipped for free
+		if order.IsFree() {
+			order.Status = domain.OrderStatusPaid
 		}
 
+		// Step 4: Create order with its items, allocations and discounts
 		if err := order.Validate(); err != nil {
 			return fmt.Errorf("order validation failed: %w", err)
 		}
@@ -98,20 +193,70 @@ func (uc *OrderUseCase) CreateOrder(ctx context.Context, req CreateOrderRequest)
 			return fmt.Errorf("failed to create order: %w", err)
 		}
 
-		// Step 4: Create payment record
-		payment := &domain.Payment{
-			OrderID: order.ID,
-			Amount:  totalAmount,
-			Status:  domain.PaymentStatusPending,
-			Method:  req.PaymentMethod,
+		// Count the redemptions against the coupons' limits
+		for _, discount := range order.Discounts {
+			redemption := &domain.CouponRedemption{CouponID: discount.CouponID, UserID: userID, OrderID: order.ID}
+			if err := couponRepo.Redeem(ctx, redemption); err != nil {
+				return fmt.Errorf("failed to redeem coupon %s: %w", discount.Code, err)
+			}
 		}
 
-		if err := payment.Validate(); err != nil {
-			return fmt.Errorf("payment validation failed: %w", err)
+		// Step 5: Reduce stock of the allocated warehouses through the
+		// inventory ledger
+		for _, item := range order.Items {
+			for _, allocation := range item.Allocations {
+				movement := newMovement(ctx, domain.MovementSale, item.ProductID, item.VariantID, fmt.Sprintf("order #%d", order.ID))
+				movement.WarehouseID = &allocation.WarehouseID
+				movement.Quantity = -allocation.Quantity
+				movement.OrderID = &order.ID
+				if err := inventoryRepo.Apply(ctx, movement); err != nil {
+					if errors.Is(err, domain.ErrInsufficientStock) {
+						return fmt.Errorf("insufficient stock for product %s", item.ProductName)
+					}
+					return fmt.Errorf("failed to update stock: %w", err)
+				}
+			}
 		}
 
-		if err := paymentRepo.Create(ctx, payment); err != nil {
-			return fmt.Errorf("failed to create payment: %w", err)
+		// Step 6: Create payment record. Orders with nothing to pay are paid
+		// already and invoiced right away.
+		if order.IsFree() {
+			if _, err := uc.invoices.issue(ctx, tx, order, time.Now()); err != nil {
+				return err
+			}
+		} else {
+			payment := &domain.Payment{
+				OrderID: order.ID,
+				Amount:  order.TotalAmount,
+				Status:  domain.PaymentStatusPending,
+				Method:  req.PaymentMethod,
+			}
+
+			if err := payment.Validate(); err != nil {
+				return fmt.Errorf("payment validation failed: %w", err)
+			}
+
+			if err := paymentRepo.Create(ctx, payment); err != nil {
+				return fmt.Errorf("failed to create payment: %w", err)
+			}
+		}
+
+		if err := recordAudit(ctx, tx, domain.AuditActionCreate, domain.AuditEntityOrder, order.ID, nil, order); err != nil {
+			return err
+		}
+
+		if beforeCommit != nil {
+			if err := beforeCommit(tx, order); err != nil {
+				return err
+			}
+		}
+
+		events = []gateway.Event{newEvent(gateway.EventOrderCreated, order)}
+		if order.Status == domain.OrderStatusPaid {
+			events = append(events, newEvent(gateway.EventOrderPaid, order))
+		}
+		if err := queueWebhooks(ctx, tx, uc.webhooks, events); err != nil {
+			return err
 		}
 
 		createdOrder = order
@@ -122,20 +267,75 @@ func (uc *OrderUseCase) CreateOrder(ctx context.Context, req CreateOrderRequest)
 		return nil, err // Auto rollback on error
 	}
 
+	// Alerts go out only once the stock change is committed
+	productIDs := make([]uint, 0, len(createdOrder.Items))
+	for _, item := range createdOrder.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	uc.stockAlerts.CheckProducts(ctx, productIDs...)
+
+	publishEvents(ctx, uc.events, events)
+
 	return createdOrder, nil
 }
 
-// GetOrderDetail retrieves order details by ID
-func (uc *OrderUseCase) GetOrderDetail(ctx context.Context, orderID uint) (*domain.Order, error) {
+// stockKey identifies the stock of a product or variant in a warehouse
+type stockKey struct {
+	productID   uint
+	variantID   uint // 0 for the product's own stock
+	warehouseID uint
+}
+
+func newStockKey(productID uint, variantID *uint, warehouseID uint) stockKey {
+	key := stockKey{productID: productID, warehouseID: warehouseID}
+	if variantID != nil {
+		key.variantID = *variantID
+	}
+	return key
+}
+
+// variantOrderItem checks the stock of the ordered variant and returns the
+// order item priced at the variant's price
+func variantOrderItem(ctx context.Context, variantRepo repository.ProductVariantRepository, product *domain.Product, item CreateOrderItemRequest) (*domain.OrderItem, error) {
+	if item.VariantID == nil {
+		return nil, fmt.Errorf("product %s requires a variant", product.Name)
+	}
+
+	variant, err := variantRepo.FindByID(ctx, *item.VariantID)
+	if err != nil || variant.ProductID != product.ID {
+		return nil, fmt.Errorf("variant with ID %d not found for product %s", *item.VariantID, product.Name)
+	}
+
+	if !variant.IsAvailable(item.Quantity) {
+		return nil, fmt.Errorf("insufficient stock for product %s (%s) (available: %d, requested: %d)",
+			product.Name, variant.Label(), variant.Stock, item.Quantity)
+	}
+
+	return &domain.OrderItem{
+		ProductID:   product.ID,
+		VariantID:   &variant.ID,
+		ProductName: product.Name,
+		SKU:         variant.SKU,
+		Quantity:    item.Quantity,
+		Price:       variant.Price,
+	}, nil
+}
+
+// GetOrderDetail retrieves order details by ID. With userID set, the order
+// must belong to that user.
+func (uc *OrderUseCase) GetOrderDetail(ctx context.Context, orderID uint, userID *uint) (*domain.Order, error) {
 	orderRepo := persistence.NewOrderRepository(uc.db)
 
 	order, err := orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("order not found")
+			return nil, ErrOrderNotFound
 		}
 		return nil, err
 	}
+	if userID != nil && order.UserID != *userID {
+		return nil, ErrOrderNotFound
+	}
 
 	return order, nil
 }
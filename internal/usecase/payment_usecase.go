@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPaymentNotFound is returned for orders without a payment, such as
+	// orders fully covered by coupons
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrPaymentNotPending is returned when settling a payment twice
+	ErrPaymentNotPending = errors.New("payment is not pending")
+)
+
+type PaymentUseCase struct {
+	db       *gorm.DB
+	invoices *InvoiceUseCase
+	webhooks *WebhookUseCase
+	events   gateway.EventPublisher
+}
+
+func NewPaymentUseCase(db *gorm.DB, invoices *InvoiceUseCase, webhooks *WebhookUseCase, events gateway.EventPublisher) *PaymentUseCase {
+	return &PaymentUseCase{
+		db:       db,
+		invoices: invoices,
+		webhooks: webhooks,
+		events:   events,
+	}
+}
+
+// CompletePayment records that the payment of an order was received: the
+// payment completes, the order is paid and its invoice is issued, all in
+// one transaction
+func (uc *PaymentUseCase) CompletePayment(ctx context.Context, orderID uint) (*domain.Payment, error) {
+	var payment *domain.Payment
+	var events []gateway.Event
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		orderRepo := persistence.NewOrderRepository(tx)
+		paymentRepo := persistence.NewPaymentRepository(tx)
+
+		// The order lock serializes concurrent settlements
+		order, err := orderRepo.LockByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		payment, err = paymentRepo.FindByOrderID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+		if payment.Status != domain.PaymentStatusPending || order.Status == domain.OrderStatusCancelled {
+			return ErrPaymentNotPending
+		}
+
+		payment.MarkAsCompleted()
+		payment.Order = nil
+		if err := paymentRepo.Update(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+		events = []gateway.Event{newEvent(gateway.EventPaymentCompleted, payment)}
+		// Shipped orders may already be completed
+		if order.Status == domain.OrderStatusPending {
+			before := *order
+			if err := orderRepo.UpdateStatus(ctx, orderID, domain.OrderStatusPaid); err != nil {
+				return fmt.Errorf("failed to update order: %w", err)
+			}
+			order.Status = domain.OrderStatusPaid
+			if err := recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityOrder, orderID, &before, order); err != nil {
+				return err
+			}
+			events = append(events, newEvent(gateway.EventOrderPaid, order))
+		}
+
+		if _, err := uc.invoices.issue(ctx, tx, order, time.Now()); err != nil {
+			return err
+		}
+		return queueWebhooks(ctx, tx, uc.webhooks, events)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publishEvents(ctx, uc.events, events)
+	return payment, nil
+}
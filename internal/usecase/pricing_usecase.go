@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// TaxRateInput holds the editable fields of a tax rate
+type TaxRateInput struct {
+	Name       string
+	Region     string
+	CategoryID *uint
+	Rate       float64
+	Inclusive  bool
+}
+
+// ShippingRateInput holds the editable fields of a shipping rate
+type ShippingRateInput struct {
+	Region    string
+	Amount    float64
+	FreeAbove float64
+}
+
+// PricingUseCase manages the tax and shipping rates orders are priced with.
+// Changing them never affects existing orders.
+type PricingUseCase struct {
+	taxRateRepo      repository.TaxRateRepository
+	shippingRateRepo repository.ShippingRateRepository
+	categoryRepo     repository.CategoryRepository
+}
+
+func NewPricingUseCase(
+	taxRateRepo repository.TaxRateRepository,
+	shippingRateRepo repository.ShippingRateRepository,
+	categoryRepo repository.CategoryRepository,
+) *PricingUseCase {
+	return &PricingUseCase{
+		taxRateRepo:      taxRateRepo,
+		shippingRateRepo: shippingRateRepo,
+		categoryRepo:     categoryRepo,
+	}
+}
+
+// CreateTaxRate creates a new tax rate
+func (uc *PricingUseCase) CreateTaxRate(ctx context.Context, input TaxRateInput) (*domain.TaxRate, error) {
+	rate := &domain.TaxRate{}
+	applyTaxRateInput(rate, input)
+
+	if err := uc.validateTaxRate(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taxRateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// GetTaxRate retrieves a tax rate by ID
+func (uc *PricingUseCase) GetTaxRate(ctx context.Context, id uint) (*domain.TaxRate, error) {
+	rate, err := uc.taxRateRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tax rate not found")
+		}
+		return nil, err
+	}
+	return rate, nil
+}
+
+// ListTaxRates retrieves all tax rates
+func (uc *PricingUseCase) ListTaxRates(ctx context.Context) ([]domain.TaxRate, error) {
+	return uc.taxRateRepo.FindAll(ctx)
+}
+
+// UpdateTaxRate updates a tax rate. version, if set, must be the rate's current
+// version.
+func (uc *PricingUseCase) UpdateTaxRate(ctx context.Context, id uint, version *uint, input TaxRateInput) (*domain.TaxRate, error) {
+	rate, err := uc.GetTaxRate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(rate.Version, version); err != nil {
+		return nil, err
+	}
+
+	applyTaxRateInput(rate, input)
+	if err := uc.validateTaxRate(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taxRateRepo.Update(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// DeleteTaxRate deletes a tax rate. version, if set, must be the rate's current
+// version.
+func (uc *PricingUseCase) DeleteTaxRate(ctx context.Context, id uint, version *uint) error {
+	rate, err := uc.GetTaxRate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(rate.Version, version); err != nil {
+		return err
+	}
+	return uc.taxRateRepo.Delete(ctx, id, rate.Version)
+}
+
+// CreateShippingRate creates a new shipping rate
+func (uc *PricingUseCase) CreateShippingRate(ctx context.Context, input ShippingRateInput) (*domain.ShippingRate, error) {
+	rate := &domain.ShippingRate{}
+	applyShippingRateInput(rate, input)
+
+	if err := rate.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.shippingRateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// GetShippingRate retrieves a shipping rate by ID
+func (uc *PricingUseCase) GetShippingRate(ctx context.Context, id uint) (*domain.ShippingRate, error) {
+	rate, err := uc.shippingRateRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shipping rate not found")
+		}
+		return nil, err
+	}
+	return rate, nil
+}
+
+// ListShippingRates retrieves all shipping rates
+func (uc *PricingUseCase) ListShippingRates(ctx context.Context) ([]domain.ShippingRate, error) {
+	return uc.shippingRateRepo.FindAll(ctx)
+}
+
+// UpdateShippingRate updates a shipping rate. version, if set, must be the rate's current
+// version.
+func (uc *PricingUseCase) UpdateShippingRate(ctx context.Context, id uint, version *uint, input ShippingRateInput) (*domain.ShippingRate, error) {
+	rate, err := uc.GetShippingRate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(rate.Version, version); err != nil {
+		return nil, err
+	}
+
+	applyShippingRateInput(rate, input)
+	if err := rate.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.shippingRateRepo.Update(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// DeleteShippingRate deletes a shipping rate. version, if set, must be the rate's current
+// version.
+func (uc *PricingUseCase) DeleteShippingRate(ctx context.Context, id uint, version *uint) error {
+	rate, err := uc.GetShippingRate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(rate.Version, version); err != nil {
+		return err
+	}
+	return uc.shippingRateRepo.Delete(ctx, id, rate.Version)
+}
+
+func (uc *PricingUseCase) validateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	if err := rate.Validate(); err != nil {
+		return err
+	}
+	if rate.CategoryID == nil {
+		return nil
+	}
+	if _, err := uc.categoryRepo.FindByID(ctx, *rate.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("category with ID %d not found", *rate.CategoryID)
+		}
+		return err
+	}
+	return nil
+}
+
+func applyTaxRateInput(rate *domain.TaxRate, input TaxRateInput) {
+	rate.Name = strings.TrimSpace(input.Name)
+	rate.Region = domain.NormalizeRegion(input.Region)
+	rate.CategoryID = input.CategoryID
+	rate.Rate = input.Rate
+	rate.Inclusive = input.Inclusive
+}
+
+func applyShippingRateInput(rate *domain.ShippingRate, input ShippingRateInput) {
+	rate.Region = domain.NormalizeRegion(input.Region)
+	rate.Amount = input.Amount
+	rate.FreeAbove = input.FreeAbove
+}
@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultImportBatchSize is the number of rows written per transaction
+	DefaultImportBatchSize = 500
+	// maxReportedRowErrors caps the row errors kept in an import report;
+	// the counters stay exact
+	maxReportedRowErrors = 1000
+	exportBatchSize      = 500
+)
+
+// RowError describes why a row of an import file was rejected
+type RowError struct {
+	Line  int    `json:"line"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarizes a bulk product import
+type ImportReport struct {
+	Total           int        `json:"total"`
+	Created         int        `json:"created"`
+	Updated         int        `json:"updated"`
+	Failed          int        `json:"failed"`
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errors_truncated"`
+}
+
+func (r *ImportReport) fail(line int, sku string, err error) {
+	r.Failed++
+	if len(r.Errors) >= maxReportedRowErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, RowError{Line: line, SKU: sku, Error: err.Error()})
+}
+
+type importRow struct {
+	line   int
+	record ProductRecord
+}
+
+type ProductImportUseCase struct {
+	db          *gorm.DB
+	stockAlerts *StockAlertUseCase
+}
+
+func NewProductImportUseCase(db *gorm.DB, stockAlerts *StockAlertUseCase) *ProductImportUseCase {
+	return &ProductImportUseCase{
+		db:          db,
+		stockAlerts: stockAlerts,
+	}
+}
+
+// Import reads products from r and upserts them by SKU. Rows are validated
+// with Product.Validate and written in batches, each batch in its own
+// transaction; a failing batch is rolled back and all its rows reported.
+// Only one batch is held in memory at a time.
+func (uc *ProductImportUseCase) Import(ctx context.Context, r io.Reader, format CatalogFormat, batchSize int) (*ImportReport, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+
+	reader, err := newRecordReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := persistence.NewCategoryRepository(uc.db).FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		categoryIDs[category.ID] = true
+	}
+
+	report := &ImportReport{Errors: []RowError{}}
+	batch := make([]importRow, 0, batchSize)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		record, line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && line == 0 {
+			return report, fmt.Errorf("failed to read import file: %w", err)
+		}
+
+		report.Total++
+		if err == nil {
+			err = validateRecord(record, categoryIDs)
+		}
+		if err != nil {
+			report.fail(line, record.SKU, err)
+			continue
+		}
+
+		batch = append(batch, importRow{line: line, record: record})
+		if len(batch) == batchSize {
+			uc.importBatch(ctx, batch, report)
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		uc.importBatch(ctx, batch, report)
+	}
+
+	return report, nil
+}
+
+// Export streams the full catalog to w without loading it into memory. Each
+// batch is flushed through w if it can flush, so a failing client stops the
+// export at the next batch.
+func (uc *ProductImportUseCase) Export(ctx context.Context, w io.Writer, format CatalogFormat) error {
+	writer, err := newRecordWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	productRepo := persistence.NewProductRepository(uc.db)
+	err = productRepo.FindInBatches(ctx, exportBatchSize, func(products []domain.Product) error {
+		for i := range products {
+			if err := writer.Write(newProductRecord(&products[i])); err != nil {
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		return flushBatch(w)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func validateRecord(record ProductRecord, categoryIDs map[uint]bool) error {
+	if record.SKU == "" {
+		return errors.New("sku is required")
+	}
+	if record.CategoryID != nil && !categoryIDs[*record.CategoryID] {
+		return fmt.Errorf("category with ID %d not found", *record.CategoryID)
+	}
+	product := domain.Product{
+		SKU:         record.SKU,
+		Name:        record.Name,
+		Description: record.Description,
+		Price:       record.Price,
+		Stock:       record.Stock,
+	}
+	return product.Validate()
+}
+
+// importBatch upserts the rows of one batch in a single transaction and
+// audits every product it creates or updates
+func (uc *ProductImportUseCase) importBatch(ctx context.Context, batch []importRow, report *ImportReport) {
+	var created, updated int
+	var alertable []uint
+
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		productRepo := persistence.NewProductRepository(tx)
+		tagRepo := persistence.NewTagRepository(tx)
+		inventoryRepo := persistence.NewInventoryRepository(tx)
+
+		skus := make([]string, 0, len(batch))
+		var tagNames []string
+		for _, row := range batch {
+			skus = append(skus, row.record.SKU)
+			tagNames = append(tagNames, row.record.Tags...)
+		}
+
+		existing, err := productRepo.FindBySKUs(ctx, skus)
+		if err != nil {
+			return err
+		}
+		bySKU := make(map[string]*domain.Product, len(existing))
+		for i := range existing {
+			bySKU[existing[i].SKU] = &existing[i]
+		}
+
+		tags, err := tagRepo.FindOrCreate(ctx, domain.NormalizeTagNames(tagNames))
+		if err != nil {
+			return err
+		}
+		tagsByName := make(map[string]domain.Tag, len(tags))
+		for _, tag := range tags {
+			tagsByName[tag.Name] = tag
+		}
+
+		for _, row := range batch {
+			record := row.record
+			product, ok := bySKU[record.SKU]
+			var before any
+			if ok {
+				before = auditedProduct(product)
+			} else {
+				product = &domain.Product{SKU: record.SKU}
+			}
+			product.Name = record.Name
+			product.Description = record.Description
+			product.Price = record.Price
+			product.Stock = record.Stock
+			product.CategoryID = record.CategoryID
+
+			if ok {
+				err = productRepo.Update(ctx, product)
+				updated++
+			} else {
+				err = productRepo.Create(ctx, product)
+				bySKU[record.SKU] = product
+				created++
+			}
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.line, err)
+			}
+
+			movement := newMovement(ctx, domain.MovementImport, product.ID, nil, fmt.Sprintf("bulk import line %d", row.line))
+			if err := inventoryRepo.SetLevel(ctx, movement, record.Stock); err != nil {
+				return fmt.Errorf("line %d: %w", row.line, err)
+			}
+			if product.ReorderThreshold > 0 {
+				alertable = append(alertable, product.ID)
+			}
+
+			if record.Tags != nil {
+				// Sorted like the tags of the products read, so unchanged
+				// tags don't show up in the audit log
+				names := domain.NormalizeTagNames(record.Tags)
+				slices.Sort(names)
+				var productTags []domain.Tag
+				for _, name := range names {
+					productTags = append(productTags, tagsByName[name])
+				}
+				if err := productRepo.ReplaceTags(ctx, product, productTags); err != nil {
+					return fmt.Errorf("line %d: %w", row.line, err)
+				}
+			}
+
+			action := domain.AuditActionCreate
+			if ok {
+				action = domain.AuditActionUpdate
+			}
+			if err := recordAudit(ctx, tx, action, domain.AuditEntityProduct, product.ID, before, auditedProduct(product)); err != nil {
+				return fmt.Errorf("line %d: %w", row.line, err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		for _, row := range batch {
+			report.fail(row.line, row.record.SKU, fmt.Errorf("batch rolled back: %w", err))
+		}
+		return
+	}
+
+	report.Created += created
+	report.Updated += updated
+	uc.stockAlerts.CheckProducts(ctx, alertable...)
+}
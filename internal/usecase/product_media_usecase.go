@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/pkg/imaging"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrImageTooLarge is returned for uploads above the configured size limit
+	ErrImageTooLarge = errors.New("image exceeds the maximum upload size")
+	// ErrImageDimensions is returned for images above the configured pixel
+	// count
+	ErrImageDimensions = errors.New("image exceeds the maximum dimensions")
+	// ErrUnsupportedImage is returned for uploads that are not a supported image
+	ErrUnsupportedImage = errors.New("unsupported image type, expected JPEG, PNG, GIF or WebP")
+)
+
+// MediaPolicy limits product image uploads
+type MediaPolicy struct {
+	MaxUploadSize int64
+	MaxPixels     int64 // Width times height
+	ThumbnailSize int
+}
+
+type ProductMediaUseCase struct {
+	productRepo repository.ProductRepository
+	imageRepo   repository.ProductImageRepository
+	storage     gateway.BlobStorage
+	policy      MediaPolicy
+}
+
+func NewProductMediaUseCase(
+	productRepo repository.ProductRepository,
+	imageRepo repository.ProductImageRepository,
+	storage gateway.BlobStorage,
+	policy MediaPolicy,
+) *ProductMediaUseCase {
+	return &ProductMediaUseCase{
+		productRepo: productRepo,
+		imageRepo:   imageRepo,
+		storage:     storage,
+		policy:      policy,
+	}
+}
+
+// UploadImage stores an image and its thumbnail and appends it to the
+// product's image list. The content type is sniffed from the data.
+func (uc *ProductMediaUseCase) UploadImage(ctx context.Context, productID uint, r io.Reader, altText string) (*domain.ProductImage, error) {
+	if _, err := uc.findProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, uc.policy.MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(len(data)) > uc.policy.MaxUploadSize {
+		return nil, ErrImageTooLarge
+	}
+
+	img, err := imaging.Decode(data, uc.policy.MaxPixels)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, ErrImageDimensions
+	}
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	thumb, thumbType, err := img.Thumbnail(uc.policy.ThumbnailSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate thumbnail: %w", err)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("products/%d/%s%s", productID, name, imaging.Extensions[img.ContentType])
+	thumbKey := fmt.Sprintf("products/%d/%s_thumb%s", productID, name, imaging.Extensions[thumbType])
+
+	if err := uc.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), img.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := uc.storage.Put(ctx, thumbKey, bytes.NewReader(thumb), int64(len(thumb)), thumbType); err != nil {
+		uc.deleteBlobs(ctx, key)
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	position, err := uc.imageRepo.NextPosition(ctx, productID)
+	if err != nil {
+		uc.deleteBlobs(ctx, key, thumbKey)
+		return nil, err
+	}
+
+	image := &domain.ProductImage{
+		ProductID:    productID,
+		Position:     position,
+		StorageKey:   key,
+		ThumbnailKey: thumbKey,
+		URL:          uc.storage.URL(key),
+		ThumbnailURL: uc.storage.URL(thumbKey),
+		ContentType:  img.ContentType,
+		Size:         int64(len(data)),
+		Width:        img.Width,
+		Height:       img.Height,
+		AltText:      altText,
+	}
+
+	if err := image.Validate(); err != nil {
+		uc.deleteBlobs(ctx, key, thumbKey)
+		return nil, err
+	}
+	if err := uc.imageRepo.Create(ctx, image); err != nil {
+		uc.deleteBlobs(ctx, key, thumbKey)
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// ListImages retrieves the images of a product in display order
+func (uc *ProductMediaUseCase) ListImages(ctx context.Context, productID uint) ([]domain.ProductImage, error) {
+	if _, err := uc.findProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	return uc.imageRepo.FindByProductID(ctx, productID)
+}
+
+// UpdateImage changes the alt text of an image
+func (uc *ProductMediaUseCase) UpdateImage(ctx context.Context, productID, imageID uint, altText string) (*domain.ProductImage, error) {
+	image, err := uc.findImage(ctx, productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	image.AltText = altText
+	if err := uc.imageRepo.Update(ctx, image); err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// ReorderImages sets the display order of a product's images. imageIDs must
+// list every image of the product exactly once.
+func (uc *ProductMediaUseCase) ReorderImages(ctx context.Context, productID uint, imageIDs []uint) ([]domain.ProductImage, error) {
+	images, err := uc.ListImages(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[uint]bool, len(images))
+	for _, image := range images {
+		existing[image.ID] = true
+	}
+	if len(imageIDs) != len(images) {
+		return nil, errors.New("image order must list every image of the product exactly once")
+	}
+	for _, id := range imageIDs {
+		if !existing[id] {
+			return nil, errors.New("image order must list every image of the product exactly once")
+		}
+		delete(existing, id)
+	}
+
+	if err := uc.imageRepo.Reorder(ctx, productID, imageIDs); err != nil {
+		return nil, err
+	}
+
+	return uc.imageRepo.FindByProductID(ctx, productID)
+}
+
+// DeleteImage removes an image and its stored files
+func (uc *ProductMediaUseCase) DeleteImage(ctx context.Context, productID, imageID uint) error {
+	image, err := uc.findImage(ctx, productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.imageRepo.Delete(ctx, image.ID); err != nil {
+		return err
+	}
+
+	uc.deleteBlobs(ctx, image.StorageKey, image.ThumbnailKey)
+	return nil
+}
+
+// StorageKeys returns the stored files of all images of a product, even a
+// soft-deleted one. Used to clean up storage when a product is purged.
+func (uc *ProductMediaUseCase) StorageKeys(ctx context.Context, productID uint) ([]string, error) {
+	images, err := uc.imageRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, 2*len(images))
+	for _, image := range images {
+		keys = append(keys, image.StorageKey, image.ThumbnailKey)
+	}
+	return keys, nil
+}
+
+// DeleteFiles removes stored files. Failures are logged, not returned, as
+// the records referencing the files are already gone.
+func (uc *ProductMediaUseCase) DeleteFiles(ctx context.Context, keys []string) {
+	uc.deleteBlobs(ctx, keys...)
+}
+
+func (uc *ProductMediaUseCase) findProduct(ctx context.Context, productID uint) (*domain.Product, error) {
+	product, err := uc.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+func (uc *ProductMediaUseCase) findImage(ctx context.Context, productID, imageID uint) (*domain.ProductImage, error) {
+	image, err := uc.imageRepo.FindByID(ctx, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("image not found")
+		}
+		return nil, err
+	}
+	if image.ProductID != productID {
+		return nil, errors.New("image not found")
+	}
+	return image, nil
+}
+
+func (uc *ProductMediaUseCase) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := uc.storage.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "failed to delete stored file", "key", key, "error", err)
+		}
+	}
+}
+
+// randomName returns an unguessable file name so stored objects can be
+// cached forever and not enumerated
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate file name: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/pkg/mergepatch"
+	"gorm.io/gorm"
+)
+
+// productDocument is the editable part of a product that merge patches apply
+// to. Stock is a pointer so that a patch removing it fails instead of
+// zeroing the stock.
+type productDocument struct {
+	SKU              string   `json:"sku"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Price            float64  `json:"price"`
+	Stock            *int     `json:"stock"`
+	CategoryID       *uint    `json:"category_id"`
+	Tags             []string `json:"tags"`
+	ReorderThreshold int      `json:"reorder_threshold"`
+}
+
+func newProductDocument(product *domain.Product) productDocument {
+	stock := product.Stock
+	return productDocument{
+		SKU:              product.SKU,
+		Name:             product.Name,
+		Description:      product.Description,
+		Price:            product.Price,
+		Stock:            &stock,
+		CategoryID:       product.CategoryID,
+		Tags:             tagNames(product.Tags),
+		ReorderThreshold: product.ReorderThreshold,
+	}
+}
+
+// PatchProduct applies a JSON Merge Patch (RFC 7396) to the editable fields
+// of a product: sku, name, description, price, stock, category_id, tags and
+// reorder_threshold. Fields missing from the patch are kept and null clears
+// a field; stock can't be cleared. The merged product is validated and only
+// the changed columns are written. version works as for UpdateProduct.
+func (uc *ProductUseCase) PatchProduct(ctx context.Context, id uint, version *uint, patch []byte) (*domain.Product, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(patch), []byte("{")) {
+		return nil, fmt.Errorf("%w: a product patch must be a JSON object", mergepatch.ErrInvalidPatch)
+	}
+
+	var patched *domain.Product
+	changed := false
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		product, err := txUC.GetProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(product.Version, version); err != nil {
+			return err
+		}
+		before := auditedProduct(product)
+
+		next, err := mergeProductPatch(newProductDocument(product), patch)
+		if err != nil {
+			return err
+		}
+
+		// Collect the changed columns while applying them, so the merged
+		// product can be validated as a whole
+		fields := make(map[string]any)
+		if next.SKU != product.SKU {
+			fields["sku"] = next.SKU
+			product.SKU = next.SKU
+		}
+		if next.Name != product.Name {
+			fields["name"] = next.Name
+			product.Name = next.Name
+		}
+		if next.Description != product.Description {
+			fields["description"] = next.Description
+			product.Description = next.Description
+		}
+		if next.Price != product.Price {
+			fields["price"] = next.Price
+			product.Price = next.Price
+		}
+		if !equalIDs(next.CategoryID, product.CategoryID) {
+			fields["category_id"] = next.CategoryID
+			product.CategoryID = next.CategoryID
+			product.Category = nil
+		}
+		if next.ReorderThreshold != product.ReorderThreshold {
+			fields["reorder_threshold"] = next.ReorderThreshold
+			product.ReorderThreshold = next.ReorderThreshold
+		}
+		stockChanged := *next.Stock != product.Stock
+		product.Stock = *next.Stock
+		tags := domain.NormalizeTagNames(next.Tags)
+		tagsChanged := !sameTagNames(tags, product.Tags)
+
+		if err := product.Validate(); err != nil {
+			return err
+		}
+		if _, ok := fields["category_id"]; ok {
+			if err := txUC.checkCategory(ctx, product.CategoryID); err != nil {
+				return err
+			}
+		}
+
+		if len(fields) == 0 && !stockChanged && !tagsChanged {
+			patched = product
+			return nil
+		}
+		changed = true
+
+		// Stock and tag changes also count as a new version of the product
+		if err := txUC.productRepo.UpdateFields(ctx, product.ID, product.Version, fields); err != nil {
+			return err
+		}
+		if stockChanged {
+			if err := txUC.setStock(ctx, product.ID, nil, product.Stock, "product update"); err != nil {
+				return err
+			}
+		}
+		if tagsChanged {
+			if err := txUC.setTags(ctx, product, tags); err != nil {
+				return err
+			}
+		}
+
+		if patched, err = txUC.GetProduct(ctx, product.ID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityProduct, id, before, auditedProduct(patched))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		uc.stockAlerts.CheckProducts(ctx, id)
+	}
+	return patched, nil
+}
+
+// mergeProductPatch applies patch to doc. Fields that can't be patched and
+// values of the wrong type are rejected.
+func mergeProductPatch(doc productDocument, patch []byte) (*productDocument, error) {
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergepatch.Apply(original, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var next productDocument
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&next); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%w: %s has the wrong type", mergepatch.ErrInvalidPatch, typeErr.Field)
+		}
+		return nil, fmt.Errorf("%w: %s", mergepatch.ErrInvalidPatch, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if next.Stock == nil {
+		return nil, fmt.Errorf("%w: stock cannot be removed", mergepatch.ErrInvalidPatch)
+	}
+	return &next, nil
+}
+
+func tagNames(tags []domain.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// sameTagNames reports whether tags has exactly the given names, in any order
+func sameTagNames(names []string, tags []domain.Tag) bool {
+	current := tagNames(tags)
+	wanted := slices.Clone(names)
+	slices.Sort(current)
+	slices.Sort(wanted)
+	return slices.Equal(current, wanted)
+}
+
+func equalIDs(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/example/clean-arch-template/pkg/mergepatch"
+)
+
+func testProductDocument() productDocument {
+	stock, category := 10, uint(3)
+	return productDocument{
+		SKU:              "MUG-1",
+		Name:             "Mug",
+		Description:      "A mug",
+		Price:            9.5,
+		Stock:            &stock,
+		CategoryID:       &category,
+		Tags:             []string{"kitchen", "sale"},
+		ReorderThreshold: 2,
+	}
+}
+
+func TestMergeProductPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  func(doc *productDocument)
+	}{
+		{"empty patch keeps everything", `{}`, func(doc *productDocument) {}},
+		{"absent fields are kept", `{"price":12.5}`, func(doc *productDocument) {
+			doc.Price = 12.5
+		}},
+		{"several fields", `{"name":"Big mug","reorder_threshold":5}`, func(doc *productDocument) {
+			doc.Name = "Big mug"
+			doc.ReorderThreshold = 5
+		}},
+		{"null clears the category", `{"category_id":null}`, func(doc *productDocument) {
+			doc.CategoryID = nil
+		}},
+		{"null clears the description", `{"description":null}`, func(doc *productDocument) {
+			doc.Description = ""
+		}},
+		{"tags are replaced whole", `{"tags":["gift"]}`, func(doc *productDocument) {
+			doc.Tags = []string{"gift"}
+		}},
+		{"null clears the tags", `{"tags":null}`, func(doc *productDocument) {
+			doc.Tags = nil
+		}},
+		{"stock can change", `{"stock":0}`, func(doc *productDocument) {
+			stock := 0
+			doc.Stock = &stock
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeProductPatch(testProductDocument(), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("mergeProductPatch: %v", err)
+			}
+			want := testProductDocument()
+			tt.want(&want)
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("got %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestMergeProductPatchRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"removing stock", `{"stock":null}`},
+		{"unknown field", `{"colour":"red"}`},
+		{"read-only field", `{"id":7}`},
+		{"wrong type", `{"price":"cheap"}`},
+		{"nested object", `{"name":{"en":"Mug"}}`},
+		{"negative category", `{"category_id":-1}`},
+		{"trailing data", `{"price":1} {"price":2}`},
+		{"not JSON", `{"price":`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mergeProductPatch(testProductDocument(), []byte(tt.patch)); !errors.Is(err, mergepatch.ErrInvalidPatch) {
+				t.Errorf("err = %v, want ErrInvalidPatch", err)
+			}
+		})
+	}
+}
+
+func TestPatchProductRequiresAnObject(t *testing.T) {
+	// Rejected before the product is read, so no database is needed
+	uc := &ProductUseCase{}
+	for _, patch := range []string{`null`, `["name"]`, `"Mug"`, `1`, ``} {
+		if _, err := uc.PatchProduct(context.Background(), 1, nil, []byte(patch)); !errors.Is(err, mergepatch.ErrInvalidPatch) {
+			t.Errorf("patch %q: err = %v, want ErrInvalidPatch", patch, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+)
+
+// CatalogFormat is a file format for bulk product import and export
+type CatalogFormat string
+
+const (
+	CatalogFormatCSV   CatalogFormat = "csv"
+	CatalogFormatJSONL CatalogFormat = "jsonl"
+)
+
+// ParseCatalogFormat accepts a format name or a matching content type
+func ParseCatalogFormat(s string) (CatalogFormat, error) {
+	mediaType, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "csv", "text/csv":
+		return CatalogFormatCSV, nil
+	case "jsonl", "ndjson", "application/jsonl", "application/x-ndjson":
+		return CatalogFormatJSONL, nil
+	}
+	return "", fmt.Errorf("unsupported catalog format %q, expected csv or jsonl", s)
+}
+
+// ContentType returns the MIME type of the format
+func (f CatalogFormat) ContentType() string {
+	if f == CatalogFormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// ProductRecord is one product in an import or export file. In CSV files the
+// tags are joined with "|"; an empty tags column leaves existing tags alone.
+type ProductRecord struct {
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price"`
+	Stock       int      `json:"stock"`
+	CategoryID  *uint    `json:"category_id"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+var catalogColumns = []string{"sku", "name", "description", "price", "stock", "category_id", "tags"}
+
+const csvTagSeparator = "|"
+
+func newProductRecord(p *domain.Product) ProductRecord {
+	tags := make([]string, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return ProductRecord{
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Stock:       p.Stock,
+		CategoryID:  p.CategoryID,
+		Tags:        tags,
+	}
+}
+
+// recordReader reads one record at a time so files of any size can be
+// imported in constant memory. Read returns the line number of the record;
+// a non-nil error other than io.EOF only concerns that record.
+type recordReader interface {
+	Read() (ProductRecord, int, error)
+}
+
+func newRecordReader(r io.Reader, format CatalogFormat) (recordReader, error) {
+	if format == CatalogFormatJSONL {
+		return &jsonlRecordReader{r: bufio.NewReader(r)}, nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"sku", "name", "price", "stock"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %q column", required)
+		}
+	}
+	return &csvRecordReader{r: cr, columns: columns}, nil
+}
+
+type csvRecordReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func (c *csvRecordReader) Read() (ProductRecord, int, error) {
+	fields, err := c.r.Read()
+	if err == io.EOF {
+		return ProductRecord{}, 0, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return ProductRecord{}, parseErr.StartLine, parseErr.Err
+		}
+		return ProductRecord{}, 0, err
+	}
+	line, _ := c.r.FieldPos(0)
+
+	field := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	record := ProductRecord{
+		SKU:         field("sku"),
+		Name:        field("name"),
+		Description: field("description"),
+	}
+	if record.Price, err = strconv.ParseFloat(field("price"), 64); err != nil {
+		return record, line, fmt.Errorf("invalid price %q", field("price"))
+	}
+	if record.Stock, err = strconv.Atoi(field("stock")); err != nil {
+		return record, line, fmt.Errorf("invalid stock %q", field("stock"))
+	}
+	if v := field("category_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			return record, line, fmt.Errorf("invalid category_id %q", v)
+		}
+		categoryID := uint(id)
+		record.CategoryID = &categoryID
+	}
+	if v := field("tags"); v != "" {
+		record.Tags = strings.Split(v, csvTagSeparator)
+	}
+	return record, line, nil
+}
+
+type jsonlRecordReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (j *jsonlRecordReader) Read() (ProductRecord, int, error) {
+	for {
+		data, err := j.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return ProductRecord{}, 0, err
+		}
+		j.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var record ProductRecord
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&record); err != nil {
+			return record, j.line, fmt.Errorf("invalid json: %w", err)
+		}
+		return record, j.line, nil
+	}
+}
+
+// recordWriter writes records in a streaming fashion; Flush must be called
+// once all records are written
+type recordWriter interface {
+	Write(record ProductRecord) error
+	Flush() error
+}
+
+func newRecordWriter(w io.Writer, format CatalogFormat) (recordWriter, error) {
+	if format == CatalogFormatJSONL {
+		bw := bufio.NewWriter(w)
+		return &jsonlRecordWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(catalogColumns); err != nil {
+		return nil, err
+	}
+	return &csvRecordWriter{w: cw}, nil
+}
+
+type csvRecordWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRecordWriter) Write(record ProductRecord) error {
+	categoryID := ""
+	if record.CategoryID != nil {
+		categoryID = strconv.FormatUint(uint64(*record.CategoryID), 10)
+	}
+	return c.w.Write([]string{
+		record.SKU,
+		record.Name,
+		record.Description,
+		strconv.FormatFloat(record.Price, 'f', -1, 64),
+		strconv.Itoa(record.Stock),
+		categoryID,
+		strings.Join(record.Tags, csvTagSeparator),
+	})
+}
+
+func (c *csvRecordWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlRecordWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlRecordWriter) Write(record ProductRecord) error {
+	return j.enc.Encode(record)
+}
+
+func (j *jsonlRecordWriter) Flush() error {
+	return j.w.Flush()
+}
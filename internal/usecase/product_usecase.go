@@ -3,40 +3,141 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
 	"github.com/example/clean-arch-template/internal/repository"
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrProductNotFound is returned for products that don't exist or are
+	// deleted
+	ErrProductNotFound = errors.New("product not found")
+	// ErrDeletedProductNotFound is returned when restoring or purging a
+	// product that isn't deleted
+	ErrDeletedProductNotFound = errors.New("deleted product not found")
+)
+
+// ProductInput holds the editable fields of a product. A nil Tags slice
+// leaves the product's tags unchanged; an empty one removes them all.
+type ProductInput struct {
+	SKU         string
+	Name        string
+	Description string
+	Price       float64
+	Stock       int
+	CategoryID  *uint
+	Tags        []string
+	// ReorderThreshold is the stock level that raises a low-stock alert; 0
+	// disables alerts
+	ReorderThreshold int
+}
+
+// VariantInput holds the editable fields of a product variant
+type VariantInput struct {
+	SKU   string
+	Size  string
+	Color string
+	Price float64
+	Stock int
+}
+
+// ProductUseCase also receives the DB instance so that every change commits
+// together with its audit log entry
 type ProductUseCase struct {
-	productRepo repository.ProductRepository
+	db            *gorm.DB
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+	tagRepo       repository.TagRepository
+	variantRepo   repository.ProductVariantRepository
+	inventoryRepo repository.InventoryRepository
+	stockAlerts   *StockAlertUseCase
 }
 
-func NewProductUseCase(productRepo repository.ProductRepository) *ProductUseCase {
+func NewProductUseCase(
+	db *gorm.DB,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	tagRepo repository.TagRepository,
+	variantRepo repository.ProductVariantRepository,
+	inventoryRepo repository.InventoryRepository,
+	stockAlerts *StockAlertUseCase,
+) *ProductUseCase {
 	return &ProductUseCase{
-		productRepo: productRepo,
+		db:            db,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+		tagRepo:       tagRepo,
+		variantRepo:   variantRepo,
+		inventoryRepo: inventoryRepo,
+		stockAlerts:   stockAlerts,
 	}
 }
 
+// withTx returns a copy of the use case whose repositories work in tx
+func (uc *ProductUseCase) withTx(tx *gorm.DB) *ProductUseCase {
+	txUC := *uc
+	txUC.productRepo = persistence.NewProductRepository(tx)
+	txUC.categoryRepo = persistence.NewCategoryRepository(tx)
+	txUC.tagRepo = persistence.NewTagRepository(tx)
+	txUC.variantRepo = persistence.NewProductVariantRepository(tx)
+	txUC.inventoryRepo = persistence.NewInventoryRepository(tx)
+	return &txUC
+}
+
 // CreateProduct creates a new product
-func (uc *ProductUseCase) CreateProduct(ctx context.Context, name, description string, price float64, stock int) (*domain.Product, error) {
+func (uc *ProductUseCase) CreateProduct(ctx context.Context, input ProductInput) (*domain.Product, error) {
 	product := &domain.Product{
-		Name:        name,
-		Description: description,
-		Price:       price,
-		Stock:       stock,
+		SKU:         input.SKU,
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		Stock:       input.Stock,
+		CategoryID:  input.CategoryID,
+
+		ReorderThreshold: input.ReorderThreshold,
 	}
 
 	if err := product.Validate(); err != nil {
 		return nil, err
 	}
+	if err := uc.checkCategory(ctx, product.CategoryID); err != nil {
+		return nil, err
+	}
+
+	var created *domain.Product
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		if err := txUC.productRepo.Create(ctx, product); err != nil {
+			return err
+		}
+
+		// Initial stock is the product's first ledger entry
+		if err := txUC.setStock(ctx, product.ID, nil, input.Stock, "initial stock"); err != nil {
+			return err
+		}
+
+		if input.Tags != nil {
+			if err := txUC.setTags(ctx, product, input.Tags); err != nil {
+				return err
+			}
+		}
 
-	if err := uc.productRepo.Create(ctx, product); err != nil {
+		var err error
+		if created, err = txUC.GetProduct(ctx, product.ID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionCreate, domain.AuditEntityProduct, product.ID, nil, auditedProduct(created))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return product, nil
+	uc.stockAlerts.CheckProducts(ctx, product.ID)
+	return created, nil
 }
 
 // GetProduct retrieves a product by ID
@@ -44,7 +145,7 @@ func (uc *ProductUseCase) GetProduct(ctx context.Context, id uint) (*domain.Prod
 	product, err := uc.productRepo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+			return nil, ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -56,41 +157,367 @@ func (uc *ProductUseCase) ListProducts(ctx context.Context) ([]domain.Product, e
 	return uc.productRepo.FindAll(ctx)
 }
 
-// UpdateProduct updates an existing product
-func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id uint, name, description string, price float64, stock int) (*domain.Product, error) {
-	product, err := uc.productRepo.FindByID(ctx, id)
+// SearchProducts lists products filtered by tag and/or category. Filtering
+// by category includes products of all its subcategories.
+func (uc *ProductUseCase) SearchProducts(ctx context.Context, categoryID *uint, tag string) ([]domain.Product, error) {
+	filter := repository.ProductFilter{}
+	if tags := domain.NormalizeTagNames([]string{tag}); len(tags) > 0 {
+		filter.Tag = tags[0]
+	}
+
+	if categoryID != nil {
+		ids, err := uc.categoryRepo.FindSubtreeIDs(ctx, *categoryID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, errors.New("category not found")
+		}
+		filter.CategoryIDs = ids
+	}
+
+	return uc.productRepo.FindByFilter(ctx, filter)
+}
+
+// UpdateProduct updates an existing product. version, if set, is the version
+// the caller based the change on; if the product changed since, the update
+// fails with domain.ErrVersionConflict. Without it, concurrent updates still
+// can't overwrite each other unnoticed.
+func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id uint, version *uint, input ProductInput) (*domain.Product, error) {
+	var updated *domain.Product
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		product, err := txUC.GetProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(product.Version, version); err != nil {
+			return err
+		}
+		before := auditedProduct(product)
+
+		product.SKU = input.SKU
+		product.Name = input.Name
+		product.Description = input.Description
+		product.Price = input.Price
+		product.Stock = input.Stock
+		product.CategoryID = input.CategoryID
+		product.Category = nil
+		product.ReorderThreshold = input.ReorderThreshold
+
+		if err := product.Validate(); err != nil {
+			return err
+		}
+		if err := txUC.checkCategory(ctx, product.CategoryID); err != nil {
+			return err
+		}
+
+		if err := txUC.productRepo.Update(ctx, product); err != nil {
+			return err
+		}
+		if err := txUC.setStock(ctx, product.ID, nil, input.Stock, "product update"); err != nil {
+			return err
+		}
+
+		if input.Tags != nil {
+			if err := txUC.setTags(ctx, product, input.Tags); err != nil {
+				return err
+			}
+		}
+
+		if updated, err = txUC.GetProduct(ctx, product.ID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityProduct, id, before, auditedProduct(updated))
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+		return nil, err
+	}
+
+	// Also re-evaluates a changed reorder threshold
+	uc.stockAlerts.CheckProducts(ctx, id)
+	return updated, nil
+}
+
+// DeleteProduct soft-deletes a product. Order history keeps resolving it.
+// version, if set, must be the product's current version.
+func (uc *ProductUseCase) DeleteProduct(ctx context.Context, id uint, version *uint) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		product, err := txUC.GetProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(product.Version, version); err != nil {
+			return err
 		}
+
+		if err := txUC.productRepo.Delete(ctx, id, product.Version); err != nil {
+			return err
+		}
+
+		deleted, err := txUC.productRepo.FindDeletedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		after := *product
+		after.DeletedAt = deleted.DeletedAt
+		return recordAudit(ctx, tx, domain.AuditActionDelete, domain.AuditEntityProduct, id, auditedProduct(product), auditedProduct(&after))
+	})
+}
+
+// RestoreProduct undoes a soft delete (admin operation)
+func (uc *ProductUseCase) RestoreProduct(ctx context.Context, id uint) (*domain.Product, error) {
+	var restored *domain.Product
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		deleted, err := txUC.productRepo.FindDeletedByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDeletedProductNotFound
+			}
+			return err
+		}
+
+		if err := txUC.productRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+
+		if restored, err = txUC.GetProduct(ctx, id); err != nil {
+			return err
+		}
+		before := *restored
+		before.DeletedAt = deleted.DeletedAt
+		return recordAudit(ctx, tx, domain.AuditActionRestore, domain.AuditEntityProduct, id, auditedProduct(&before), auditedProduct(restored))
+	})
+	if err != nil {
 		return nil, err
 	}
+	return restored, nil
+}
 
-	product.Name = name
-	product.Description = description
-	product.Price = price
-	product.Stock = stock
+// PurgeProduct permanently removes a soft-deleted product (admin operation).
+// Products that were ever ordered must stay for order history.
+func (uc *ProductUseCase) PurgeProduct(ctx context.Context, id uint) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
 
-	if err := product.Validate(); err != nil {
+		product, err := txUC.productRepo.FindDeletedByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDeletedProductNotFound
+			}
+			return err
+		}
+
+		ordered, err := txUC.productRepo.HasOrderItems(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ordered {
+			return errors.New("product has order history and cannot be purged")
+		}
+
+		if err := txUC.productRepo.Purge(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionPurge, domain.AuditEntityProduct, id, auditedProduct(product), nil)
+	})
+}
+
+// ListTags retrieves all tags in use
+func (uc *ProductUseCase) ListTags(ctx context.Context) ([]domain.Tag, error) {
+	return uc.tagRepo.FindAll(ctx)
+}
+
+// ListVariants retrieves the variants of a product
+func (uc *ProductUseCase) ListVariants(ctx context.Context, productID uint) ([]domain.ProductVariant, error) {
+	if _, err := uc.GetProduct(ctx, productID); err != nil {
 		return nil, err
 	}
+	return uc.variantRepo.FindByProductID(ctx, productID)
+}
 
-	if err := uc.productRepo.Update(ctx, product); err != nil {
+// CreateVariant adds a variant to a product. Once a product has variants
+// it can only be ordered through them.
+func (uc *ProductUseCase) CreateVariant(ctx context.Context, productID uint, input VariantInput) (*domain.ProductVariant, error) {
+	if _, err := uc.GetProduct(ctx, productID); err != nil {
 		return nil, err
 	}
 
-	return product, nil
+	variant := &domain.ProductVariant{
+		ProductID: productID,
+		SKU:       input.SKU,
+		Size:      input.Size,
+		Color:     input.Color,
+		Price:     input.Price,
+		Stock:     input.Stock,
+	}
+
+	if err := variant.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		if err := txUC.variantRepo.Create(ctx, variant); err != nil {
+			return err
+		}
+		if err := txUC.setStock(ctx, productID, &variant.ID, input.Stock, "initial stock"); err != nil {
+			return err
+		}
+		variant.Stock = input.Stock
+
+		return recordAudit(ctx, tx, domain.AuditActionCreate, domain.AuditEntityVariant, variant.ID, nil, variant)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.stockAlerts.CheckProducts(ctx, productID)
+	return variant, nil
+}
+
+// UpdateVariant updates a variant of a product. version, if set, must be the
+// variant's current version.
+func (uc *ProductUseCase) UpdateVariant(ctx context.Context, productID, variantID uint, version *uint, input VariantInput) (*domain.ProductVariant, error) {
+	var variant *domain.ProductVariant
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		var err error
+		if variant, err = txUC.findVariant(ctx, productID, variantID); err != nil {
+			return err
+		}
+		if err := checkVersion(variant.Version, version); err != nil {
+			return err
+		}
+		before := *variant
+
+		variant.SKU = input.SKU
+		variant.Size = input.Size
+		variant.Color = input.Color
+		variant.Price = input.Price
+		variant.Stock = input.Stock
+
+		if err := variant.Validate(); err != nil {
+			return err
+		}
+
+		if err := txUC.variantRepo.Update(ctx, variant); err != nil {
+			return err
+		}
+		if err := txUC.setStock(ctx, productID, &variant.ID, input.Stock, "variant update"); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityVariant, variant.ID, &before, variant)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.stockAlerts.CheckProducts(ctx, productID)
+	return variant, nil
 }
 
-// DeleteProduct deletes a product
-func (uc *ProductUseCase) DeleteProduct(ctx context.Context, id uint) error {
-	_, err := uc.productRepo.FindByID(ctx, id)
+// DeleteVariant soft-deletes a variant of a product. version, if set, must
+// be the variant's current version.
+func (uc *ProductUseCase) DeleteVariant(ctx context.Context, productID, variantID uint, version *uint) error {
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		variant, err := txUC.findVariant(ctx, productID, variantID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(variant.Version, version); err != nil {
+			return err
+		}
+		if err := txUC.variantRepo.Delete(ctx, variantID, variant.Version); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionDelete, domain.AuditEntityVariant, variantID, variant, nil)
+	})
 	if err != nil {
+		return err
+	}
+
+	// The product may be sold on its own stock again
+	uc.stockAlerts.CheckProducts(ctx, productID)
+	return nil
+}
+
+func (uc *ProductUseCase) findVariant(ctx context.Context, productID, variantID uint) (*domain.ProductVariant, error) {
+	variant, err := uc.variantRepo.FindByID(ctx, variantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("variant not found")
+		}
+		return nil, err
+	}
+	if variant.ProductID != productID {
+		return nil, errors.New("variant not found")
+	}
+	return variant, nil
+}
+
+// checkVersion fails with domain.ErrVersionConflict unless the caller expects
+// no particular version or the current one
+func checkVersion(current uint, expected *uint) error {
+	if expected != nil && *expected != current {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+func (uc *ProductUseCase) checkCategory(ctx context.Context, categoryID *uint) error {
+	if categoryID == nil {
+		return nil
+	}
+	if _, err := uc.categoryRepo.FindByID(ctx, *categoryID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("product not found")
+			return fmt.Errorf("category with ID %d not found", *categoryID)
 		}
 		return err
 	}
+	return nil
+}
+
+// setStock records a manual adjustment bringing stock to level, if needed.
+// Callers check stock alerts once the change is committed.
+func (uc *ProductUseCase) setStock(ctx context.Context, productID uint, variantID *uint, level int, reason string) error {
+	movement := newMovement(ctx, domain.MovementAdjustment, productID, variantID, reason)
+	if err := uc.inventoryRepo.SetLevel(ctx, movement, level); err != nil {
+		return fmt.Errorf("failed to update stock: %w", err)
+	}
+	return nil
+}
+
+func (uc *ProductUseCase) setTags(ctx context.Context, product *domain.Product, names []string) error {
+	tags, err := uc.tagRepo.FindOrCreate(ctx, domain.NormalizeTagNames(names))
+	if err != nil {
+		return fmt.Errorf("failed to save tags: %w", err)
+	}
+	return uc.productRepo.ReplaceTags(ctx, product, tags)
+}
 
-	return uc.productRepo.Delete(ctx, id)
+// auditedProduct is the product as recorded in the audit log: its own fields
+// and tag names. Variants are audited on their own and stock changes are in
+// the inventory ledger.
+func auditedProduct(product *domain.Product) any {
+	snapshot := *product
+	snapshot.Category = nil
+	snapshot.Tags = nil
+	snapshot.Variants = nil
+	snapshot.Images = nil
+	snapshot.StockLevels = nil
+	return struct {
+		domain.Product
+		Tags []string `json:"tags"`
+	}{snapshot, tagNames(product.Tags)}
 }
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrShipmentNotFound is returned for shipments missing from the order
+	ErrShipmentNotFound = errors.New("shipment not found")
+	// ErrOrderNotShippable is returned when shipping a cancelled or
+	// completed order
+	ErrOrderNotShippable = errors.New("order can't be shipped in its current status")
+)
+
+// CreateShipmentInput describes a shipment of an order. Without items the
+// shipment holds everything not shipped yet.
+type CreateShipmentInput struct {
+	Carrier        string
+	TrackingNumber string
+	Note           string
+	Items          []ShipmentItemInput
+}
+
+// ShipmentItemInput is the quantity of an order item put in a shipment
+type ShipmentItemInput struct {
+	OrderItemID uint
+	Quantity    int
+}
+
+// ShipmentStatusInput moves a shipment forward. Carrier and tracking
+// number, when given, replace the shipment's.
+type ShipmentStatusInput struct {
+	Status         domain.ShipmentStatus
+	Note           string
+	Carrier        string
+	TrackingNumber string
+}
+
+type ShipmentUseCase struct {
+	db *gorm.DB
+}
+
+func NewShipmentUseCase(db *gorm.DB) *ShipmentUseCase {
+	return &ShipmentUseCase{
+		db: db,
+	}
+}
+
+// CreateShipment packs a shipment of an order. Items can't ship more than
+// was ordered, counting earlier shipments.
+func (uc *ShipmentUseCase) CreateShipment(ctx context.Context, orderID uint, input CreateShipmentInput) (*domain.Shipment, error) {
+	var shipment *domain.Shipment
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		orderRepo := persistence.NewOrderRepository(tx)
+		shipmentRepo := persistence.NewShipmentRepository(tx)
+
+		// The lock keeps concurrent shipments from shipping the same items
+		order, err := orderRepo.LockByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if !order.CanShip() {
+			return ErrOrderNotShippable
+		}
+
+		shipments, err := shipmentRepo.FindByOrderID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		items, err := shipmentItems(order, domain.ShippedQuantities(shipments, false), input.Items)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		shipment = &domain.Shipment{
+			OrderID:        orderID,
+			Carrier:        strings.TrimSpace(input.Carrier),
+			TrackingNumber: strings.TrimSpace(input.TrackingNumber),
+			Status:         domain.ShipmentStatusPacked,
+			Items:          items,
+			Events: []domain.ShipmentEvent{
+				{Status: domain.ShipmentStatusPacked, Note: input.Note, CreatedAt: now},
+			},
+		}
+		if err := shipment.Validate(); err != nil {
+			return err
+		}
+
+		return shipmentRepo.Create(ctx, shipment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// shipmentItems checks the requested items against what is left to ship of
+// the order; no items means all that is left
+func shipmentItems(order *domain.Order, shipped map[uint]int, requested []ShipmentItemInput) ([]domain.ShipmentItem, error) {
+	var items []domain.ShipmentItem
+	if len(requested) == 0 {
+		for _, item := range order.Items {
+			if left := item.Quantity - shipped[item.ID]; left > 0 {
+				items = append(items, domain.ShipmentItem{OrderItemID: item.ID, Quantity: left})
+			}
+		}
+		if len(items) == 0 {
+			return nil, errors.New("all items of the order have already shipped")
+		}
+		return items, nil
+	}
+
+	ordered := make(map[uint]int, len(order.Items))
+	for _, item := range order.Items {
+		ordered[item.ID] = item.Quantity
+	}
+	for _, req := range requested {
+		quantity, ok := ordered[req.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("order item %d not found in order", req.OrderItemID)
+		}
+		if req.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for order item %d must be greater than 0", req.OrderItemID)
+		}
+		if left := quantity - shipped[req.OrderItemID]; req.Quantity > left {
+			return nil, fmt.Errorf("only %d of order item %d left to ship", left, req.OrderItemID)
+		}
+		shipped[req.OrderItemID] += req.Quantity
+		items = append(items, domain.ShipmentItem{OrderItemID: req.OrderItemID, Quantity: req.Quantity})
+	}
+	return items, nil
+}
+
+// UpdateShipmentStatus moves a shipment forward and records it in its
+// history. Once every item of the order is delivered, the order is
+// completed.
+func (uc *ShipmentUseCase) UpdateShipmentStatus(ctx context.Context, orderID, shipmentID uint, input ShipmentStatusInput) (*domain.Shipment, error) {
+	var shipment *domain.Shipment
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		orderRepo := persistence.NewOrderRepository(tx)
+		shipmentRepo := persistence.NewShipmentRepository(tx)
+
+		order, err := orderRepo.LockByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		shipment, err = shipmentRepo.FindByID(ctx, shipmentID)
+		if err != nil || shipment.OrderID != orderID {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShipmentNotFound
+			}
+			return err
+		}
+
+		if carrier := strings.TrimSpace(input.Carrier); carrier != "" {
+			shipment.Carrier = carrier
+		}
+		if tracking := strings.TrimSpace(input.TrackingNumber); tracking != "" {
+			shipment.TrackingNumber = tracking
+		}
+		if err := shipment.Advance(input.Status, input.Note, time.Now()); err != nil {
+			return err
+		}
+		if err := shipmentRepo.UpdateStatus(ctx, shipment, &shipment.Events[len(shipment.Events)-1]); err != nil {
+			return err
+		}
+
+		if shipment.Status != domain.ShipmentStatusDelivered || !order.CanShip() {
+			return nil
+		}
+		shipments, err := shipmentRepo.FindByOrderID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if !order.IsDelivered(shipments) {
+			return nil
+		}
+		before := *order
+		if err := orderRepo.UpdateStatus(ctx, orderID, domain.OrderStatusCompleted); err != nil {
+			return err
+		}
+		order.Status = domain.OrderStatusCompleted
+		return recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityOrder, orderID, &before, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// ListShipments retrieves the shipments of an order with their status
+// history. With userID set, the order must belong to that user.
+func (uc *ShipmentUseCase) ListShipments(ctx context.Context, orderID uint, userID *uint) ([]domain.Shipment, error) {
+	order, err := persistence.NewOrderRepository(uc.db).FindByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if userID != nil && order.UserID != *userID {
+		return nil, ErrOrderNotFound
+	}
+
+	return persistence.NewShipmentRepository(uc.db).FindByOrderID(ctx, orderID)
+}
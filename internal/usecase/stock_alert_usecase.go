@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/repository"
+)
+
+type StockAlertUseCase struct {
+	productRepo repository.ProductRepository
+	alertRepo   repository.StockAlertRepository
+	notifier    gateway.Notifier
+}
+
+func NewStockAlertUseCase(
+	productRepo repository.ProductRepository,
+	alertRepo repository.StockAlertRepository,
+	notifier gateway.Notifier,
+) *StockAlertUseCase {
+	return &StockAlertUseCase{
+		productRepo: productRepo,
+		alertRepo:   alertRepo,
+		notifier:    notifier,
+	}
+}
+
+// CheckProducts compares the stock of the products (and their variants) with
+// their reorder thresholds. An alert is sent when an item falls to its
+// threshold and it has no open alert yet; items back above it have their
+// alert resolved. Call it after the stock change is committed. Failures are
+// logged and never fail the change that triggered the check.
+func (uc *StockAlertUseCase) CheckProducts(ctx context.Context, productIDs ...uint) {
+	slices.Sort(productIDs)
+	for _, id := range slices.Compact(productIDs) {
+		if err := uc.checkProduct(ctx, id); err != nil {
+			slog.ErrorContext(ctx, "low-stock check failed", "product_id", id, "error", err)
+		}
+	}
+}
+
+func (uc *StockAlertUseCase) checkProduct(ctx context.Context, productID uint) error {
+	product, err := uc.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	// Products sold through variants only run low per variant
+	if !product.HasVariants() {
+		return uc.checkItem(ctx, product, nil, product.SKU, product.Stock)
+	}
+	if err := uc.alertRepo.Resolve(ctx, product.ID, nil); err != nil {
+		return err
+	}
+	for _, variant := range product.Variants {
+		if err := uc.checkItem(ctx, product, &variant.ID, variant.SKU, variant.Stock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *StockAlertUseCase) checkItem(ctx context.Context, product *domain.Product, variantID *uint, sku string, stock int) error {
+	if !product.IsLowStock(stock) {
+		return uc.alertRepo.Resolve(ctx, product.ID, variantID)
+	}
+
+	alert := &domain.StockAlert{
+		ProductID: product.ID,
+		VariantID: variantID,
+		Stock:     stock,
+		Threshold: product.ReorderThreshold,
+	}
+	opened, err := uc.alertRepo.Open(ctx, alert)
+	if err != nil || !opened {
+		return err
+	}
+
+	return uc.notifier.NotifyLowStock(ctx, gateway.LowStockAlert{
+		ProductID:   product.ID,
+		VariantID:   variantID,
+		SKU:         sku,
+		Name:        product.Name,
+		Stock:       stock,
+		Threshold:   product.ReorderThreshold,
+		TriggeredAt: alert.CreatedAt,
+	})
+}
+
+// ListLowStock reports every product and variant at or below its reorder
+// threshold, lowest stock first
+func (uc *StockAlertUseCase) ListLowStock(ctx context.Context) ([]repository.LowStockItem, error) {
+	return uc.alertRepo.FindLowStock(ctx)
+}
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+)
+
+func verificationEmail(user *domain.User, link string, ttl time.Duration) gateway.Email {
+	return gateway.Email{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Please confirm your email address by opening the link below:
+
+%s
+
+The link expires in %s. If you did not create an account, you can ignore this email.
+`, user.FullName, link, ttl),
+	}
+}
+
+func resetPasswordEmail(user *domain.User, link string, ttl time.Duration) gateway.Email {
+	return gateway.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to reset your password. Open the link below to choose a new one:
+
+%s
+
+The link expires in %s. If you did not request a password reset, you can ignore this email.
+`, user.FullName, link, ttl),
+	}
+}
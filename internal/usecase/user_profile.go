@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrIncorrectPassword is returned when a sensitive change is confirmed
+// with the wrong current password
+var ErrIncorrectPassword = errors.New("current password is incorrect")
+
+// UpdateProfileRequest holds the profile fields to change; nil fields are kept
+type UpdateProfileRequest struct {
+	FullName *string `json:"full_name"`
+	Email    *string `json:"email"`
+}
+
+// UpdateProfile changes a user's name and/or email. A new email address has
+// to be verified again before the user can place orders.
+func (uc *UserUseCase) UpdateProfile(ctx context.Context, userID uint, req UpdateProfileRequest) (*domain.User, error) {
+	var user *domain.User
+	emailChanged := false
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		var err error
+		if user, err = txUC.GetProfile(ctx, userID); err != nil {
+			return err
+		}
+		before := *user
+
+		if req.FullName != nil {
+			user.FullName = strings.TrimSpace(*req.FullName)
+		}
+
+		if req.Email != nil {
+			email := domain.NormalizeEmail(*req.Email)
+			if !strings.EqualFold(email, user.Email) {
+				exists, err := txUC.userRepo.EmailExists(ctx, email)
+				if err != nil {
+					return err
+				}
+				if exists {
+					return errors.New("email already registered")
+				}
+				emailChanged = true
+			}
+			user.Email = email
+		}
+
+		if err := user.Validate(); err != nil {
+			return err
+		}
+
+		if emailChanged {
+			user.VerifiedAt = nil
+		}
+
+		if err := txUC.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityUser, user.ID, &before, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if emailChanged {
+		if err := uc.sendVerificationEmail(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces the password after verifying the current one
+func (uc *UserUseCase) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		user, err := txUC.GetProfile(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+			return ErrIncorrectPassword
+		}
+
+		if len(newPassword) < 6 {
+			return errors.New("password must be at least 6 characters")
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		before := *user
+		user.Password = string(hashedPassword)
+
+		if err := txUC.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Outstanding reset links must not undo the change
+		if err := txUC.userTokenRepo.InvalidateForUser(ctx, user.ID, domain.UserTokenPasswordReset); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionPasswordChange, domain.AuditEntityUser, user.ID, &before, user)
+	})
+}
@@ -3,28 +3,144 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
 	"github.com/example/clean-arch-template/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// recentLoginAttemptsLimit is the number of attempts shown in the security view
+const recentLoginAttemptsLimit = 20
+
+// ErrInvalidCredentials is returned for any failed login, whether or not the
+// email exists, so the response never reveals which part was wrong
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// LoginThrottledError is returned when an email has too many recent failed
+// logins. RetryAfter tells the client when the next attempt will be accepted.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts, please try again later"
+}
+
+// LockoutPolicy controls throttling of failed logins per email. After each
+// failure the next attempt must wait DelayBase, doubling per further failure
+// up to DelayMax; after MaxFailedAttempts failures within FailureWindow the
+// email is locked for LockoutDuration since the last failure.
+type LockoutPolicy struct {
+	MaxFailedAttempts int
+	FailureWindow     time.Duration
+	LockoutDuration   time.Duration
+	DelayBase         time.Duration
+	DelayMax          time.Duration
+}
+
+// retryAfter returns how long the caller must still wait, or zero
+func (p LockoutPolicy) retryAfter(failures int, lastFailure, now time.Time) time.Duration {
+	if failures == 0 {
+		return 0
+	}
+
+	var wait time.Duration
+	if p.MaxFailedAttempts > 0 && failures >= p.MaxFailedAttempts {
+		wait = p.LockoutDuration
+	} else if p.DelayBase > 0 {
+		wait = p.DelayBase
+		for i := 1; i < failures && wait < p.DelayMax; i++ {
+			wait *= 2
+		}
+		if wait > p.DelayMax {
+			wait = p.DelayMax
+		}
+	}
+
+	if remaining := lastFailure.Add(wait).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// SecurityOverview summarizes the sign-in activity of a user
+type SecurityOverview struct {
+	RecentAttempts []domain.LoginAttempt `json:"recent_attempts"`
+	LockedUntil    *time.Time            `json:"locked_until,omitempty"`
+}
+
+// dummyPasswordHash is compared against when the email is unknown so a login
+// takes the same time whether or not the account exists
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	return hash
+})
+
+// UserUseCaseConfig holds the policies of the user use case
+type UserUseCaseConfig struct {
+	Lockout               LockoutPolicy
+	VerificationTokenTTL  time.Duration
+	PasswordResetTokenTTL time.Duration
+	// LinkBaseURL is the frontend URL that emailed links point to
+	LinkBaseURL string
+}
+
+// UserUseCase also receives the DB instance so that every change commits
+// together with its audit log entry
 type UserUseCase struct {
-	userRepo repository.UserRepository
+	db               *gorm.DB
+	userRepo         repository.UserRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	userTokenRepo    repository.UserTokenRepository
+	mailer           gateway.Mailer
+	cfg              UserUseCaseConfig
 }
 
-func NewUserUseCase(userRepo repository.UserRepository) *UserUseCase {
+func NewUserUseCase(
+	db *gorm.DB,
+	userRepo repository.UserRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	userTokenRepo repository.UserTokenRepository,
+	mailer gateway.Mailer,
+	cfg UserUseCaseConfig,
+) *UserUseCase {
 	return &UserUseCase{
-		userRepo: userRepo,
+		db:               db,
+		userRepo:         userRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		userTokenRepo:    userTokenRepo,
+		mailer:           mailer,
+		cfg:              cfg,
 	}
 }
 
-// Register creates a new user with hashed password
+// withTx returns a copy of the use case whose repositories work in tx
+func (uc *UserUseCase) withTx(tx *gorm.DB) *UserUseCase {
+	txUC := *uc
+	txUC.userRepo = persistence.NewUserRepository(tx)
+	txUC.loginAttemptRepo = persistence.NewLoginAttemptRepository(tx)
+	txUC.userTokenRepo = persistence.NewUserTokenRepository(tx)
+	return &txUC
+}
+
+// Register creates a new user with hashed password. Emails are stored
+// lowercased, and addresses that differ only in case are the same account.
 func (uc *UserUseCase) Register(ctx context.Context, email, fullName, password string) (*domain.User, error) {
-	// Check if user already exists
-	existingUser, _ := uc.userRepo.FindByEmail(ctx, email)
-	if existingUser != nil {
+	email = domain.NormalizeEmail(email)
+
+	// Check if user already exists (deleted users keep their email reserved)
+	exists, err := uc.userRepo.EmailExists(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
 		return nil, errors.New("email already registered")
 	}
 
@@ -38,6 +154,7 @@ func (uc *UserUseCase) Register(ctx context.Context, email, fullName, password s
 		Email:    email,
 		FullName: fullName,
 		Password: string(hashedPassword),
+		Role:     domain.UserRoleCustomer,
 	}
 
 	// Validate
@@ -46,29 +163,142 @@ func (uc *UserUseCase) Register(ctx context.Context, email, fullName, password s
 	}
 
 	// Create user
-	if err := uc.userRepo.Create(ctx, user); err != nil {
+	err = uc.db.Transaction(func(tx *gorm.DB) error {
+		if err := uc.withTx(tx).userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionCreate, domain.AuditEntityUser, user.ID, nil, user)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	// Registration succeeds even if the email can't be sent; the user can
+	// ask for a new verification email later
+	if err := uc.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	return user, nil
 }
 
-// Login authenticates a user
-func (uc *UserUseCase) Login(ctx context.Context, email, password string) (*domain.User, error) {
-	user, err := uc.userRepo.FindByEmail(ctx, email)
+// Login authenticates a user. Every attempt is recorded; repeated failures
+// for the same email are throttled and eventually locked out. Attempts for
+// the same email are serialized, so parallel guesses can't all pass the
+// checks on the same failure count.
+func (uc *UserUseCase) Login(ctx context.Context, email, password, ipAddress string) (*domain.User, error) {
+	key := domain.NormalizeEmail(email)
+
+	var user *domain.User
+	var loginErr error
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+		if err := txUC.loginAttemptRepo.LockEmail(ctx, key); err != nil {
+			return fmt.Errorf("failed to lock login attempts: %w", err)
+		}
+		var err error
+		user, loginErr, err = txUC.attemptLogin(ctx, key, email, password, ipAddress)
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid email or password")
+		return nil, err
+	}
+	if loginErr != nil {
+		return nil, loginErr
+	}
+	return user, nil
+}
+
+// attemptLogin checks and records one login attempt while the email is
+// locked. Rejected attempts are reported as loginErr so that their record
+// still commits; err is for failures to check or record them.
+func (uc *UserUseCase) attemptLogin(ctx context.Context, key, email, password, ipAddress string) (user *domain.User, loginErr, err error) {
+	now := time.Now()
+
+	// Step 1: Reject early if the email is throttled or locked
+	failures, lastFailure, err := uc.recentFailures(ctx, key, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wait := uc.cfg.Lockout.retryAfter(failures, lastFailure, now); wait > 0 {
+		if err := uc.recordAttempt(ctx, key, nil, ipAddress, domain.LoginOutcomeLocked); err != nil {
+			return nil, nil, err
 		}
+		return nil, &LoginThrottledError{RetryAfter: wait}, nil
+	}
+
+	// Step 2: Verify credentials, hashing even for unknown emails
+	user, err = uc.userRepo.FindByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	hash := dummyPasswordHash()
+	if user != nil {
+		hash = []byte(user.Password)
+	}
+	passwordErr := bcrypt.CompareHashAndPassword(hash, []byte(password))
+
+	if user == nil || passwordErr != nil {
+		var userID *uint
+		if user != nil {
+			userID = &user.ID
+		}
+		if err := uc.recordAttempt(ctx, key, userID, ipAddress, domain.LoginOutcomeFailure); err != nil {
+			return nil, nil, err
+		}
+		return nil, ErrInvalidCredentials, nil
+	}
+
+	if err := uc.recordAttempt(ctx, key, &user.ID, ipAddress, domain.LoginOutcomeSuccess); err != nil {
+		return nil, nil, err
+	}
+
+	return user, nil, nil
+}
+
+// UnlockUser clears the login lockout of a user (admin operation)
+func (uc *UserUseCase) UnlockUser(ctx context.Context, userID uint, ipAddress string) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		user, err := txUC.GetProfile(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := txUC.recordAttempt(ctx, domain.NormalizeEmail(user.Email), &user.ID, ipAddress, domain.LoginOutcomeUnlocked); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionUnlock, domain.AuditEntityUser, user.ID, nil, nil)
+	})
+}
+
+// GetSecurityOverview returns the recent sign-in activity of a user
+func (uc *UserUseCase) GetSecurityOverview(ctx context.Context, userID uint) (*SecurityOverview, error) {
+	user, err := uc.GetProfile(ctx, userID)
+	if err != nil {
 		return nil, err
 	}
+	key := domain.NormalizeEmail(user.Email)
 
-	// Compare password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, errors.New("invalid email or password")
+	attempts, err := uc.loginAttemptRepo.FindRecentByEmail(ctx, key, recentLoginAttemptsLimit)
+	if err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	overview := &SecurityOverview{RecentAttempts: attempts}
+
+	now := time.Now()
+	failures, lastFailure, err := uc.recentFailures(ctx, key, now)
+	if err != nil {
+		return nil, err
+	}
+	if wait := uc.cfg.Lockout.retryAfter(failures, lastFailure, now); wait > 0 {
+		lockedUntil := now.Add(wait)
+		overview.LockedUntil = &lockedUntil
+	}
+
+	return overview, nil
 }
 
 // GetProfile retrieves user profile by ID
@@ -82,3 +312,127 @@ func (uc *UserUseCase) GetProfile(ctx context.Context, userID uint) (*domain.Use
 	}
 	return user, nil
 }
+
+// DeleteUser soft-deletes a user (admin operation). The user can no longer
+// log in but can be restored.
+func (uc *UserUseCase) DeleteUser(ctx context.Context, userID uint) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		user, err := txUC.GetProfile(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := txUC.userRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+
+		deleted, err := txUC.userRepo.FindDeletedByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionDelete, domain.AuditEntityUser, userID, user, deleted)
+	})
+}
+
+// RestoreUser undoes a soft delete (admin operation)
+func (uc *UserUseCase) RestoreUser(ctx context.Context, userID uint) (*domain.User, error) {
+	var restored *domain.User
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		deleted, err := txUC.userRepo.FindDeletedByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("deleted user not found")
+			}
+			return err
+		}
+
+		if err := txUC.userRepo.Restore(ctx, userID); err != nil {
+			return err
+		}
+
+		if restored, err = txUC.GetProfile(ctx, userID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionRestore, domain.AuditEntityUser, userID, deleted, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeUser permanently removes a soft-deleted user (admin operation).
+// Users with orders must stay for accounting; delete their account instead.
+func (uc *UserUseCase) PurgeUser(ctx context.Context, userID uint) error {
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		user, err := txUC.userRepo.FindDeletedByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("deleted user not found")
+			}
+			return err
+		}
+
+		hasOrders, err := txUC.userRepo.HasOrders(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if hasOrders {
+			return errors.New("user has orders and cannot be purged")
+		}
+
+		if err := txUC.userTokenRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := txUC.loginAttemptRepo.DeleteByUser(ctx, userID, domain.NormalizeEmail(user.Email)); err != nil {
+			return err
+		}
+
+		if err := txUC.userRepo.Purge(ctx, userID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionPurge, domain.AuditEntityUser, userID, user, nil)
+	})
+}
+
+// recentFailures counts consecutive failed logins within the failure window,
+// stopping at the latest success or unlock
+func (uc *UserUseCase) recentFailures(ctx context.Context, email string, now time.Time) (int, time.Time, error) {
+	attempts, err := uc.loginAttemptRepo.FindByEmailSince(ctx, email, now.Add(-uc.cfg.Lockout.FailureWindow))
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to load login attempts: %w", err)
+	}
+
+	var failures int
+	var lastFailure time.Time
+	for _, attempt := range attempts {
+		if attempt.ResetsFailures() {
+			break
+		}
+		if attempt.Outcome == domain.LoginOutcomeFailure {
+			if failures == 0 {
+				lastFailure = attempt.CreatedAt
+			}
+			failures++
+		}
+	}
+	return failures, lastFailure, nil
+}
+
+func (uc *UserUseCase) recordAttempt(ctx context.Context, email string, userID *uint, ipAddress string, outcome domain.LoginOutcome) error {
+	attempt := &domain.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		Outcome:   outcome,
+	}
+	if err := uc.loginAttemptRepo.Create(ctx, attempt); err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return nil
+}
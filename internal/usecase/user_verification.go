@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/pkg/token"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidToken is returned for unknown, used or expired email tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrEmailNotVerified is returned when an action requires a verified email
+	ErrEmailNotVerified = errors.New("email address must be verified first")
+)
+
+// VerifyEmail consumes an email verification token and marks the user verified
+func (uc *UserUseCase) VerifyEmail(ctx context.Context, rawToken string) (*domain.User, error) {
+	var user *domain.User
+	err := uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		userToken, err := txUC.consumeToken(ctx, domain.UserTokenEmailVerification, rawToken)
+		if err != nil {
+			return err
+		}
+
+		if user, err = txUC.GetProfile(ctx, userToken.UserID); err != nil {
+			return err
+		}
+		if user.IsVerified() {
+			return nil
+		}
+
+		before := *user
+		now := time.Now()
+		user.VerifiedAt = &now
+		if err := txUC.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionUpdate, domain.AuditEntityUser, user.ID, &before, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResendVerificationEmail issues a new verification token, invalidating older ones
+func (uc *UserUseCase) ResendVerificationEmail(ctx context.Context, userID uint) error {
+	user, err := uc.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsVerified() {
+		return errors.New("email address is already verified")
+	}
+	return uc.sendVerificationEmail(ctx, user)
+}
+
+// RequestPasswordReset emails a reset link if the email belongs to a user.
+// It behaves identically for unknown emails so accounts can't be enumerated;
+// the email is sent in the background for the same reason.
+func (uc *UserUseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		rawToken, err := uc.issueToken(ctx, user.ID, domain.UserTokenPasswordReset, uc.cfg.PasswordResetTokenTTL)
+		if err != nil {
+			log.Printf("Failed to issue password reset token for user %d: %v", user.ID, err)
+			return
+		}
+
+		err = uc.mailer.Send(ctx, resetPasswordEmail(user, uc.link("/reset-password", rawToken), uc.cfg.PasswordResetTokenTTL))
+		if err != nil {
+			log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword consumes a password reset token and sets a new password
+func (uc *UserUseCase) ResetPassword(ctx context.Context, rawToken, newPassword string) error {
+	if len(newPassword) < 6 {
+		return errors.New("password must be at least 6 characters")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return uc.db.Transaction(func(tx *gorm.DB) error {
+		txUC := uc.withTx(tx)
+
+		userToken, err := txUC.consumeToken(ctx, domain.UserTokenPasswordReset, rawToken)
+		if err != nil {
+			return err
+		}
+
+		user, err := txUC.GetProfile(ctx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+		before := *user
+		user.Password = string(hashedPassword)
+
+		// Receiving the email proves ownership of the address
+		if !user.IsVerified() {
+			now := time.Now()
+			user.VerifiedAt = &now
+		}
+
+		if err := txUC.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Any other outstanding reset links are no longer valid
+		if err := txUC.userTokenRepo.InvalidateForUser(ctx, user.ID, domain.UserTokenPasswordReset); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, domain.AuditActionPasswordChange, domain.AuditEntityUser, user.ID, &before, user)
+	})
+}
+
+func (uc *UserUseCase) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	rawToken, err := uc.issueToken(ctx, user.ID, domain.UserTokenEmailVerification, uc.cfg.VerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+	return uc.mailer.Send(ctx, verificationEmail(user, uc.link("/verify-email", rawToken), uc.cfg.VerificationTokenTTL))
+}
+
+// issueToken invalidates previous tokens for the purpose and stores a new one
+func (uc *UserUseCase) issueToken(ctx context.Context, userID uint, purpose domain.UserTokenPurpose, ttl time.Duration) (string, error) {
+	rawToken, hash, err := token.NewOpaque()
+	if err != nil {
+		return "", err
+	}
+
+	if err := uc.userTokenRepo.InvalidateForUser(ctx, userID, purpose); err != nil {
+		return "", fmt.Errorf("failed to invalidate previous tokens: %w", err)
+	}
+
+	userToken := &domain.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := uc.userTokenRepo.Create(ctx, userToken); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return rawToken, nil
+}
+
+// consumeToken looks up a raw token and marks it used exactly once
+func (uc *UserUseCase) consumeToken(ctx context.Context, purpose domain.UserTokenPurpose, rawToken string) (*domain.UserToken, error) {
+	if rawToken == "" {
+		return nil, ErrInvalidToken
+	}
+
+	userToken, err := uc.userTokenRepo.FindByHash(ctx, purpose, token.HashOpaque(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !userToken.IsUsable(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	used, err := uc.userTokenRepo.MarkUsed(ctx, userToken.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidToken
+	}
+
+	return userToken, nil
+}
+
+func (uc *UserUseCase) link(path, rawToken string) string {
+	return uc.cfg.LinkBaseURL + path + "?token=" + rawToken
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// WarehouseInput holds the editable fields of a warehouse
+type WarehouseInput struct {
+	Code      string
+	Name      string
+	Address   string
+	Priority  int
+	IsDefault bool
+}
+
+type WarehouseUseCase struct {
+	warehouseRepo repository.WarehouseRepository
+}
+
+func NewWarehouseUseCase(warehouseRepo repository.WarehouseRepository) *WarehouseUseCase {
+	return &WarehouseUseCase{
+		warehouseRepo: warehouseRepo,
+	}
+}
+
+// CreateWarehouse creates a new warehouse
+func (uc *WarehouseUseCase) CreateWarehouse(ctx context.Context, input WarehouseInput) (*domain.Warehouse, error) {
+	warehouse := &domain.Warehouse{}
+	applyWarehouseInput(warehouse, input)
+
+	if err := warehouse.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.warehouseRepo.Create(ctx, warehouse); err != nil {
+		return nil, err
+	}
+
+	return warehouse, nil
+}
+
+// GetWarehouse retrieves a warehouse by ID
+func (uc *WarehouseUseCase) GetWarehouse(ctx context.Context, id uint) (*domain.Warehouse, error) {
+	warehouse, err := uc.warehouseRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("warehouse not found")
+		}
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+// ListWarehouses retrieves all warehouses in allocation order
+func (uc *WarehouseUseCase) ListWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	return uc.warehouseRepo.FindAll(ctx)
+}
+
+// UpdateWarehouse updates a warehouse. Making it the default replaces the
+// current default; the default warehouse can only be changed that way.
+// version, if set, must be the warehouse's current version.
+func (uc *WarehouseUseCase) UpdateWarehouse(ctx context.Context, id uint, version *uint, input WarehouseInput) (*domain.Warehouse, error) {
+	warehouse, err := uc.GetWarehouse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(warehouse.Version, version); err != nil {
+		return nil, err
+	}
+	if warehouse.IsDefault && !input.IsDefault {
+		return nil, errors.New("make another warehouse the default instead")
+	}
+
+	applyWarehouseInput(warehouse, input)
+	if err := warehouse.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.warehouseRepo.Update(ctx, warehouse); err != nil {
+		return nil, err
+	}
+
+	return warehouse, nil
+}
+
+// DeleteWarehouse deletes an empty warehouse other than the default one.
+// version, if set, must be the warehouse's current version.
+func (uc *WarehouseUseCase) DeleteWarehouse(ctx context.Context, id uint, version *uint) error {
+	warehouse, err := uc.GetWarehouse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(warehouse.Version, version); err != nil {
+		return err
+	}
+	if warehouse.IsDefault {
+		return errors.New("the default warehouse cannot be deleted")
+	}
+
+	hasStock, err := uc.warehouseRepo.HasStock(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasStock {
+		return errors.New("warehouse still holds stock; transfer it first")
+	}
+
+	return uc.warehouseRepo.Delete(ctx, id, warehouse.Version)
+}
+
+func applyWarehouseInput(warehouse *domain.Warehouse, input WarehouseInput) {
+	warehouse.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	warehouse.Name = strings.TrimSpace(input.Name)
+	warehouse.Address = input.Address
+	warehouse.Priority = input.Priority
+	warehouse.IsDefault = input.IsDefault
+}
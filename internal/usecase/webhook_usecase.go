@@ -0,0 +1,392 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/persistence"
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/pkg/webhook"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWebhookNotFound is returned for webhook subscriptions that don't exist
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned for deliveries that don't exist
+	// or belong to another subscription
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// jobDeliverWebhook makes one attempt to deliver a webhook
+const jobDeliverWebhook = "webhook.deliver"
+
+type deliverWebhookPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// Headers sent with every webhook besides the signature
+const (
+	webhookHeaderEvent    = "X-Webhook-Event"
+	webhookHeaderDelivery = "X-Webhook-Delivery"
+)
+
+// WebhookInput describes a webhook subscription. An empty Secret is
+// generated on create and kept on update; Active defaults to true.
+type WebhookInput struct {
+	URL         string
+	Secret      string
+	Events      []string
+	Description string
+	Active      *bool
+}
+
+// WebhookDeliveryPage is a page of deliveries, newest first
+type WebhookDeliveryPage struct {
+	Deliveries []domain.WebhookDelivery `json:"deliveries"`
+	Total      int64                    `json:"total"`
+	Limit      int                      `json:"limit"`
+	Offset     int                      `json:"offset"`
+}
+
+// webhookBody is the JSON body of every webhook
+type webhookBody struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// WebhookUseCase manages webhook subscriptions and delivers events to them.
+// Every published event is logged as one delivery per subscription and sent
+// from the job queue, retried until the subscriber acknowledges it with a
+// 2xx response.
+type WebhookUseCase struct {
+	db     *gorm.DB
+	sender gateway.WebhookSender
+	jobs   *JobQueue
+}
+
+// NewWebhookUseCase creates the use case and registers its jobs with jobs
+func NewWebhookUseCase(db *gorm.DB, sender gateway.WebhookSender, jobs *JobQueue) *WebhookUseCase {
+	uc := &WebhookUseCase{
+		db:     db,
+		sender: sender,
+		jobs:   jobs,
+	}
+	HandleJob(jobs, jobDeliverWebhook, uc.handleDeliver)
+	return uc
+}
+
+// ListWebhooks retrieves all subscriptions
+func (uc *WebhookUseCase) ListWebhooks(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return persistence.NewWebhookRepository(uc.db).FindAll(ctx)
+}
+
+// GetWebhook retrieves a subscription by ID
+func (uc *WebhookUseCase) GetWebhook(ctx context.Context, id uint) (*domain.WebhookSubscription, error) {
+	subscription, err := persistence.NewWebhookRepository(uc.db).FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// CreateWebhook subscribes a URL to events
+func (uc *WebhookUseCase) CreateWebhook(ctx context.Context, input WebhookInput) (*domain.WebhookSubscription, error) {
+	subscription := &domain.WebhookSubscription{Active: true}
+	if input.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		input.Secret = secret
+	}
+	if err := applyWebhookInput(subscription, input); err != nil {
+		return nil, err
+	}
+
+	if err := persistence.NewWebhookRepository(uc.db).Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return subscription, nil
+}
+
+// UpdateWebhook changes a subscription. Deliveries already logged keep
+// going to the subscription's current URL with its current secret. version,
+// if set, must be the subscription's current version.
+func (uc *WebhookUseCase) UpdateWebhook(ctx context.Context, id uint, version *uint, input WebhookInput) (*domain.WebhookSubscription, error) {
+	subscription, err := uc.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(subscription.Version, version); err != nil {
+		return nil, err
+	}
+	if input.Secret == "" {
+		input.Secret = subscription.Secret
+	}
+	if err := applyWebhookInput(subscription, input); err != nil {
+		return nil, err
+	}
+
+	if err := persistence.NewWebhookRepository(uc.db).Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return subscription, nil
+}
+
+// DeleteWebhook removes a subscription with its delivery log; deliveries
+// still queued are dropped. version, if set, must be the subscription's
+// current version.
+func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, id uint, version *uint) error {
+	subscription, err := uc.GetWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(subscription.Version, version); err != nil {
+		return err
+	}
+	return persistence.NewWebhookRepository(uc.db).Delete(ctx, id, subscription.Version)
+}
+
+// ListDeliveries retrieves a page of a subscription's delivery log,
+// optionally filtered by status and event type
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, filter repository.WebhookDeliveryFilter, limit, offset int) (*WebhookDeliveryPage, error) {
+	if _, err := uc.GetWebhook(ctx, filter.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	limit = max(1, min(limit, 500))
+	offset = max(0, offset)
+
+	deliveries, total, err := persistence.NewWebhookDeliveryRepository(uc.db).FindAll(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookDeliveryPage{Deliveries: deliveries, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// GetDelivery retrieves a delivery of a subscription with its attempts
+func (uc *WebhookUseCase) GetDelivery(ctx context.Context, subscriptionID, deliveryID uint) (*domain.WebhookDelivery, error) {
+	delivery, err := persistence.NewWebhookDeliveryRepository(uc.db).FindByID(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// Redeliver sends a delivery again, with a fresh set of retries, whatever
+// its outcome so far. A delivery that is still queued isn't queued twice.
+func (uc *WebhookUseCase) Redeliver(ctx context.Context, subscriptionID, deliveryID uint) (*domain.WebhookDelivery, error) {
+	delivery, err := uc.GetDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.db.Transaction(func(tx *gorm.DB) error {
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.DeliveredAt = nil
+		if err := persistence.NewWebhookDeliveryRepository(tx).UpdateStatus(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to update delivery: %w", err)
+		}
+		return uc.enqueueDelivery(ctx, tx, delivery.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Publish logs a delivery of event for every active subscription to its
+// type and queues them in tx, the transaction that makes the change the
+// event describes. Deliveries are thus sent if and only if it commits.
+func (uc *WebhookUseCase) Publish(ctx context.Context, tx *gorm.DB, event gateway.Event) error {
+	subscriptions, err := persistence.NewWebhookRepository(tx).FindActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find webhook subscriptions: %w", err)
+	}
+	subscriptions = slices.DeleteFunc(subscriptions, func(s domain.WebhookSubscription) bool {
+		return !s.Subscribes(event.Type)
+	})
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	// All subscribers receive the same event ID, so that they can tell
+	// redeliveries apart from new events
+	eventID, err := newWebhookEventID()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(webhookBody{ID: eventID, Type: event.Type, OccurredAt: event.OccurredAt, Data: event.Data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook: %w", err)
+	}
+
+	deliveryRepo := persistence.NewWebhookDeliveryRepository(tx)
+	for _, subscription := range subscriptions {
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      event.Type,
+			Payload:        body,
+			Status:         domain.WebhookDeliveryPending,
+		}
+		if err := deliveryRepo.Create(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to log webhook delivery: %w", err)
+		}
+		if err := uc.enqueueDelivery(ctx, tx, delivery.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *WebhookUseCase) enqueueDelivery(ctx context.Context, tx *gorm.DB, deliveryID uint) error {
+	_, err := uc.jobs.enqueue(ctx, tx, jobDeliverWebhook, deliverWebhookPayload{DeliveryID: deliveryID}, JobOptions{
+		UniqueKey: "webhook-delivery:" + strconv.FormatUint(uint64(deliveryID), 10),
+	})
+	return err
+}
+
+// handleDeliver makes one attempt to deliver a webhook and logs it. Failed
+// attempts return an error so the job queue retries them with backoff.
+func (uc *WebhookUseCase) handleDeliver(ctx context.Context, payload deliverWebhookPayload) error {
+	deliveryRepo := persistence.NewWebhookDeliveryRepository(uc.db)
+
+	delivery, err := deliveryRepo.FindByID(ctx, payload.DeliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The subscription was deleted
+			return fmt.Errorf("%w: %w", ErrJobPermanent, ErrWebhookDeliveryNotFound)
+		}
+		return err
+	}
+	if delivery.Status == domain.WebhookDeliverySucceeded {
+		return nil
+	}
+	subscription, err := persistence.NewWebhookRepository(uc.db).FindByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %w", ErrJobPermanent, ErrWebhookNotFound)
+		}
+		return err
+	}
+	if !subscription.Active {
+		delivery.Status = domain.WebhookDeliveryFailed
+		if err := deliveryRepo.UpdateStatus(ctx, delivery); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: subscription %d is disabled", ErrJobPermanent, subscription.ID)
+	}
+
+	now := time.Now()
+	header := http.Header{}
+	header.Set(webhookHeaderEvent, delivery.EventType)
+	header.Set(webhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	header.Set(webhook.HeaderSignature, webhook.Sign(subscription.Secret, now, delivery.Payload))
+
+	resp, sendErr := uc.sender.Send(ctx, gateway.WebhookRequest{URL: subscription.URL, Header: header, Body: delivery.Payload})
+	attempt := &domain.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		DurationMs: time.Since(now).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	} else {
+		attempt.StatusCode = resp.StatusCode
+		attempt.ResponseBody = strings.ToValidUTF8(resp.Body, "")
+	}
+
+	var deliveryErr error
+	if attempt.Succeeded() {
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	} else {
+		deliveryErr = sendErr
+		if deliveryErr == nil {
+			deliveryErr = fmt.Errorf("subscriber responded with status %d", attempt.StatusCode)
+		}
+		delivery.Status = domain.WebhookDeliveryRetrying
+		if job := runningJob(ctx); job == nil || job.Attempts >= job.MaxAttempts {
+			delivery.Status = domain.WebhookDeliveryFailed
+		}
+	}
+
+	// The attempt is logged even when the job's time is up
+	ctx = context.WithoutCancel(ctx)
+	err = uc.db.Transaction(func(tx *gorm.DB) error {
+		deliveryRepo := persistence.NewWebhookDeliveryRepository(tx)
+		if err := deliveryRepo.CreateAttempt(ctx, attempt); err != nil {
+			return err
+		}
+		return deliveryRepo.UpdateStatus(ctx, delivery)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log webhook attempt: %w", err)
+	}
+	return deliveryErr
+}
+
+// applyWebhookInput validates input and copies it onto subscription
+func applyWebhookInput(subscription *domain.WebhookSubscription, input WebhookInput) error {
+	events := make([]string, 0, len(input.Events))
+	for _, eventType := range input.Events {
+		eventType = strings.TrimSpace(eventType)
+		if !slices.Contains(gateway.EventTypes, eventType) {
+			return fmt.Errorf("unknown event type %q; must be one of %v", eventType, gateway.EventTypes)
+		}
+		if !slices.Contains(events, eventType) {
+			events = append(events, eventType)
+		}
+	}
+
+	subscription.URL = strings.TrimSpace(input.URL)
+	subscription.Secret = input.Secret
+	subscription.Events = events
+	subscription.Description = strings.TrimSpace(input.Description)
+	if input.Active != nil {
+		subscription.Active = *input.Active
+	}
+	return subscription.Validate()
+}
+
+// newWebhookSecret generates a signing secret for a subscription
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// newWebhookEventID generates the ID an event is delivered under
+func newWebhookEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/clean-arch-template/internal/domain"
+	"github.com/example/clean-arch-template/internal/gateway"
+	"github.com/example/clean-arch-template/internal/infrastructure/notify"
+	"github.com/example/clean-arch-template/internal/repository"
+	"github.com/example/clean-arch-template/pkg/webhook"
+	"gorm.io/gorm"
+)
+
+// testJobPolicy retries twice, one and then two minutes after a failure
+var testJobPolicy = JobPolicy{
+	Workers:      1,
+	PollInterval: time.Second,
+	MaxAttempts:  3,
+	BackoffBase:  time.Minute,
+	BackoffMax:   10 * time.Minute,
+	Timeout:      5 * time.Second,
+}
+
+// receivedWebhook is a request that reached the subscriber
+type receivedWebhook struct {
+	header   http.Header
+	body     []byte
+	verified bool
+}
+
+// subscriber is a webhook receiver answering with the scripted statuses in
+// turn, then 200, each with the body "attempt N"
+type subscriber struct {
+	*httptest.Server
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func newSubscriber(t *testing.T, secret string, statuses ...int) *subscriber {
+	t.Helper()
+	s := &subscriber{secret: secret, statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *subscriber) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, receivedWebhook{
+		header:   r.Header.Clone(),
+		body:     body,
+		verified: webhook.Verify(s.secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body, time.Minute, time.Now()),
+	})
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "attempt %d", len(s.received))
+}
+
+func (s *subscriber) requests() []receivedWebhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedWebhook(nil), s.received...)
+}
+
+// webhookTest wires the webhook use case to a subscriber. An in-memory
+// SQLite database stands in for Postgres; jobs are claimed by the test
+// instead of the queue's workers, so retries don't have to wait.
+type webhookTest struct {
+	db           *gorm.DB
+	jobs         *JobQueue
+	uc           *WebhookUseCase
+	subscriber   *subscriber
+	subscription *domain.WebhookSubscription
+}
+
+func newWebhookTest(t *testing.T, statuses ...int) *webhookTest {
+	t.Helper()
+	db := newTestDB(t, &domain.Job{}, &domain.WebhookSubscription{}, &domain.WebhookDelivery{}, &domain.WebhookDeliveryAttempt{})
+
+	jobs := NewJobQueue(db, testJobPolicy)
+	uc := NewWebhookUseCase(db, notify.NewWebhookSender(time.Second), jobs)
+
+	const secret = "whsec_test_0123456789"
+	sub := newSubscriber(t, secret, statuses...)
+	subscription, err := uc.CreateWebhook(context.Background(), WebhookInput{
+		URL:    sub.URL,
+		Secret: secret,
+		Events: []string{gateway.EventOrderPaid},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	return &webhookTest{db: db, jobs: jobs, uc: uc, subscriber: sub, subscription: subscription}
+}
+
+func (wt *webhookTest) publish(t *testing.T) {
+	t.Helper()
+	if err := wt.uc.Publish(context.Background(), wt.db, testEvent()); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func testEvent() gateway.Event {
+	return gateway.Event{
+		Type:       gateway.EventOrderPaid,
+		OccurredAt: time.Now(),
+		Data:       map[string]any{"order_id": 42},
+	}
+}
+
+// runJob claims the pending job due first, even if it isn't due yet, runs
+// it like a worker would and returns it as saved
+func (wt *webhookTest) runJob(t *testing.T) *domain.Job {
+	t.Helper()
+	return claim(t, wt.jobs, jobDeliverWebhook, time.Now().Add(24*time.Hour))
+}
+
+// delivery returns the subscription's only delivery with its attempts
+func (wt *webhookTest) delivery(t *testing.T) *domain.WebhookDelivery {
+	t.Helper()
+	ctx := context.Background()
+	page, err := wt.uc.ListDeliveries(ctx, repository.WebhookDeliveryFilter{SubscriptionID: wt.subscription.ID}, 10, 0)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(page.Deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(page.Deliveries))
+	}
+	delivery, err := wt.uc.GetDelivery(ctx, wt.subscription.ID, page.Deliveries[0].ID)
+	if err != nil {
+		t.Fatalf("GetDelivery: %v", err)
+	}
+	return delivery
+}
+
+func attemptStatuses(delivery *domain.WebhookDelivery) []int {
+	statuses := make([]int, len(delivery.Attempts))
+	for i, attempt := range delivery.Attempts {
+		statuses[i] = attempt.StatusCode
+	}
+	return statuses
+}
+
+// checkRetryAt fails unless job waits about delay before its next attempt
+func checkRetryAt(t *testing.T, job *domain.Job, delay time.Duration) {
+	t.Helper()
+	if job.Status != domain.JobStatusPending {
+		t.Fatalf("job status = %s, want pending", job.Status)
+	}
+	if wait := time.Until(job.RunAt); wait < delay-5*time.Second || wait > delay {
+		t.Errorf("job retries in %v, want %v", wait.Round(time.Second), delay)
+	}
+}
+
+func TestWebhookDeliveryRecordsResponse(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusAccepted)
+	wt.publish(t)
+
+	if job := wt.runJob(t); job.Status != domain.JobStatusCompleted {
+		t.Errorf("job status = %s, want completed", job.Status)
+	}
+
+	delivery := wt.delivery(t)
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.DeliveredAt == nil {
+		t.Errorf("delivery status = %s, delivered at %v; want succeeded", delivery.Status, delivery.DeliveredAt)
+	}
+	if len(delivery.Attempts) != 1 {
+		t.Fatalf("got %d attempts, want 1", len(delivery.Attempts))
+	}
+	attempt := delivery.Attempts[0]
+	if attempt.StatusCode != http.StatusAccepted || attempt.ResponseBody != "attempt 1" || attempt.Error != "" {
+		t.Errorf("attempt logged %d %q %q, want the subscriber's 202 and body", attempt.StatusCode, attempt.ResponseBody, attempt.Error)
+	}
+
+	requests := wt.subscriber.requests()
+	if len(requests) != 1 {
+		t.Fatalf("subscriber received %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if !req.verified {
+		t.Error("subscriber couldn't verify the signature")
+	}
+	if got := req.header.Get(webhookHeaderEvent); got != gateway.EventOrderPaid {
+		t.Errorf("%s = %q", webhookHeaderEvent, got)
+	}
+	if got := req.header.Get(webhookHeaderDelivery); got != strconv.FormatUint(uint64(delivery.ID), 10) {
+		t.Errorf("%s = %q, want the delivery ID %d", webhookHeaderDelivery, got, delivery.ID)
+	}
+
+	var body webhookBody
+	if err := json.Unmarshal(req.body, &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.ID != delivery.EventID || body.Type != gateway.EventOrderPaid {
+		t.Errorf("body has event %s %s, want %s %s", body.ID, body.Type, delivery.EventID, gateway.EventOrderPaid)
+	}
+}
+
+func TestWebhookPublishIsPartOfTheTransaction(t *testing.T) {
+	wt := newWebhookTest(t)
+	ctx := context.Background()
+
+	rollback := errors.New("order failed")
+	err := wt.db.Transaction(func(tx *gorm.DB) error {
+		if err := wt.uc.Publish(ctx, tx, testEvent()); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("Transaction: %v", err)
+	}
+
+	for _, model := range []any{&domain.WebhookDelivery{}, &domain.Job{}} {
+		var count int64
+		if err := wt.db.Model(model).Count(&count).Error; err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("%T: %d rows left by a rolled back change, want 0", model, count)
+		}
+	}
+
+	err = wt.db.Transaction(func(tx *gorm.DB) error {
+		return wt.uc.Publish(ctx, tx, testEvent())
+	})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if job := wt.runJob(t); job.Status != domain.JobStatusCompleted {
+		t.Errorf("job status = %s, want completed", job.Status)
+	}
+}
+
+func TestWebhookDeliveryRecordsConnectionErrors(t *testing.T) {
+	wt := newWebhookTest(t)
+	wt.subscriber.Close()
+	wt.publish(t)
+
+	checkRetryAt(t, wt.runJob(t), time.Minute)
+
+	delivery := wt.delivery(t)
+	if delivery.Status != domain.WebhookDeliveryRetrying {
+		t.Errorf("delivery status = %s, want retrying", delivery.Status)
+	}
+	if len(delivery.Attempts) != 1 || delivery.Attempts[0].Error == "" || delivery.Attempts[0].StatusCode != 0 {
+		t.Errorf("attempts = %+v, want one with the connection error", delivery.Attempts)
+	}
+}
+
+func TestWebhookDeliveryRetriesServerErrorsWithBackoff(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	wt.publish(t)
+
+	checkRetryAt(t, wt.runJob(t), time.Minute)
+	if delivery := wt.delivery(t); delivery.Status != domain.WebhookDeliveryRetrying {
+		t.Errorf("after attempt 1: delivery status = %s, want retrying", delivery.Status)
+	}
+
+	checkRetryAt(t, wt.runJob(t), 2*time.Minute)
+	if delivery := wt.delivery(t); delivery.Status != domain.WebhookDeliveryRetrying {
+		t.Errorf("after attempt 2: delivery status = %s, want retrying", delivery.Status)
+	}
+
+	if job := wt.runJob(t); job.Status != domain.JobStatusCompleted {
+		t.Errorf("job status = %s, want completed", job.Status)
+	}
+	delivery := wt.delivery(t)
+	if delivery.Status != domain.WebhookDeliverySucceeded {
+		t.Errorf("delivery status = %s, want succeeded", delivery.Status)
+	}
+	want := []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK}
+	if got := attemptStatuses(delivery); !slices.Equal(got, want) {
+		t.Errorf("attempt statuses = %v, want %v", got, want)
+	}
+	if body := delivery.Attempts[1].ResponseBody; body != "attempt 2" {
+		t.Errorf("attempt 2 logged body %q", body)
+	}
+
+	// Every attempt sends the same event, freshly signed
+	requests := wt.subscriber.requests()
+	for i, req := range requests {
+		if string(req.body) != string(requests[0].body) || !req.verified {
+			t.Errorf("request %d: body %s, verified %v", i+1, req.body, req.verified)
+		}
+	}
+}
+
+func TestWebhookDeliveryFailsWhenOutOfAttempts(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	wt.publish(t)
+
+	wt.runJob(t)
+	wt.runJob(t)
+	if job := wt.runJob(t); job.Status != domain.JobStatusDead {
+		t.Errorf("job status = %s, want dead", job.Status)
+	}
+
+	delivery := wt.delivery(t)
+	if delivery.Status != domain.WebhookDeliveryFailed || delivery.DeliveredAt != nil {
+		t.Errorf("delivery status = %s, delivered at %v; want failed", delivery.Status, delivery.DeliveredAt)
+	}
+	if len(delivery.Attempts) != testJobPolicy.MaxAttempts {
+		t.Errorf("got %d attempts, want %d", len(delivery.Attempts), testJobPolicy.MaxAttempts)
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	ctx := context.Background()
+	wt := newWebhookTest(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	wt.publish(t)
+	for i := 0; i < testJobPolicy.MaxAttempts; i++ {
+		wt.runJob(t)
+	}
+	failed := wt.delivery(t)
+	if failed.Status != domain.WebhookDeliveryFailed {
+		t.Fatalf("delivery status = %s, want failed", failed.Status)
+	}
+
+	if _, err := wt.uc.Redeliver(ctx, wt.subscription.ID+1, failed.ID); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+		t.Errorf("Redeliver for another subscription: err = %v, want ErrWebhookDeliveryNotFound", err)
+	}
+
+	// Redelivering twice before the job ran queues it once
+	for i := 0; i < 2; i++ {
+		delivery, err := wt.uc.Redeliver(ctx, wt.subscription.ID, failed.ID)
+		if err != nil {
+			t.Fatalf("Redeliver: %v", err)
+		}
+		if delivery.Status != domain.WebhookDeliveryPending {
+			t.Errorf("redelivered status = %s, want pending", delivery.Status)
+		}
+	}
+	var queued int64
+	wt.db.Model(&domain.Job{}).Where("finished_at IS NULL").Count(&queued)
+	if queued != 1 {
+		t.Errorf("%d jobs queued, want 1", queued)
+	}
+
+	if job := wt.runJob(t); job.Status != domain.JobStatusCompleted {
+		t.Errorf("job status = %s, want completed", job.Status)
+	}
+	delivery := wt.delivery(t)
+	if delivery.Status != domain.WebhookDeliverySucceeded {
+		t.Errorf("delivery status = %s, want succeeded", delivery.Status)
+	}
+
+	// A delivery that succeeded is sent again too
+	if _, err := wt.uc.Redeliver(ctx, wt.subscription.ID, delivery.ID); err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	wt.runJob(t)
+
+	delivery = wt.delivery(t)
+	want := []int{500, 500, 500, 200, 200}
+	if got := attemptStatuses(delivery); !slices.Equal(got, want) {
+		t.Errorf("attempt statuses = %v, want %v", got, want)
+	}
+	for i, req := range wt.subscriber.requests() {
+		if string(req.body) != string(delivery.Payload) {
+			t.Errorf("request %d sent %s, want the logged payload", i+1, req.body)
+		}
+	}
+}
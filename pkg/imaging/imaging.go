@@ -0,0 +1,98 @@
+// Package imaging inspects uploaded images and renders thumbnails.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+var (
+	// ErrUnsupportedFormat is returned for content that is not a supported image
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrTooManyPixels is returned for images whose dimensions exceed the
+	// limit passed to Decode
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// Extensions maps the supported content types to file extensions
+var Extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Image is a decoded upload together with its sniffed content type
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	image       image.Image
+}
+
+// Decode sniffs the content type from the data itself, ignoring whatever the
+// client claimed, and decodes the image. The dimensions are read from the
+// header first: a small file can declare an image that takes gigabytes to
+// decode, so images above maxPixels are rejected before decoding.
+func Decode(data []byte, maxPixels int64) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := Extensions[contentType]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	b := img.Bounds()
+	return &Image{ContentType: contentType, Width: b.Dx(), Height: b.Dy(), image: img}, nil
+}
+
+// Thumbnail scales the image down so its longest side is at most maxSide
+// pixels and encodes it. Images with possible transparency are encoded as
+// PNG, everything else as JPEG. The content type of the result is returned.
+func (img *Image) Thumbnail(maxSide int) ([]byte, string, error) {
+	w, h := img.Width, img.Height
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, max(1, h*maxSide/w)
+		} else {
+			w, h = max(1, w*maxSide/h), maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img.image, img.image.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	switch img.ContentType {
+	case "image/png", "image/gif", "image/webp":
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	default:
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+}
@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader returns a tiny PNG that only declares its dimensions, like a
+// decompression bomb would
+func pngHeader(w, h uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // RGBA
+
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	img, err := Decode(encodePNG(t, 64, 32), 64*32)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if img.ContentType != "image/png" || img.Width != 64 || img.Height != 32 {
+		t.Errorf("got %s %dx%d, want image/png 64x32", img.ContentType, img.Width, img.Height)
+	}
+}
+
+func TestDecodeRejectsTooManyPixels(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"header only", pngHeader(100_000, 100_000)},
+		{"one pixel over", encodePNG(t, 64, 33)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data, 64*32)
+			if !errors.Is(err, ErrTooManyPixels) {
+				t.Errorf("err = %v, want ErrTooManyPixels", err)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsUnsupportedContent(t *testing.T) {
+	_, err := Decode([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), 1<<20)
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("err = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img, err := Decode(encodePNG(t, 64, 32), 1<<20)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	data, contentType, err := img.Thumbnail(16)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	if contentType != "image/png" {
+		t.Errorf("content type = %s, want image/png", contentType)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if cfg.Width != 16 || cfg.Height != 8 {
+		t.Errorf("thumbnail is %dx%d, want 16x8", cfg.Width, cfg.Height)
+	}
+}
@@ -0,0 +1,68 @@
+// Package mergepatch applies JSON Merge Patches (RFC 7396): a patch lists the
+// members to change, null removes a member and anything absent is kept.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ContentType is the media type of merge patch documents
+const ContentType = "application/merge-patch+json"
+
+// ErrInvalidPatch is returned for patches that aren't valid JSON
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// Apply returns doc with patch applied. Objects are merged member by member;
+// any other patch value, arrays included, replaces the target as a whole.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target any
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := decode(doc, &target); err != nil {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+	}
+
+	var p any
+	if err := decode(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(merge(target, p))
+}
+
+// decode reads exactly one JSON value, keeping numbers as written
+func decode(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("trailing data after JSON value")
+	}
+	return nil
+}
+
+// merge implements the MergePatch function of RFC 7396, section 2
+func merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,90 @@
+package mergepatch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		// RFC 7396, appendix A
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"remove one of two", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaced by string", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"string replaced by array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested objects merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays replaced whole", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"array document", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"array patch", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"null patch", `{"a":"foo"}`, `null`, `null`},
+		{"string patch", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null member kept", `{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{"object patch on array", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"null inside new object", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+
+		{"absent members kept", `{"a":1,"b":{"c":2}}`, `{}`, `{"a":1,"b":{"c":2}}`},
+		{"empty document", ``, `{"a":null,"b":1}`, `{"b":1}`},
+		{"numbers kept as written", `{"n":12345678901234567890}`, `{"m":0.1}`, `{"n":12345678901234567890,"m":0.1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !sameJSON(t, got, []byte(tt.want)) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsInvalidPatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"empty", ``},
+		{"not JSON", `{"a":`},
+		{"trailing data", `{"a":1} {"b":2}`},
+		{"trailing garbage", `{"a":1}x`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply([]byte(`{"a":0}`), []byte(tt.patch)); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("err = %v, want ErrInvalidPatch", err)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsInvalidDocuments(t *testing.T) {
+	_, err := Apply([]byte(`{"a":`), []byte(`{}`))
+	if err == nil || errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("err = %v, want an invalid document error", err)
+	}
+}
+
+// sameJSON reports whether a and b hold the same JSON value, whatever the
+// order of object members
+func sameJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := decode(a, &va); err != nil {
+		t.Fatalf("decode %s: %v", a, err)
+	}
+	if err := decode(b, &vb); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
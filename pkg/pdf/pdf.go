@@ -0,0 +1,234 @@
+// Package pdf writes simple text documents as PDF: text in the standard
+// Helvetica fonts, lines and filled rectangles. Fonts are not embedded, so
+// documents stay small and need nothing but the standard library.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page sizes in points (1/72 inch)
+var (
+	A4     = Size{Width: 595.28, Height: 841.89}
+	Letter = Size{Width: 612, Height: 792}
+)
+
+// Size is the width and height of a page in points
+type Size struct {
+	Width  float64
+	Height float64
+}
+
+// Font is one of the standard fonts every PDF reader provides
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = [...]string{
+	Helvetica:     "Helvetica",
+	HelveticaBold: "Helvetica-Bold",
+}
+
+// Document is a PDF being built page by page
+type Document struct {
+	size  Size
+	title string
+	pages []*Page
+}
+
+// New starts an empty document with pages of size
+func New(size Size) *Document {
+	return &Document{size: size}
+}
+
+// SetTitle sets the title readers show for the document
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// Size returns the page size of the document
+func (d *Document) Size() Size {
+	return d.size
+}
+
+// AddPage appends a blank page and returns it
+func (d *Document) AddPage() *Page {
+	page := &Page{height: d.size.Height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Page is a page of a document. Coordinates are in points from the top left
+// corner of the page, y growing downwards.
+type Page struct {
+	height  float64
+	content bytes.Buffer
+}
+
+// Text draws text with its baseline starting at x, y
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		font+1, number(size), number(x), number(p.height-y), escape(encode(text)))
+}
+
+// TextRight draws text ending at x, e.g. for right-aligned amounts
+func (p *Page) TextRight(x, y float64, font Font, size float64, text string) {
+	p.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// Line draws a line of width from x1, y1 to x2, y2
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		number(width), number(x1), number(p.height-y1), number(x2), number(p.height-y2))
+}
+
+// FillRect fills the rectangle with top left corner x, y in a shade of gray
+// from 0 (black) to 1 (white)
+func (p *Page) FillRect(x, y, width, height, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n",
+		number(gray), number(x), number(p.height-y-height), number(width), number(height))
+}
+
+// TextWidth returns the width of text in points
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := helveticaWidths
+	if font == HelveticaBold {
+		widths = helveticaBoldWidths
+	}
+
+	var units int
+	for _, c := range encode(text) {
+		if c >= 32 && c <= 126 {
+			units += widths[c-32]
+		} else {
+			units += defaultWidth
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	out := &writer{w: w}
+
+	// Objects 1-4 are the catalog, the page tree and the two fonts; each
+	// page is followed by its content stream
+	pageIDs := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	infoID := 5 + 2*len(d.pages)
+
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	out.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIDs, " "), len(d.pages)))
+	for i, name := range fontNames {
+		out.object(3+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	for i, page := range d.pages {
+		id := 5 + 2*i
+		out.object(id, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			number(d.size.Width), number(d.size.Height), id+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return out.n, err
+		}
+		if err := zw.Close(); err != nil {
+			return out.n, err
+		}
+		out.stream(id+1, compressed.Bytes())
+	}
+	out.object(infoID, fmt.Sprintf("<< /Title (%s) /Producer (clean-arch-template) >>", escape(encode(d.title))))
+
+	xref := out.n
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", infoID+1)
+	for _, offset := range out.offsets {
+		out.printf("%010d 00000 n \n", offset)
+	}
+	out.printf("trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", infoID+1, infoID, xref)
+	return out.n, out.err
+}
+
+// writer tracks the offsets of objects for the cross-reference table and
+// keeps the first error
+type writer struct {
+	w       io.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
+
+// object writes object id; objects must be written in ID order
+func (w *writer) object(id int, body string) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) stream(id int, data []byte) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", id, len(data), data)
+}
+
+// number formats a coordinate without needless digits
+func number(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", v), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// winAnsi maps the characters of WinAnsiEncoding that differ from Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encode converts text to WinAnsiEncoding, the encoding of the standard
+// fonts; characters it lacks become "?"
+func encode(text string) []byte {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b = append(b, byte(r))
+		case winAnsi[r] != 0:
+			b = append(b, winAnsi[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// escape makes encoded text safe inside a PDF string literal
+func escape(b []byte) string {
+	var s strings.Builder
+	for _, c := range b {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			s.WriteByte('\\')
+			s.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&s, "\\%03o", c)
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return s.String()
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps buckets in process memory. It is suitable for a single
+// instance; use RedisStore to share limits between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	now     func() time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	expires time.Time
+}
+
+// NewMemoryStore creates an in-memory store and starts a janitor that drops
+// buckets which have been idle long enough to be full again. The janitor
+// stops when ctx is cancelled.
+func NewMemoryStore(ctx context.Context, cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+	go s.janitor(ctx, cleanupInterval)
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(policy.Limit), updated: now}}
+		s.buckets[key] = b
+	}
+
+	res := b.take(now, policy)
+	b.expires = now.Add(res.ResetAfter)
+	return res, nil
+}
+
+func (s *MemoryStore) janitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			now := s.now()
+			for key, b := range s.buckets {
+				if now.After(b.expires) {
+					delete(s.buckets, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
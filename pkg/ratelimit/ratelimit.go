@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy describes a token bucket: the bucket holds at most Limit tokens and
+// refills completely over Period, i.e. at Limit/Period tokens per unit time.
+// Each request consumes one token.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token is available; zero when allowed
+}
+
+// Store keeps token buckets keyed by an arbitrary string. Implementations
+// must make Take atomic per key so concurrent requests can't overspend.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// bucket is the token bucket state shared by the store implementations
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket for the time elapsed since its last update and
+// then tries to consume one token
+func (b *bucket) take(now time.Time, policy Policy) Result {
+	rate := float64(policy.Limit) / float64(policy.Period)
+
+	elapsed := now.Sub(b.updated)
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(policy.Limit), b.tokens+float64(elapsed)*rate)
+	}
+	b.updated = now
+
+	res := Result{Limit: policy.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	res.ResetAfter = time.Duration(math.Ceil((float64(policy.Limit) - b.tokens) / rate))
+	return res
+}
@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// clock is a manually advanced time source shared with a store
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newMemoryStore(_ *testing.T, clk *clock) Store {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket), now: clk.Now}
+}
+
+// newRedisStore runs the store against miniredis, a local Redis-compatible
+// server with Lua scripting
+func newRedisStore(t *testing.T, clk *clock) Store {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	store := NewRedisStore(client, "test:")
+	store.now = clk.Now
+	return store
+}
+
+var stores = []struct {
+	name string
+	new  func(t *testing.T, clk *clock) Store
+}{
+	{"memory", newMemoryStore},
+	{"redis", newRedisStore},
+}
+
+func TestTakeRefillsAtPolicyRate(t *testing.T) {
+	// One token per second, three at most
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+
+	steps := []struct {
+		advance time.Duration
+		want    Result
+	}{
+		{0, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+		{0, Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+		{0, Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+		{0, Result{Allowed: false, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second, RetryAfter: time.Second}},
+		{500 * time.Millisecond, Result{Allowed: false, Limit: 3, Remaining: 0, ResetAfter: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{500 * time.Millisecond, Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+		// Refills never exceed the limit
+		{time.Minute, Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+	}
+
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			store := tt.new(t, clk)
+
+			for i, step := range steps {
+				clk.Advance(step.advance)
+				got, err := store.Take(context.Background(), "key", policy)
+				if err != nil {
+					t.Fatalf("step %d: Take: %v", i, err)
+				}
+				if !sameResult(got, step.want) {
+					t.Errorf("step %d: got %+v, want %+v", i, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTakeKeepsKeysApart(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 1, Period: time.Minute}
+
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.new(t, &clock{now: time.Now()})
+
+			for _, key := range []string{"a", "b"} {
+				res, err := store.Take(context.Background(), key, policy)
+				if err != nil {
+					t.Fatalf("Take(%s): %v", key, err)
+				}
+				if !res.Allowed {
+					t.Errorf("Take(%s) was limited by another key's bucket", key)
+				}
+			}
+		})
+	}
+}
+
+func TestTakeIsAtomic(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 10, Period: time.Hour}
+
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.new(t, &clock{now: time.Now()})
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			allowed := 0
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					res, err := store.Take(context.Background(), "key", policy)
+					if err != nil {
+						t.Errorf("Take: %v", err)
+						return
+					}
+					if res.Allowed {
+						mu.Lock()
+						allowed++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if allowed != policy.Limit {
+				t.Errorf("%d concurrent requests allowed, want %d", allowed, policy.Limit)
+			}
+		})
+	}
+}
+
+func TestRedisStoreExpiresFullBuckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	store := NewRedisStore(client, "test:")
+	store.now = (&clock{now: time.Now()}).Now
+
+	policy := Policy{Name: "test", Limit: 2, Period: 10 * time.Second}
+	for i := 0; i < 2; i++ {
+		if _, err := store.Take(context.Background(), "key", policy); err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+	}
+
+	// The key lives exactly until the bucket would be full again
+	if ttl := server.TTL("test:key"); ttl != 10*time.Second {
+		t.Errorf("TTL = %v, want 10s", ttl)
+	}
+	server.FastForward(10 * time.Second)
+	if server.Exists("test:key") {
+		t.Error("bucket still stored after it refilled")
+	}
+}
+
+// sameResult compares results, allowing the stores' rounding to whole
+// milliseconds and floating point error in durations
+func sameResult(got, want Result) bool {
+	near := func(a, b time.Duration) bool {
+		d := a - b
+		return d > -time.Millisecond && d < time.Millisecond
+	}
+	return got.Allowed == want.Allowed &&
+		got.Limit == want.Limit &&
+		got.Remaining == want.Remaining &&
+		near(got.ResetAfter, want.ResetAfter) &&
+		near(got.RetryAfter, want.RetryAfter)
+}
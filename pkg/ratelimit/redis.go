@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript implements the same token bucket as bucket.take atomically on
+// the Redis server. State is a hash of {tokens, updated (ms)} that expires
+// once the bucket would be full again.
+//
+// KEYS[1] bucket key; ARGV: limit, period (ms), now (ms)
+// Returns {allowed, remaining, reset_after_ms, retry_after_ms}
+var takeScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = limit / period
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil then
+	tokens = limit
+	updated = now
+end
+
+local elapsed = now - updated
+if elapsed > 0 then
+	tokens = math.min(limit, tokens + elapsed * rate)
+end
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) / rate)
+end
+
+local reset_after = math.ceil((limit - tokens) / rate)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", now)
+redis.call("PEXPIRE", KEYS[1], math.max(reset_after, 1))
+
+return {allowed, math.floor(tokens), reset_after, retry_after}
+`)
+
+// RedisStore keeps buckets in Redis (or any server speaking the Redis
+// protocol with Lua scripting, such as Valkey, KeyDB or miniredis) so that
+// limits are shared by all instances of the service.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	vals, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		policy.Limit, policy.Period.Milliseconds(), s.now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    vals[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(vals[1]),
+		ResetAfter: time.Duration(vals[2]) * time.Millisecond,
+		RetryAfter: time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
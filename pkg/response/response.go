@@ -61,3 +61,61 @@ func Unauthorized(c *fiber.Ctx, message string) error {
 		Error:   message,
 	})
 }
+
+// TooManyRequests sends a rate limit exceeded error response
+func TooManyRequests(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// Forbidden sends a forbidden error response
+func Forbidden(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusForbidden).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// Conflict sends a conflict error response
+func Conflict(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusConflict).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// PreconditionFailed sends a precondition failed error response, e.g. for an
+// If-Match header that doesn't match
+func PreconditionFailed(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusPreconditionFailed).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// PreconditionRequired sends a precondition required error response, e.g.
+// for a conditional write without an If-Match header
+func PreconditionRequired(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusPreconditionRequired).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// PayloadTooLarge sends a request entity too large error response
+func PayloadTooLarge(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
+
+// UnsupportedMediaType sends an unsupported media type error response
+func UnsupportedMediaType(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusUnsupportedMediaType).JSON(Response{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,94 @@
+// Package scheduler runs recurring background jobs. With a Locker shared by
+// all instances of a service, each run of a job happens on one instance
+// only.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is work repeated every Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Locker provides locks shared by all instances of a service
+type Locker interface {
+	// TryLock takes the lock called name without waiting. ok is false when
+	// another holder has it; otherwise unlock must be called to release it.
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Scheduler runs jobs on their intervals
+type Scheduler struct {
+	locker Locker
+	logger *slog.Logger
+	jobs   []Job
+	wg     sync.WaitGroup
+}
+
+// New creates a scheduler whose jobs run under locks from locker. A nil
+// locker runs every job on every instance.
+func New(locker Locker, logger *slog.Logger) *Scheduler {
+	return &Scheduler{locker: locker, logger: logger}
+}
+
+// Add registers a job; jobs must be added before Start
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job once per interval, the first time one interval after
+// starting, until ctx is done. It returns immediately.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.run(ctx, job)
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until the jobs stopped after their context is done, letting
+// runs in progress finish
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	if s.locker != nil {
+		unlock, ok, err := s.locker.TryLock(ctx, job.Name)
+		if err != nil {
+			s.logger.Error("failed to lock job", "job", job.Name, "error", err)
+			return
+		}
+		if !ok {
+			// Another instance is running the job
+			s.logger.Debug("job already running elsewhere", "job", job.Name)
+			return
+		}
+		defer unlock()
+	}
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		s.logger.Error("job failed", "job", job.Name, "duration", time.Since(start), "error", err)
+		return
+	}
+	s.logger.Debug("job finished", "job", job.Name, "duration", time.Since(start))
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_0123456789abcdef"
+
+// newReceiver starts a subscriber that answers 204 to requests signed with
+// testSecret and 401 to anything else
+func newReceiver(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !Verify(testSecret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, 5*time.Minute, time.Now()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url string, timestamp time.Time, signature string, body []byte) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, signature)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	srv := newReceiver(t)
+	body := []byte(`{"id":"evt_1","type":"order.paid","data":{"order_id":42}}`)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		signature string
+		body      []byte
+		want      int
+	}{
+		{"signed", now, Sign(testSecret, now, body), body, http.StatusNoContent},
+		{"tampered body", now, Sign(testSecret, now, body), []byte(`{"id":"evt_1","type":"order.paid","data":{"order_id":43}}`), http.StatusUnauthorized},
+		{"other secret", now, Sign("whsec_somebody_else", now, body), body, http.StatusUnauthorized},
+		{"timestamp changed", now.Add(time.Second), Sign(testSecret, now, body), body, http.StatusUnauthorized},
+		{"replayed later", now.Add(-10 * time.Minute), Sign(testSecret, now.Add(-10*time.Minute), body), body, http.StatusUnauthorized},
+		{"missing prefix", now, Sign(testSecret, now, body)[len(signaturePrefix):], body, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(t, srv.URL, tt.timestamp, tt.signature, tt.body); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsMalformedTimestamp(t *testing.T) {
+	body := []byte(`{}`)
+	now := time.Now()
+	if Verify(testSecret, "yesterday", Sign(testSecret, now, body), body, time.Minute, now) {
+		t.Error("Verify accepted a timestamp that isn't a number")
+	}
+}